@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// AuditRecord describes a command applied to the cluster.
+type AuditRecord struct {
+	// Index and Term are those of the raft log entry of the command.
+	Index uint64
+	Term  uint64
+	// Time is when the leader appended the command, by its clock.
+	Time      time.Time
+	Op        string
+	Namespace string
+	Key       string
+	// Keys are the keys written by the commands that write several, such
+	// as imports and transactions, which have no Key.
+	Keys []string
+	// Principal is the subject of the client certificate of the caller,
+	// empty without one. ClientAddr is the address of the caller, empty
+	// for the commands of the leader itself.
+	Principal  string
+	ClientAddr string
+	// Result is how the command ended, such as "ok" or "condition_failed".
+	Result string
+}
+
+// NewAuditRecord converts a record of the audit log or of an audit file.
+func NewAuditRecord(r *raftdv1.AuditRecord) AuditRecord {
+	return AuditRecord{
+		Index:      r.Index,
+		Term:       r.Term,
+		Time:       time.Unix(0, r.Time),
+		Op:         r.Op,
+		Namespace:  r.Namespace,
+		Key:        r.Key,
+		Keys:       r.Keys,
+		Principal:  r.Principal,
+		ClientAddr: r.ClientAddr,
+		Result:     strings.ToLower(strings.TrimPrefix(r.Code.String(), "APPLY_CODE_")),
+	}
+}
+
+// TailAudit calls fn with the records of the replicated audit log after
+// index after, or with the last ones of them when last is positive, in
+// index order. With follow, it goes on with every record applied later
+// until ctx is done or fn fails. A broken stream is reopened, possibly on
+// another node, after the last record seen.
+func (c *Client) TailAudit(ctx context.Context, after uint64, last int64, follow bool, fn func(AuditRecord) error) error {
+	return c.read(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		stream, err := raftdv1.NewAuditServiceClient(conn).TailAudit(ctx, &raftdv1.TailAuditRequest{
+			AfterIndex: after,
+			Last:       last,
+			Follow:     follow,
+		})
+		if err != nil {
+			return err
+		}
+		for {
+			record, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := fn(NewAuditRecord(record)); err != nil {
+				return err
+			}
+			after, last = record.Index, 0
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// Change is the write of a key at a revision, or its deletion.
+type Change struct {
+	Revision  uint64
+	Namespace string
+	Key       string
+	Value     []byte
+	Deleted   bool
+}
+
+// SubscribeChanges calls fn with the batches of changes after the
+// checkpoint of consumer, in revision order, and acknowledges every batch
+// fn returns nil for, until ctx is done or fn fails. A broken stream is
+// reopened, possibly on another node, from the checkpoint, so a batch may
+// be passed to fn again if its acknowledgement was lost.
+func (c *Client) SubscribeChanges(ctx context.Context, consumer string, fn func([]Change) error) error {
+	return c.read(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		stream, err := raftdv1.NewChangeServiceClient(conn).SubscribeChanges(ctx, &raftdv1.SubscribeChangesRequest{Consumer: consumer})
+		if err != nil {
+			return err
+		}
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				return err
+			}
+
+			changes := make([]Change, 0, len(resp.Changes))
+			for _, change := range resp.Changes {
+				changes = append(changes, Change{
+					Revision:  change.Revision,
+					Namespace: change.Namespace,
+					Key:       change.Key,
+					Value:     change.Value,
+					Deleted:   change.Deleted,
+				})
+			}
+			if err := fn(changes); err != nil {
+				return err
+			}
+			if len(changes) > 0 {
+				if err := c.AckChanges(ctx, consumer, changes[len(changes)-1].Revision); err != nil {
+					return err
+				}
+			}
+		}
+	})
+}
+
+// AckChanges moves the checkpoint of consumer forward to revision. A new
+// consumer can skip the changes applied so far by acknowledging the
+// current revision.
+func (c *Client) AckChanges(ctx context.Context, consumer string, revision uint64) error {
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewChangeServiceClient(conn).AckChanges(ctx, &raftdv1.AckChangesRequest{Consumer: consumer, Revision: revision})
+		return err
+	})
+}
+
+// RemoveChangeConsumer drops the checkpoint of a consumer that will not
+// come back, so that it no longer holds back compaction. It fails with
+// codes.NotFound for a consumer without one, and like Join is not retried
+// once it may have reached the leader.
+func (c *Client) RemoveChangeConsumer(ctx context.Context, consumer string) error {
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewChangeServiceClient(conn).RemoveChangeConsumer(ctx, &raftdv1.RemoveChangeConsumerRequest{Consumer: consumer})
+		return sentOnce(err)
+	})
+}
@@ -0,0 +1,705 @@
+// Package client is a Go client for raftd clusters.
+//
+// A Client is given the gRPC endpoints of some or all nodes. Writes are sent
+// to the leader, which is discovered with Status and rediscovered whenever a
+// node answers that it is not the leader. Reads go to any reachable node.
+// Calls that fail because a node is unavailable or not the leader are
+// retried against other nodes with exponential backoff. Calls rejected by
+// the rate limits of a server are retried after the delay it asks for.
+//
+// Calls are traced with the global OpenTelemetry tracer provider and
+// propagator, so the spans of an application continue on the servers.
+package client
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+const (
+	defaultMaxRetries = 5
+	defaultBackoff    = 50 * time.Millisecond
+	defaultMaxBackoff = 2 * time.Second
+
+	restoreChunkSize = 64 * 1024
+
+	// clientIDHeader identifies the client to the per client rate limits
+	// of the servers that know neither its certificate nor its address.
+	clientIDHeader = "x-client-id"
+)
+
+var (
+	// ErrNoEndpoints is returned by New when Config has no endpoints.
+	ErrNoEndpoints = errors.New("raftd: no endpoints")
+
+	// ErrNotFound is returned by Get when the key does not exist.
+	ErrNotFound = errors.New("raftd: key not found")
+
+	// ErrNoLeader is returned when no endpoint knows of a leader.
+	ErrNoLeader = errors.New("raftd: no leader")
+
+	// ErrNamespaceNotFound is returned by key operations and namespace
+	// lookups when the namespace does not exist.
+	ErrNamespaceNotFound = errors.New("raftd: namespace not found")
+)
+
+// Config configures a Client.
+type Config struct {
+	// Endpoints are the gRPC addresses of the cluster nodes.
+	Endpoints []string
+
+	// Namespace scopes the key operations of the client. The default
+	// namespace is used when empty.
+	Namespace string
+
+	// DialOptions are passed to grpc.NewClient. Insecure transport
+	// credentials are used when empty.
+	DialOptions []grpc.DialOption
+
+	// MaxRetries is how many times a failed call is retried. Defaults to 5.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry, doubled on every
+	// following one up to MaxBackoff. Defaults to 50ms and 2s.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// KeyValue is a key/value pair returned by Range.
+type KeyValue struct {
+	Key   string
+	Value []byte
+}
+
+// Client is safe for concurrent use.
+//
+// Every Client picks a random ID and numbers its writes, so that a write
+// retried after its first attempt was committed but not acknowledged is
+// not applied twice.
+type Client struct {
+	cfg Config
+
+	clientID string
+	sequence atomic.Uint64
+
+	mu     sync.Mutex
+	conns  map[string]*grpc.ClientConn
+	leader string
+	next   int
+}
+
+// New creates a Client. Connections are established lazily.
+func New(cfg Config) (*Client, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+	if len(cfg.DialOptions) == 0 {
+		cfg.DialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+
+	var id [16]byte
+	if _, err := crand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	clientID := hex.EncodeToString(id[:])
+
+	cfg.DialOptions = append(cfg.DialOptions[:len(cfg.DialOptions):len(cfg.DialOptions)],
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(metadata.AppendToOutgoingContext(ctx, clientIDHeader, clientID), method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(metadata.AppendToOutgoingContext(ctx, clientIDHeader, clientID), desc, cc, method, opts...)
+		}),
+	)
+
+	return &Client{
+		cfg:      cfg,
+		clientID: clientID,
+		conns:    make(map[string]*grpc.ClientConn),
+	}, nil
+}
+
+// Close closes every connection of the client.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for endpoint, conn := range c.conns {
+		errs = append(errs, conn.Close())
+		delete(c.conns, endpoint)
+	}
+	return errors.Join(errs...)
+}
+
+// ReadOption configures a Get or Range.
+type ReadOption func(*readOptions)
+
+type readOptions struct {
+	maxStaleness time.Duration
+	linearizable bool
+	revision     uint64
+}
+
+// WithMaxStaleness makes a read fail over to another node unless the node
+// serving it heard from the leader within d.
+func WithMaxStaleness(d time.Duration) ReadOption {
+	return func(o *readOptions) {
+		o.maxStaleness = d
+	}
+}
+
+// Linearizable makes a read reflect every write acknowledged before it
+// started. Such reads are served by the leader and cost a raft round trip.
+func Linearizable() ReadOption {
+	return func(o *readOptions) {
+		o.linearizable = true
+	}
+}
+
+// AtRevision makes a read return the values as they were at revision, as
+// long as its history was not compacted.
+func AtRevision(revision uint64) ReadOption {
+	return func(o *readOptions) {
+		o.revision = revision
+	}
+}
+
+func (o readOptions) consistency() (raftdv1.ReadConsistency, *durationpb.Duration) {
+	if o.linearizable {
+		return raftdv1.ReadConsistency_READ_CONSISTENCY_LINEARIZABLE, nil
+	}
+	if o.maxStaleness <= 0 {
+		return raftdv1.ReadConsistency_READ_CONSISTENCY_STALE, nil
+	}
+	return raftdv1.ReadConsistency_READ_CONSISTENCY_BOUNDED, durationpb.New(o.maxStaleness)
+}
+
+// Get returns the value of key, or ErrNotFound.
+func (c *Client) Get(ctx context.Context, key string, opts ...ReadOption) ([]byte, error) {
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	consistency, maxStaleness := o.consistency()
+
+	var value []byte
+	err := c.readWith(ctx, o, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewKVServiceClient(conn).Get(ctx, &raftdv1.GetRequest{
+			Namespace:    c.cfg.Namespace,
+			Key:          key,
+			Consistency:  consistency,
+			MaxStaleness: maxStaleness,
+			Revision:     o.revision,
+		})
+		if err != nil {
+			return err
+		}
+		value = resp.Value
+		return nil
+	})
+	if status.Code(err) == codes.NotFound && !namespaceNotFound(err) {
+		return nil, ErrNotFound
+	}
+	return value, namespaceError(err)
+}
+
+// Range returns up to limit pairs whose key starts with prefix, in key
+// order. A limit of zero returns all of them.
+func (c *Client) Range(ctx context.Context, prefix string, limit int64, opts ...ReadOption) ([]KeyValue, error) {
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	consistency, maxStaleness := o.consistency()
+
+	var kvs []KeyValue
+	err := c.readWith(ctx, o, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewKVServiceClient(conn).Range(ctx, &raftdv1.RangeRequest{
+			Namespace:    c.cfg.Namespace,
+			Prefix:       prefix,
+			Limit:        limit,
+			Consistency:  consistency,
+			MaxStaleness: maxStaleness,
+			Revision:     o.revision,
+		})
+		if err != nil {
+			return err
+		}
+		kvs = make([]KeyValue, 0, len(resp.Kvs))
+		for _, kv := range resp.Kvs {
+			kvs = append(kvs, KeyValue{Key: kv.Key, Value: kv.Value})
+		}
+		return nil
+	})
+	return kvs, namespaceError(err)
+}
+
+// Set sets key to value.
+func (c *Client) Set(ctx context.Context, key string, value []byte) error {
+	session := c.newSession()
+	return namespaceError(c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewKVServiceClient(conn).Set(ctx, &raftdv1.SetRequest{
+			Namespace: c.cfg.Namespace,
+			Key:       key,
+			Value:     value,
+			Session:   session,
+		})
+		return err
+	}))
+}
+
+// CompareAndSwap sets key to value if it holds expected, or if it does not
+// exist when expected is nil, and reports whether it did.
+func (c *Client) CompareAndSwap(ctx context.Context, key string, expected, value []byte) (bool, error) {
+	var swapped bool
+	session := c.newSession()
+	err := c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewKVServiceClient(conn).CompareAndSwap(ctx, &raftdv1.CompareAndSwapRequest{
+			Namespace: c.cfg.Namespace,
+			Key:       key,
+			Expected:  expected,
+			Value:     value,
+			Session:   session,
+		})
+		if err != nil {
+			return err
+		}
+		swapped = resp.Swapped
+		return nil
+	})
+	return swapped, namespaceError(err)
+}
+
+// CounterOption configures an Increment or Decrement.
+type CounterOption func(*raftdv1.IncrementRequest)
+
+// WithInitial sets the value a missing counter starts from. It defaults
+// to zero.
+func WithInitial(n int64) CounterOption {
+	return func(r *raftdv1.IncrementRequest) {
+		r.Initial = n
+	}
+}
+
+// WithMin makes the call fail with codes.OutOfRange, changing nothing, if
+// the counter would drop below n.
+func WithMin(n int64) CounterOption {
+	return func(r *raftdv1.IncrementRequest) {
+		r.Min = &n
+	}
+}
+
+// WithMax makes the call fail with codes.OutOfRange, changing nothing, if
+// the counter would exceed n.
+func WithMax(n int64) CounterOption {
+	return func(r *raftdv1.IncrementRequest) {
+		r.Max = &n
+	}
+}
+
+// Increment atomically adds delta to the counter at key and returns the
+// new value. Counters are stored as base 10 integers.
+func (c *Client) Increment(ctx context.Context, key string, delta int64, opts ...CounterOption) (int64, error) {
+	req := &raftdv1.IncrementRequest{
+		Namespace: c.cfg.Namespace,
+		Key:       key,
+		Delta:     delta,
+		Session:   c.newSession(),
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	var value int64
+	err := c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewKVServiceClient(conn).Increment(ctx, req)
+		if err != nil {
+			return err
+		}
+		value = resp.Value
+		return nil
+	})
+	return value, namespaceError(err)
+}
+
+// Decrement atomically subtracts delta from the counter at key and
+// returns the new value.
+func (c *Client) Decrement(ctx context.Context, key string, delta int64, opts ...CounterOption) (int64, error) {
+	inc := &raftdv1.IncrementRequest{}
+	for _, opt := range opts {
+		opt(inc)
+	}
+	req := &raftdv1.DecrementRequest{
+		Namespace: c.cfg.Namespace,
+		Key:       key,
+		Delta:     delta,
+		Initial:   inc.Initial,
+		Min:       inc.Min,
+		Max:       inc.Max,
+		Session:   c.newSession(),
+	}
+
+	var value int64
+	err := c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewKVServiceClient(conn).Decrement(ctx, req)
+		if err != nil {
+			return err
+		}
+		value = resp.Value
+		return nil
+	})
+	return value, namespaceError(err)
+}
+
+// Delete deletes key.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	session := c.newSession()
+	return namespaceError(c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewKVServiceClient(conn).Delete(ctx, &raftdv1.DeleteRequest{
+			Namespace: c.cfg.Namespace,
+			Key:       key,
+			Session:   session,
+		})
+		return err
+	}))
+}
+
+// Compact drops the history of every namespace superseded at revision. It
+// fails with codes.FailedPrecondition past the checkpoint of a change sink
+// or consumer, whose changes would be lost.
+func (c *Client) Compact(ctx context.Context, revision uint64) error {
+	session := c.newSession()
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewKVServiceClient(conn).Compact(ctx, &raftdv1.CompactRequest{
+			Revision: revision,
+			Session:  session,
+		})
+		return err
+	})
+}
+
+// Status returns the cluster status as seen by any reachable node.
+func (c *Client) Status(ctx context.Context) (*raftdv1.StatusResponse, error) {
+	var resp *raftdv1.StatusResponse
+	err := c.read(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		var err error
+		resp, err = raftdv1.NewRaftServiceClient(conn).Status(ctx, &raftdv1.StatusRequest{})
+		return err
+	})
+	return resp, err
+}
+
+// Join adds the node id listening for raft traffic on addr to the cluster,
+// as a read replica if nonVoter is set. Membership changes are not
+// deduplicated, so the call is not retried once it may have reached the
+// leader.
+func (c *Client) Join(ctx context.Context, id, addr string, nonVoter bool) error {
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewRaftServiceClient(conn).Join(ctx, &raftdv1.JoinRequest{
+			Id:       id,
+			Address:  addr,
+			NonVoter: nonVoter,
+		})
+		return sentOnce(err)
+	})
+}
+
+// Leave removes the node id from the cluster. Like Join, it is not retried
+// once it may have reached the leader.
+func (c *Client) Leave(ctx context.Context, id string) error {
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewRaftServiceClient(conn).Leave(ctx, &raftdv1.LeaveRequest{Id: id})
+		return sentOnce(err)
+	})
+}
+
+// Restore replaces the state of the whole cluster with the snapshot read
+// from r. The snapshot is streamed once, so the call is not retried after
+// the upload started.
+func (c *Client) Restore(ctx context.Context, r io.Reader) error {
+	conn, err := c.leaderConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	stream, err := raftdv1.NewRaftServiceClient(conn).Restore(ctx)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, restoreChunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&raftdv1.RestoreRequest{Chunk: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// Backup writes a snapshot of the state of the cluster, taken on the
+// leader, to w in the format Restore reads. The snapshot is streamed once,
+// so the call is not retried after the download started.
+func (c *Client) Backup(ctx context.Context, w io.Writer) error {
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		stream, err := raftdv1.NewRaftServiceClient(conn).Backup(ctx, &raftdv1.BackupRequest{})
+		if err != nil {
+			return err
+		}
+
+		started := false
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				if started {
+					return finalError{err}
+				}
+				return err
+			}
+
+			started = true
+			if _, err := w.Write(resp.Chunk); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// newSession numbers a write. Every attempt of the write sends the same
+// session.
+func (c *Client) newSession() *raftdv1.WriteSession {
+	return &raftdv1.WriteSession{
+		ClientId: c.clientID,
+		Sequence: c.sequence.Add(1),
+	}
+}
+
+// readWith sends linearizable reads to the leader and others to any node.
+func (c *Client) readWith(ctx context.Context, o readOptions, call func(context.Context, *grpc.ClientConn) error) error {
+	if o.linearizable {
+		return c.write(ctx, call)
+	}
+	return c.read(ctx, call)
+}
+
+// read runs call against the endpoints in turn until one succeeds.
+func (c *Client) read(ctx context.Context, call func(context.Context, *grpc.ClientConn) error) error {
+	return c.retry(ctx, func() error {
+		endpoint := c.nextEndpoint()
+		conn, err := c.conn(endpoint)
+		if err != nil {
+			return err
+		}
+		return call(ctx, conn)
+	})
+}
+
+// write runs call against the leader, rediscovering it when needed.
+func (c *Client) write(ctx context.Context, call func(context.Context, *grpc.ClientConn) error) error {
+	return c.retry(ctx, func() error {
+		conn, err := c.leaderConn(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = call(ctx, conn)
+		if retryable(err) {
+			c.resetLeader()
+		}
+		return err
+	})
+}
+
+func (c *Client) retry(ctx context.Context, fn func() error) error {
+	backoff := c.cfg.Backoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if final, ok := err.(finalError); ok {
+			return final.err
+		}
+		if err == nil || attempt >= c.cfg.MaxRetries {
+			return err
+		}
+
+		// Full jitter keeps clients that failed together from retrying
+		// together. A call throttled by the server is retried no sooner
+		// than the server asked.
+		delay := rand.N(backoff) + 1
+		if hint, ok := retryAfter(err); ok {
+			delay = max(delay, hint)
+		} else if !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		backoff = min(2*backoff, c.cfg.MaxBackoff)
+	}
+}
+
+// leaderConn returns a connection to the leader, asking every endpoint for
+// its status if the leader is not known yet.
+func (c *Client) leaderConn(ctx context.Context) (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	leader := c.leader
+	c.mu.Unlock()
+	if leader != "" {
+		return c.conn(leader)
+	}
+
+	for _, endpoint := range c.cfg.Endpoints {
+		conn, err := c.conn(endpoint)
+		if err != nil {
+			continue
+		}
+
+		resp, err := raftdv1.NewRaftServiceClient(conn).Status(ctx, &raftdv1.StatusRequest{})
+		if err != nil {
+			continue
+		}
+
+		if resp.LeaderId != "" && resp.LeaderId == resp.Id {
+			c.mu.Lock()
+			c.leader = endpoint
+			c.mu.Unlock()
+			return conn, nil
+		}
+	}
+
+	return nil, status.Error(codes.Unavailable, ErrNoLeader.Error())
+}
+
+func (c *Client) resetLeader() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.leader = ""
+}
+
+func (c *Client) nextEndpoint() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	endpoint := c.cfg.Endpoints[c.next%len(c.cfg.Endpoints)]
+	c.next++
+	return endpoint
+}
+
+func (c *Client) conn(endpoint string) (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if conn, ok := c.conns[endpoint]; ok {
+		return conn, nil
+	}
+
+	conn, err := grpc.NewClient(endpoint, c.cfg.DialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	c.conns[endpoint] = conn
+	return conn, nil
+}
+
+// retryable reports whether err may succeed on another node or later.
+// finalError is an error retry returns as is, without another attempt.
+type finalError struct {
+	err error
+}
+
+func (e finalError) Error() string {
+	return e.err.Error()
+}
+
+// sentOnce keeps a call that is not safe to repeat from being retried once
+// it may have reached the leader. Only the calls a node turned down before
+// handling them, for not being the leader or for being throttled, are
+// retried.
+func sentOnce(err error) error {
+	if err == nil || retryable(err) && status.Code(err) == codes.FailedPrecondition {
+		return err
+	}
+	if _, ok := retryAfter(err); ok {
+		return err
+	}
+	return finalError{err}
+}
+
+func retryable(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+
+	switch s.Code() {
+	case codes.Unavailable:
+		return true
+	case codes.FailedPrecondition:
+		// Returned by followers and replicas for writes.
+		return s.Message() == "not the leader"
+	default:
+		return false
+	}
+}
+
+// retryAfter returns the delay a server asked for before retrying a call it
+// throttled.
+func retryAfter(err error) (time.Duration, bool) {
+	s, ok := status.FromError(err)
+	if !ok || s.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.RetryDelay.AsDuration(), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// ErrLeaseExpired is returned by Lease.Err once the lease was lost.
+var ErrLeaseExpired = errors.New("raftd: lease expired")
+
+// Lease is a lease granted by the cluster and kept alive in the background
+// until it is revoked or lost. Locks and elections won with a lease are
+// released when it ends.
+type Lease struct {
+	c   *Client
+	id  uint64
+	ttl time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// GrantLease grants a lease that expires ttl after its last renewal, and
+// renews it every third of ttl until Revoke is called.
+func (c *Client) GrantLease(ctx context.Context, ttl time.Duration) (*Lease, error) {
+	var resp *raftdv1.GrantResponse
+	session := c.newSession()
+	err := c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		var err error
+		resp, err = raftdv1.NewLockServiceClient(conn).Grant(ctx, &raftdv1.GrantRequest{
+			Ttl:     durationpb.New(ttl),
+			Session: session,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keepAliveCtx, cancel := context.WithCancel(context.Background())
+	l := &Lease{
+		c:      c,
+		id:     resp.LeaseId,
+		ttl:    ttl,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go l.keepAlive(keepAliveCtx)
+	return l, nil
+}
+
+// ID returns the ID of the lease.
+func (l *Lease) ID() uint64 {
+	return l.id
+}
+
+// Done is closed when the lease is revoked or lost.
+func (l *Lease) Done() <-chan struct{} {
+	return l.done
+}
+
+// Err returns ErrLeaseExpired if the lease was lost, context.Canceled if
+// it was revoked, and nil while it is alive.
+func (l *Lease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Revoke stops renewing the lease and revokes it, releasing its locks at
+// once rather than when it expires.
+func (l *Lease) Revoke(ctx context.Context) error {
+	l.cancel()
+	<-l.done
+
+	err := l.c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewLockServiceClient(conn).Revoke(ctx, &raftdv1.RevokeRequest{LeaseId: l.id})
+		return err
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
+
+// keepAlive renews the lease until ctx is cancelled or the lease is lost.
+// The lease is considered lost once the cluster reports it unknown or no
+// renewal succeeded for a whole TTL.
+func (l *Lease) keepAlive(ctx context.Context) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			l.setErr(context.Canceled)
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		err := l.c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+			_, err := raftdv1.NewLockServiceClient(conn).KeepAlive(ctx, &raftdv1.KeepAliveRequest{LeaseId: l.id})
+			return err
+		})
+		switch {
+		case err == nil:
+			renewed = start
+		case ctx.Err() != nil:
+			l.setErr(context.Canceled)
+			return
+		case status.Code(err) == codes.NotFound || time.Since(renewed) >= l.ttl:
+			l.setErr(ErrLeaseExpired)
+			return
+		}
+	}
+}
+
+func (l *Lease) setErr(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.err = err
+}
+
+// Lock waits until the lock name is acquired with lease and returns its
+// fencing token. Tokens grow with every acquisition, so a resource guarded
+// by the lock can reject writes carrying a token lower than one it has
+// seen.
+func (c *Client) Lock(ctx context.Context, name string, lease *Lease) (uint64, error) {
+	var token uint64
+	err := c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewLockServiceClient(conn).Lock(ctx, &raftdv1.LockRequest{Name: name, LeaseId: lease.id})
+		if err != nil {
+			return err
+		}
+		token = resp.Token
+		return nil
+	})
+	return token, err
+}
+
+// TryLock acquires the lock name with lease if it is free, and reports
+// whether it did along with the fencing token.
+func (c *Client) TryLock(ctx context.Context, name string, lease *Lease) (uint64, bool, error) {
+	var resp *raftdv1.TryLockResponse
+	err := c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		var err error
+		resp, err = raftdv1.NewLockServiceClient(conn).TryLock(ctx, &raftdv1.TryLockRequest{Name: name, LeaseId: lease.id})
+		return err
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return resp.Token, resp.Acquired, nil
+}
+
+// Unlock releases the lock name held with lease.
+func (c *Client) Unlock(ctx context.Context, name string, lease *Lease) error {
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewLockServiceClient(conn).Unlock(ctx, &raftdv1.UnlockRequest{Name: name, LeaseId: lease.id})
+		return err
+	})
+}
+
+// Leader is the leader of an election.
+type Leader struct {
+	LeaseID uint64
+	Token   uint64
+	Value   []byte
+}
+
+// Campaign waits until lease wins the election, publishing value to its
+// observers, and returns the fencing token of the win.
+func (c *Client) Campaign(ctx context.Context, election string, lease *Lease, value []byte) (uint64, error) {
+	var token uint64
+	err := c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewLockServiceClient(conn).Campaign(ctx, &raftdv1.CampaignRequest{
+			Election: election,
+			LeaseId:  lease.id,
+			Value:    value,
+		})
+		if err != nil {
+			return err
+		}
+		token = resp.Token
+		return nil
+	})
+	return token, err
+}
+
+// Resign gives up the election won with lease.
+func (c *Client) Resign(ctx context.Context, election string, lease *Lease) error {
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewLockServiceClient(conn).Resign(ctx, &raftdv1.ResignRequest{Election: election, LeaseId: lease.id})
+		return err
+	})
+}
+
+// Observe sends the current leader of election, or nil when there is none,
+// and then every change of leader, until ctx is done. A broken stream is
+// reopened, possibly on another node, which resends the current leader.
+func (c *Client) Observe(ctx context.Context, election string) <-chan *Leader {
+	ch := make(chan *Leader)
+	go func() {
+		defer close(ch)
+
+		for ctx.Err() == nil {
+			err := c.read(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+				stream, err := raftdv1.NewLockServiceClient(conn).Observe(ctx, &raftdv1.ObserveRequest{Election: election})
+				if err != nil {
+					return err
+				}
+				for {
+					resp, err := stream.Recv()
+					if err != nil {
+						return err
+					}
+
+					var leader *Leader
+					if resp.Leader != nil {
+						leader = &Leader{
+							LeaseID: resp.Leader.LeaseId,
+							Token:   resp.Leader.Token,
+							Value:   resp.Leader.Value,
+						}
+					}
+					select {
+					case ch <- leader:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+			})
+			if status.Code(err) == codes.InvalidArgument {
+				return
+			}
+
+			timer := time.NewTimer(c.cfg.MaxBackoff)
+			select {
+			case <-ctx.Done():
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+	}()
+	return ch
+}
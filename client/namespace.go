@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// Quota limits a namespace. Zero means no limit.
+type Quota struct {
+	MaxKeys      int64
+	MaxBytes     int64
+	MaxValueSize int64
+}
+
+func (q Quota) proto() *raftdv1.Quota {
+	return &raftdv1.Quota{
+		MaxKeys:      q.MaxKeys,
+		MaxBytes:     q.MaxBytes,
+		MaxValueSize: q.MaxValueSize,
+	}
+}
+
+// Namespace is a namespace with its quota and usage.
+type Namespace struct {
+	Name  string
+	Quota Quota
+	// Keys and Bytes are the usage as applied on the node that answered.
+	Keys  int64
+	Bytes int64
+}
+
+func newNamespace(ns *raftdv1.Namespace) *Namespace {
+	return &Namespace{
+		Name: ns.Name,
+		Quota: Quota{
+			MaxKeys:      ns.Quota.GetMaxKeys(),
+			MaxBytes:     ns.Quota.GetMaxBytes(),
+			MaxValueSize: ns.Quota.GetMaxValueSize(),
+		},
+		Keys:  ns.Usage.GetKeys(),
+		Bytes: ns.Usage.GetBytes(),
+	}
+}
+
+// CreateNamespace creates an empty namespace.
+func (c *Client) CreateNamespace(ctx context.Context, name string, quota Quota) error {
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewNamespaceServiceClient(conn).CreateNamespace(ctx, &raftdv1.CreateNamespaceRequest{
+			Namespace: &raftdv1.Namespace{Name: name, Quota: quota.proto()},
+		})
+		return err
+	})
+}
+
+// GetNamespace returns the namespace, or ErrNamespaceNotFound.
+func (c *Client) GetNamespace(ctx context.Context, name string) (*Namespace, error) {
+	var ns *raftdv1.Namespace
+	err := c.read(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewNamespaceServiceClient(conn).GetNamespace(ctx, &raftdv1.GetNamespaceRequest{Name: name})
+		if err != nil {
+			return err
+		}
+		ns = resp.Namespace
+		return nil
+	})
+	if err != nil {
+		return nil, namespaceError(err)
+	}
+	return newNamespace(ns), nil
+}
+
+// ListNamespaces returns every namespace in name order.
+func (c *Client) ListNamespaces(ctx context.Context) ([]*Namespace, error) {
+	var list []*Namespace
+	err := c.read(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewNamespaceServiceClient(conn).ListNamespaces(ctx, &raftdv1.ListNamespacesRequest{})
+		if err != nil {
+			return err
+		}
+		list = make([]*Namespace, 0, len(resp.Namespaces))
+		for _, ns := range resp.Namespaces {
+			list = append(list, newNamespace(ns))
+		}
+		return nil
+	})
+	return list, err
+}
+
+// UpdateNamespace replaces the quota of the namespace.
+func (c *Client) UpdateNamespace(ctx context.Context, name string, quota Quota) error {
+	return namespaceError(c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewNamespaceServiceClient(conn).UpdateNamespace(ctx, &raftdv1.UpdateNamespaceRequest{
+			Name:  name,
+			Quota: quota.proto(),
+		})
+		return err
+	}))
+}
+
+// DeleteNamespace deletes the namespace along with its keys.
+func (c *Client) DeleteNamespace(ctx context.Context, name string) error {
+	return namespaceError(c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewNamespaceServiceClient(conn).DeleteNamespace(ctx, &raftdv1.DeleteNamespaceRequest{Name: name})
+		return err
+	}))
+}
+
+// namespaceNotFound reports whether err says the namespace does not exist,
+// as opposed to a key.
+func namespaceNotFound(err error) bool {
+	s, ok := status.FromError(err)
+	return ok && s.Code() == codes.NotFound && s.Message() == "namespace not found"
+}
+
+// namespaceError maps a missing namespace to ErrNamespaceNotFound.
+func namespaceError(err error) error {
+	if namespaceNotFound(err) {
+		return ErrNamespaceNotFound
+	}
+	return err
+}
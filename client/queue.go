@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// ErrEmpty is returned by Dequeue and Peek when the queue has no visible
+// message.
+var ErrEmpty = errors.New("raftd: queue is empty")
+
+// Message is a message delivered by Dequeue or returned by Peek.
+type Message struct {
+	ID   uint64
+	Body []byte
+	// Attempts counts the deliveries of the message, including this one.
+	Attempts uint32
+	// Receipt identifies the delivery to Ack and Nack. Peek leaves it
+	// zero.
+	Receipt uint64
+}
+
+func newMessage(msg *raftdv1.Message) *Message {
+	return &Message{
+		ID:       msg.Id,
+		Body:     msg.Body,
+		Attempts: msg.Attempts,
+		Receipt:  msg.Receipt,
+	}
+}
+
+// Enqueue appends body to queue and returns the message ID.
+func (c *Client) Enqueue(ctx context.Context, queue string, body []byte) (uint64, error) {
+	var id uint64
+	session := c.newSession()
+	err := c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewQueueServiceClient(conn).Enqueue(ctx, &raftdv1.EnqueueRequest{
+			Queue:   queue,
+			Body:    body,
+			Session: session,
+		})
+		if err != nil {
+			return err
+		}
+		id = resp.Id
+		return nil
+	})
+	return id, err
+}
+
+// Dequeue delivers the oldest visible message of queue and hides it for
+// visibilityTimeout, or for 30 seconds if zero. The message is delivered
+// again unless it is acknowledged in time. Dequeue returns ErrEmpty if no
+// message is visible.
+func (c *Client) Dequeue(ctx context.Context, queue string, visibilityTimeout time.Duration) (*Message, error) {
+	req := &raftdv1.DequeueRequest{
+		Queue:   queue,
+		Session: c.newSession(),
+	}
+	if visibilityTimeout != 0 {
+		req.VisibilityTimeout = durationpb.New(visibilityTimeout)
+	}
+
+	var msg *raftdv1.Message
+	err := c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewQueueServiceClient(conn).Dequeue(ctx, req)
+		if err != nil {
+			return err
+		}
+		msg = resp.Message
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, ErrEmpty
+	}
+	return newMessage(msg), nil
+}
+
+// Ack deletes a delivered message. It fails with codes.FailedPrecondition
+// if the delivery timed out or was settled already.
+func (c *Client) Ack(ctx context.Context, queue string, msg *Message) error {
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewQueueServiceClient(conn).Ack(ctx, &raftdv1.AckRequest{
+			Queue:   queue,
+			Id:      msg.ID,
+			Receipt: msg.Receipt,
+		})
+		return err
+	})
+}
+
+// Nack makes a delivered message visible again at once.
+func (c *Client) Nack(ctx context.Context, queue string, msg *Message) error {
+	return c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := raftdv1.NewQueueServiceClient(conn).Nack(ctx, &raftdv1.NackRequest{
+			Queue:   queue,
+			Id:      msg.ID,
+			Receipt: msg.Receipt,
+		})
+		return err
+	})
+}
+
+// Peek returns the message Dequeue would deliver next, or ErrEmpty.
+func (c *Client) Peek(ctx context.Context, queue string, opts ...ReadOption) (*Message, error) {
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	consistency, maxStaleness := o.consistency()
+
+	var msg *raftdv1.Message
+	err := c.readWith(ctx, o, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewQueueServiceClient(conn).Peek(ctx, &raftdv1.PeekRequest{
+			Queue:        queue,
+			Consistency:  consistency,
+			MaxStaleness: maxStaleness,
+		})
+		if err != nil {
+			return err
+		}
+		msg = resp.Message
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, ErrEmpty
+	}
+	return newMessage(msg), nil
+}
+
+// Length returns the number of visible and in-flight messages of queue.
+func (c *Client) Length(ctx context.Context, queue string, opts ...ReadOption) (visible, inFlight int64, err error) {
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	consistency, maxStaleness := o.consistency()
+
+	err = c.readWith(ctx, o, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewQueueServiceClient(conn).Length(ctx, &raftdv1.LengthRequest{
+			Queue:        queue,
+			Consistency:  consistency,
+			MaxStaleness: maxStaleness,
+		})
+		if err != nil {
+			return err
+		}
+		visible, inFlight = resp.Visible, resp.InFlight
+		return nil
+	})
+	return visible, inFlight, err
+}
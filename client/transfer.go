@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// importChunkSize bounds the size of the pairs of an ImportRequest.
+const importChunkSize = 256 * 1024
+
+// Export calls fn with the batches of pairs whose key starts with prefix, in
+// key order, and returns the revision they were read at. The pairs reflect
+// a single revision. The call is retried against other nodes until fn was
+// given a batch; a stream broken after that fails the export.
+func (c *Client) Export(ctx context.Context, prefix string, fn func([]KeyValue) error, opts ...ReadOption) (uint64, error) {
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	consistency, maxStaleness := o.consistency()
+
+	var revision uint64
+	err := c.readWith(ctx, o, func(ctx context.Context, conn *grpc.ClientConn) error {
+		stream, err := raftdv1.NewKVServiceClient(conn).Export(ctx, &raftdv1.ExportRequest{
+			Namespace:    c.cfg.Namespace,
+			Prefix:       prefix,
+			Consistency:  consistency,
+			MaxStaleness: maxStaleness,
+			Revision:     o.revision,
+		})
+		if err != nil {
+			return err
+		}
+
+		started := false
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				if started {
+					return fmt.Errorf("export interrupted: %v", err)
+				}
+				return err
+			}
+			if resp.Revision != 0 {
+				revision = resp.Revision
+			}
+			if len(resp.Kvs) == 0 {
+				continue
+			}
+
+			kvs := make([]KeyValue, 0, len(resp.Kvs))
+			for _, kv := range resp.Kvs {
+				kvs = append(kvs, KeyValue{Key: kv.Key, Value: kv.Value})
+			}
+			started = true
+			if err := fn(kvs); err != nil {
+				return fmt.Errorf("export interrupted: %w", err)
+			}
+		}
+	})
+	return revision, namespaceError(err)
+}
+
+// ImportResult is the outcome of an Import.
+type ImportResult struct {
+	// Keys and Bytes count the pairs imported, or that would have been
+	// by a dry run.
+	Keys  uint64
+	Bytes int64
+	// Revision is the revision of the last batch written.
+	Revision uint64
+}
+
+// Import sets the pairs next returns until it returns io.EOF. The leader
+// writes them in batches, each of them atomically, so a failed import may
+// leave the batches before the failure written. With dryRun, the pairs are
+// only validated. progress, if not nil, is called with the number of keys
+// and bytes sent so far.
+//
+// The pairs are streamed once, so the call is not retried after the upload
+// started.
+func (c *Client) Import(ctx context.Context, next func() (KeyValue, error), dryRun bool, progress func(keys uint64, bytes int64)) (ImportResult, error) {
+	conn, err := c.leaderConn(ctx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	stream, err := raftdv1.NewKVServiceClient(conn).Import(ctx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	var keys uint64
+	var bytes int64
+	req := &raftdv1.ImportRequest{Namespace: c.cfg.Namespace, DryRun: dryRun}
+	size := 0
+	send := func() error {
+		if err := stream.Send(req); err != nil {
+			return err
+		}
+		keys += uint64(len(req.Kvs))
+		bytes += int64(size)
+		if progress != nil {
+			progress(keys, bytes)
+		}
+		req, size = &raftdv1.ImportRequest{}, 0
+		return nil
+	}
+
+	// A failed send is reported by CloseAndRecv, along with the error of
+	// the server.
+	finish := func() (ImportResult, error) {
+		resp, err := stream.CloseAndRecv()
+		if err != nil {
+			return ImportResult{}, namespaceError(err)
+		}
+		return ImportResult{Keys: resp.Keys, Bytes: resp.Bytes, Revision: resp.Revision}, nil
+	}
+
+	for {
+		kv, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return ImportResult{}, err
+		}
+
+		req.Kvs = append(req.Kvs, &raftdv1.KeyValue{Key: kv.Key, Value: kv.Value})
+		size += len(kv.Key) + len(kv.Value)
+		if size >= importChunkSize {
+			if err := send(); err != nil {
+				return finish()
+			}
+		}
+	}
+	// An empty first request still carries the namespace and dry run.
+	if len(req.Kvs) > 0 || keys == 0 {
+		_ = send()
+	}
+	return finish()
+}
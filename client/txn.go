@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// Compare is a condition of a Txn on the current value of a key.
+type Compare struct {
+	Key   string
+	Value []byte
+	// Missing requires the key not to exist, rather than to hold Value.
+	Missing bool
+}
+
+// ValueIs requires key to hold value.
+func ValueIs(key string, value []byte) Compare {
+	return Compare{Key: key, Value: value}
+}
+
+// KeyMissing requires key not to exist.
+func KeyMissing(key string) Compare {
+	return Compare{Key: key, Missing: true}
+}
+
+// TxnOp is a write of a Txn.
+type TxnOp struct {
+	Key    string
+	Value  []byte
+	Delete bool
+}
+
+// SetOp sets key to value.
+func SetOp(key string, value []byte) TxnOp {
+	return TxnOp{Key: key, Value: value}
+}
+
+// DeleteOp deletes key.
+func DeleteOp(key string) TxnOp {
+	return TxnOp{Key: key, Delete: true}
+}
+
+// Txn applies the success operations if every comparison holds, and the
+// failure ones otherwise, atomically. It reports whether the comparisons
+// held. Each branch may write a key once at most.
+func (c *Client) Txn(ctx context.Context, compares []Compare, success, failure []TxnOp) (bool, error) {
+	req := &raftdv1.TxnRequest{
+		Namespace: c.cfg.Namespace,
+		Success:   txnOps(success),
+		Failure:   txnOps(failure),
+		Session:   c.newSession(),
+	}
+	for _, compare := range compares {
+		req.Compares = append(req.Compares, &raftdv1.Compare{Key: compare.Key, Value: compare.Value, Missing: compare.Missing})
+	}
+
+	var succeeded bool
+	err := c.write(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := raftdv1.NewKVServiceClient(conn).Txn(ctx, req)
+		if err != nil {
+			return err
+		}
+		succeeded = resp.Succeeded
+		return nil
+	})
+	return succeeded, namespaceError(err)
+}
+
+func txnOps(ops []TxnOp) []*raftdv1.TxnOp {
+	list := make([]*raftdv1.TxnOp, 0, len(ops))
+	for _, op := range ops {
+		list = append(list, &raftdv1.TxnOp{Key: op.Key, Value: op.Value, Delete: op.Delete})
+	}
+	return list
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// WatchOption configures a Watch.
+type WatchOption func(*raftdv1.WatchRequest)
+
+// WithPrefix watches every key starting with the key given to Watch.
+func WithPrefix() WatchOption {
+	return func(r *raftdv1.WatchRequest) {
+		r.Prefix = true
+	}
+}
+
+// AfterRevision makes a Watch start with the changes after revision,
+// rather than after the revision the node serving it applied last.
+func AfterRevision(revision uint64) WatchOption {
+	return func(r *raftdv1.WatchRequest) {
+		r.AfterRevision = revision
+	}
+}
+
+// errResume ends a call to read whose stream broke after delivering a
+// response, so that Watch reopens it with the retries of a new call.
+var errResume = errors.New("raftd: resume watch")
+
+// Watch calls fn with the batches of changes of key, in revision order,
+// until ctx is done or fn fails. A broken stream is reopened, possibly on
+// another node, after the last change passed to fn, so no change is missed
+// or repeated. It fails with codes.OutOfRange once the changes it needs
+// were compacted.
+func (c *Client) Watch(ctx context.Context, key string, fn func([]Change) error, opts ...WatchOption) error {
+	req := &raftdv1.WatchRequest{Namespace: c.cfg.Namespace, Key: key}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	for {
+		err := c.watch(ctx, req, fn)
+		if !errors.Is(err, errResume) {
+			return namespaceError(err)
+		}
+	}
+}
+
+// watch follows one stream at a time, reopening it after the last revision
+// it got while the retries last.
+func (c *Client) watch(ctx context.Context, req *raftdv1.WatchRequest, fn func([]Change) error) error {
+	return c.read(ctx, func(ctx context.Context, conn *grpc.ClientConn) error {
+		stream, err := raftdv1.NewKVServiceClient(conn).Watch(ctx, req)
+		if err != nil {
+			return err
+		}
+		for delivered := false; ; delivered = true {
+			resp, err := stream.Recv()
+			if err != nil {
+				if delivered && retryable(err) {
+					return errResume
+				}
+				return err
+			}
+			if resp.Revision != 0 {
+				req.AfterRevision = resp.Revision
+			}
+			if len(resp.Changes) == 0 {
+				continue
+			}
+
+			changes := make([]Change, 0, len(resp.Changes))
+			for _, change := range resp.Changes {
+				changes = append(changes, Change{
+					Revision:  change.Revision,
+					Namespace: change.Namespace,
+					Key:       change.Key,
+					Value:     change.Value,
+					Deleted:   change.Deleted,
+				})
+			}
+			if err := fn(changes); err != nil {
+				return err
+			}
+			req.AfterRevision = changes[len(changes)-1].Revision
+		}
+	})
+}
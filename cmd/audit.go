@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/amjadjibon/raftd/client"
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// auditPollInterval is how often tail --follow --file looks for new
+// records.
+const auditPollInterval = 500 * time.Millisecond
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Read the audit log",
+}
+
+var auditTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Print the last records of the audit log",
+	Long: `Print the last records of the replicated audit log, or with --file of the
+audit file of a node.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		after, _ := cmd.Flags().GetUint64("after")
+		last, _ := cmd.Flags().GetInt64("last")
+		follow, _ := cmd.Flags().GetBool("follow")
+		if last < 0 {
+			return fmt.Errorf("invalid number of records %d", last)
+		}
+		if after > 0 {
+			last = 0
+		}
+
+		ctx, cancel := requestContext(cmd)
+		if follow {
+			ctx, cancel = context.WithCancel(cmd.Context())
+		}
+		defer cancel()
+
+		emit := func(record client.AuditRecord) error {
+			return printResult(cmd, newAuditRecordResult(record))
+		}
+
+		var err error
+		if file != "" {
+			err = tailAuditFile(ctx, file, after, int(last), follow, emit)
+		} else {
+			var c *client.Client
+			c, err = newClient(cmd)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			err = c.TailAudit(ctx, after, last, follow, emit)
+		}
+		if follow && cmd.Context().Err() != nil {
+			return nil
+		}
+		return err
+	},
+}
+
+// tailAuditFile calls fn with the records of an audit file after index
+// after, or the last ones of them when last is positive. With follow, it
+// then polls the file for new records until ctx is done, moving on to the
+// new file once the file is rotated.
+func tailAuditFile(ctx context.Context, path string, after uint64, last int, follow bool, fn func(client.AuditRecord) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	r := auditFileReader{r: bufio.NewReader(f)}
+	records, err := r.read(after)
+	if err != nil {
+		return err
+	}
+	if last > 0 && len(records) > last {
+		records = records[len(records)-last:]
+	}
+	for _, record := range records {
+		if err := fn(record); err != nil {
+			return err
+		}
+		after = record.Index
+	}
+	if !follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(auditPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		// Check for a rotation before reading, so that the records
+		// written to the old file in between are not missed.
+		rotated := false
+		if info, err := os.Stat(path); err == nil {
+			if current, err := f.Stat(); err == nil && !os.SameFile(info, current) {
+				rotated = true
+			}
+		}
+
+		records, err := r.read(after)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return err
+			}
+			after = record.Index
+		}
+
+		if rotated {
+			next, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_ = f.Close()
+			f, r = next, auditFileReader{r: bufio.NewReader(next)}
+		}
+	}
+}
+
+// auditFileReader reads the records of an audit file as it grows.
+type auditFileReader struct {
+	r *bufio.Reader
+	// partial is the start of a line still being written.
+	partial []byte
+}
+
+// read returns the complete records after index after that were written
+// since the last call.
+func (a *auditFileReader) read(after uint64) ([]client.AuditRecord, error) {
+	var records []client.AuditRecord
+	for {
+		line, err := a.r.ReadBytes('\n')
+		a.partial = append(a.partial, line...)
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, a.partial = bytes.TrimSpace(a.partial), nil
+		if len(line) == 0 {
+			continue
+		}
+		var record raftdv1.AuditRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("invalid audit record: %w", err)
+		}
+		if record.Index > after {
+			records = append(records, client.NewAuditRecord(&record))
+		}
+	}
+}
+
+type auditRecordResult struct {
+	Index      uint64    `json:"index"`
+	Term       uint64    `json:"term"`
+	Time       time.Time `json:"time"`
+	Op         string    `json:"op"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Key        string    `json:"key,omitempty"`
+	Keys       []string  `json:"keys,omitempty"`
+	Principal  string    `json:"principal,omitempty"`
+	ClientAddr string    `json:"client_addr,omitempty"`
+	Result     string    `json:"result"`
+}
+
+func newAuditRecordResult(r client.AuditRecord) auditRecordResult {
+	return auditRecordResult{
+		Index:      r.Index,
+		Term:       r.Term,
+		Time:       r.Time.UTC(),
+		Op:         r.Op,
+		Namespace:  r.Namespace,
+		Key:        r.Key,
+		Keys:       r.Keys,
+		Principal:  r.Principal,
+		ClientAddr: r.ClientAddr,
+		Result:     r.Result,
+	}
+}
+
+// Table prints the record on a line of its own, so that records can be
+// printed as they come.
+func (r auditRecordResult) Table(w io.Writer) error {
+	namespace := r.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	key := fmt.Sprintf("key=%q", r.Key)
+	if len(r.Keys) > 0 {
+		key = fmt.Sprintf("keys=%q", r.Keys)
+	}
+	_, err := fmt.Fprintf(w, "%s index=%d term=%d op=%s namespace=%s %s result=%s principal=%q client=%s\n",
+		r.Time.Format(time.RFC3339Nano), r.Index, r.Term, r.Op, namespace, key, r.Result, r.Principal, r.ClientAddr)
+	return err
+}
+
+// Raw prints the record as a JSON line.
+func (r auditRecordResult) Raw(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+func init() {
+	auditTailCmd.Flags().String("file", "", "Read this audit file of a node instead of the replicated audit log")
+	auditTailCmd.Flags().Uint64("after", 0, "Print every record after this raft index instead of the last ones")
+	auditTailCmd.Flags().Int64("last", 10, "Number of records to print, 0 for all")
+	auditTailCmd.Flags().BoolP("follow", "f", false, "Keep printing the records as they are applied")
+
+	auditCmd.AddCommand(auditTailCmd)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Write a snapshot of the cluster state to a file",
+	Long: `Write a snapshot of the cluster state to a file.
+
+The snapshot is taken on the leader and is in the format restore reads, either
+to roll back the running cluster or to seed a new one.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to := cmd.Flag("to").Value.String()
+
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		f, err := os.Create(to)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
+		// A partial snapshot would only fail to restore later.
+		if err := errors.Join(c.Backup(ctx, f), f.Close()); err != nil {
+			_ = os.Remove(to)
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: "Snapshot written to " + to})
+	},
+}
+
+func init() {
+	backupCmd.Flags().String("to", "", "Snapshot file to write")
+	_ = backupCmd.MarkFlagRequired("to")
+}
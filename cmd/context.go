@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// cliConfig is the contexts file, kept at $XDG_CONFIG_HOME/raftd/contexts.json
+// (~/.config/raftd/contexts.json by default).
+type cliConfig struct {
+	Current  string                 `json:"current,omitempty"`
+	Contexts map[string]*cliContext `json:"contexts"`
+}
+
+// cliContext is a named cluster.
+type cliContext struct {
+	Endpoints []string `json:"endpoints"`
+}
+
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "raftd", "contexts.json"), nil
+}
+
+func loadConfig() (*cliConfig, error) {
+	cfg := &cliConfig{Contexts: make(map[string]*cliContext)}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("invalid contexts file %s: %w", path, err)
+	}
+	if cfg.Contexts == nil {
+		cfg.Contexts = make(map[string]*cliContext)
+	}
+	return cfg, nil
+}
+
+func saveConfig(cfg *cliConfig) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+func completeContextNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	names := make([]string, 0, len(cfg.Contexts))
+	for name := range cfg.Contexts {
+		names = append(names, name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage named clusters",
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the named clusters",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		return printResult(cmd, contextsResult{cfg})
+	},
+}
+
+var contextSetCmd = &cobra.Command{
+	Use:   "set NAME --endpoints HOST:PORT,...",
+	Short: "Create or update a named cluster",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(endpoints) == 0 {
+			return errors.New("--endpoints is required")
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		cfg.Contexts[args[0]] = &cliContext{Endpoints: endpoints}
+		if cfg.Current == "" {
+			cfg.Current = args[0]
+		}
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: fmt.Sprintf("Context %s saved", args[0])})
+	},
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:               "use NAME",
+	Short:             "Make a named cluster the current one",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContextNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := cfg.Contexts[args[0]]; !ok {
+			return fmt.Errorf("context %q not found", args[0])
+		}
+
+		cfg.Current = args[0]
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: fmt.Sprintf("Switched to context %s", args[0])})
+	},
+}
+
+var contextDeleteCmd = &cobra.Command{
+	Use:               "delete NAME",
+	Short:             "Delete a named cluster",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContextNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := cfg.Contexts[args[0]]; !ok {
+			return fmt.Errorf("context %q not found", args[0])
+		}
+
+		delete(cfg.Contexts, args[0])
+		if cfg.Current == args[0] {
+			cfg.Current = ""
+		}
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: fmt.Sprintf("Context %s deleted", args[0])})
+	},
+}
+
+type contextsResult struct {
+	*cliConfig
+}
+
+func (r contextsResult) names() []string {
+	names := make([]string, 0, len(r.Contexts))
+	for name := range r.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r contextsResult) Table(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CURRENT\tNAME\tENDPOINTS")
+	for _, name := range r.names() {
+		current := ""
+		if name == r.Current {
+			current = "*"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", current, name, strings.Join(r.Contexts[name].Endpoints, ","))
+	}
+	return tw.Flush()
+}
+
+func (r contextsResult) Raw(w io.Writer) error {
+	for _, name := range r.names() {
+		if _, err := fmt.Fprintln(w, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextSetCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextDeleteCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/amjadjibon/raftd/client"
+)
+
+var incrCmd = &cobra.Command{
+	Use:   "incr",
+	Short: "Atomically increment a counter",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCounter(cmd, (*client.Client).Increment)
+	},
+}
+
+var decrCmd = &cobra.Command{
+	Use:   "decr",
+	Short: "Atomically decrement a counter",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCounter(cmd, (*client.Client).Decrement)
+	},
+}
+
+type counterFunc func(c *client.Client, ctx context.Context, key string, delta int64, opts ...client.CounterOption) (int64, error)
+
+func runCounter(cmd *cobra.Command, fn counterFunc) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	delta, _ := cmd.Flags().GetInt64("by")
+	initial, _ := cmd.Flags().GetInt64("initial")
+	opts := []client.CounterOption{client.WithInitial(initial)}
+	if cmd.Flags().Changed("min") {
+		min, _ := cmd.Flags().GetInt64("min")
+		opts = append(opts, client.WithMin(min))
+	}
+	if cmd.Flags().Changed("max") {
+		max, _ := cmd.Flags().GetInt64("max")
+		opts = append(opts, client.WithMax(max))
+	}
+
+	ctx, cancel := requestContext(cmd)
+	defer cancel()
+
+	key := cmd.Flag("key").Value.String()
+	value, err := fn(c, ctx, key, delta, opts...)
+	if err != nil {
+		return err
+	}
+
+	return printResult(cmd, counterResult{Key: key, Value: value})
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{incrCmd, decrCmd} {
+		cmd.Flags().String("key", "", "Key of the counter")
+		cmd.Flags().Int64("by", 1, "Amount to change the counter by")
+		cmd.Flags().Int64("initial", 0, "Value a missing counter starts from")
+		cmd.Flags().Int64("min", 0, "Fail if the counter would drop below this value")
+		cmd.Flags().Int64("max", 0, "Fail if the counter would exceed this value")
+
+		_ = cmd.MarkFlagRequired("key")
+	}
+}
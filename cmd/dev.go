@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/amjadjibon/raftd/client"
+	"github.com/amjadjibon/raftd/server"
+)
+
+var devNodes int
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Run a local cluster in a single process",
+	Long: `Run a local cluster in a single process.
+
+Every node gets loopback ports and a data directory of its own, and all of
+them are joined to the first one. The data is kept in a temporary directory
+that is removed on exit.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if devNodes < 1 {
+			return fmt.Errorf("--nodes must be at least 1")
+		}
+
+		logger, err := newLogger()
+		if err != nil {
+			return err
+		}
+
+		shutdownTracing, err := setupTracing(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = shutdownTracing(context.WithoutCancel(cmd.Context()))
+		}()
+
+		dir, err := os.MkdirTemp("", "raftd-dev-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		configs := make([]server.Config, devNodes)
+		grpcAddrs := make([]string, devNodes)
+		for i := range configs {
+			raftAddr, err := freeAddr()
+			if err != nil {
+				return err
+			}
+			grpcAddr, err := freeAddr()
+			if err != nil {
+				return err
+			}
+
+			id := fmt.Sprintf("node%d", i)
+			configs[i] = server.Config{
+				RaftDir:    filepath.Join(dir, id),
+				RaftBind:   raftAddr,
+				RaftNodeID: id,
+				GRPCAddr:   grpcAddr,
+				Bootstrap:  i == 0,
+				Logger:     logger,
+			}
+			grpcAddrs[i] = grpcAddr
+		}
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		errs := make(chan error, len(configs))
+		for _, cfg := range configs {
+			go func() {
+				errs <- server.Run(ctx, cfg)
+			}()
+		}
+
+		// wait stops every node and collects the errors of the running
+		// ones.
+		running := len(configs)
+		wait := func(err error) error {
+			cancel()
+			for ; running > 0; running-- {
+				err = errors.Join(err, <-errs)
+			}
+			return err
+		}
+
+		if err := joinDevNodes(ctx, configs, grpcAddrs); err != nil {
+			return wait(err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "raftd dev cluster running with data in %s\n\n", dir)
+		for i, cfg := range configs {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %s  grpc %s  raft %s\n", cfg.RaftNodeID, grpcAddrs[i], cfg.RaftBind)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\n  raftd --endpoints %s status\n\n", strings.Join(grpcAddrs, ","))
+		fmt.Fprintln(cmd.OutOrStdout(), "Press Ctrl+C to stop.")
+
+		select {
+		case <-ctx.Done():
+			return wait(nil)
+		case err := <-errs:
+			// A node stopped on its own; take the others down with it.
+			running--
+			return wait(err)
+		}
+	},
+}
+
+// joinDevNodes adds every node but the first to the cluster once the first
+// one has become the leader.
+func joinDevNodes(ctx context.Context, configs []server.Config, grpcAddrs []string) error {
+	cl, err := client.New(client.Config{
+		Endpoints:  grpcAddrs[:1],
+		MaxRetries: 20,
+	})
+	if err != nil {
+		return err
+	}
+	defer cl.Close()
+
+	for _, cfg := range configs[1:] {
+		if err := cl.Join(ctx, cfg.RaftNodeID, cfg.RaftBind, false); err != nil {
+			return fmt.Errorf("join %s: %w", cfg.RaftNodeID, err)
+		}
+	}
+	return nil
+}
+
+// freeAddr returns a loopback address with a port that is free right now.
+func freeAddr() (string, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer lis.Close()
+	return lis.Addr().String(), nil
+}
+
+func init() {
+	devCmd.Flags().IntVar(&devNodes, "nodes", 3, "Number of nodes to run")
+	addLogFlags(devCmd)
+	addTraceFlags(devCmd)
+}
@@ -1,106 +1,266 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
-	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	"github.com/amjadjibon/raftd/client"
 )
 
-func NewKvServiceClient(grpcAddr string) (raftdv1.KVServiceClient, error) {
-	conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return nil, err
-	}
-
-	return raftdv1.NewKVServiceClient(conn), nil
-}
-
 var kvGetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get a value by key",
-	Run: func(cmd *cobra.Command, args []string) {
-		grpcAddr = cmd.Flag("grpc-addr").Value.String()
-		client, err := NewKvServiceClient(grpcAddr)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		opts, err := readOptions(cmd)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			return err
 		}
 
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
 		key := cmd.Flag("key").Value.String()
-		value, err := client.Get(cmd.Context(), &raftdv1.GetRequest{Key: key})
+		value, err := c.Get(ctx, key, opts...)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			return err
 		}
 
-		cmd.Println(string(value.Value))
+		return printResult(cmd, valueResult{Key: key, Value: value})
+	},
+}
+
+var kvRangeCmd = &cobra.Command{
+	Use:   "range",
+	Short: "List key/value pairs by key prefix",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		opts, err := readOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
+		prefix := cmd.Flag("prefix").Value.String()
+		limit, _ := cmd.Flags().GetInt64("limit")
+		kvs, err := c.Range(ctx, prefix, limit, opts...)
+		if err != nil {
+			return err
+		}
+
+		res := make(rangeResult, 0, len(kvs))
+		for _, kv := range kvs {
+			res = append(res, valueResult{Key: kv.Key, Value: kv.Value})
+		}
+
+		return printResult(cmd, res)
 	},
 }
 
 var kvSetCmd = &cobra.Command{
 	Use:   "set",
 	Short: "Set a value by key",
-	Run: func(cmd *cobra.Command, args []string) {
-		grpcAddr = cmd.Flag("grpc-addr").Value.String()
-		client, err := NewKvServiceClient(grpcAddr)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			return err
 		}
+		defer c.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
 
 		key := cmd.Flag("key").Value.String()
 		value := cmd.Flag("value").Value.String()
-		_, err = client.Set(cmd.Context(), &raftdv1.SetRequest{Key: key, Value: []byte(value)})
-		if err != nil {
-			cmd.PrintErr(err)
-			return
+		if err := c.Set(ctx, key, []byte(value)); err != nil {
+			return err
 		}
 
-		cmd.Println("Value set successfully")
+		return printResult(cmd, messageResult{Message: "Value set successfully"})
 	},
 }
 
 var deleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete a value by key",
-	Run: func(cmd *cobra.Command, args []string) {
-		grpcAddr = cmd.Flag("grpc-addr").Value.String()
-		client, err := NewKvServiceClient(grpcAddr)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			return err
 		}
+		defer c.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
 
 		key := cmd.Flag("key").Value.String()
-		_, err = client.Delete(cmd.Context(), &raftdv1.DeleteRequest{Key: key})
+		if err := c.Delete(ctx, key); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: "Value deleted successfully"})
+	},
+}
+
+var compactCmd = &cobra.Command{
+	Use:   "compact REVISION",
+	Short: "Drop the history superseded at a revision",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		revision, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid revision %q", args[0])
+		}
+
+		c, err := newClient(cmd)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			return err
 		}
+		defer c.Close()
 
-		cmd.Println("Value deleted successfully")
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
+		if err := c.Compact(ctx, revision); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: fmt.Sprintf("Compacted history to revision %d", revision)})
 	},
 }
 
+// readOptions maps the --linearizable, --max-staleness and --revision
+// flags to read options. Reads are stale unless one of the first two is
+// given.
+func readOptions(cmd *cobra.Command) ([]client.ReadOption, error) {
+	var opts []client.ReadOption
+	if revision, _ := cmd.Flags().GetUint64("revision"); revision > 0 {
+		opts = append(opts, client.AtRevision(revision))
+	}
+
+	if linearizable, _ := cmd.Flags().GetBool("linearizable"); linearizable {
+		return append(opts, client.Linearizable()), nil
+	}
+
+	maxStaleness, err := cmd.Flags().GetDuration("max-staleness")
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case maxStaleness < 0:
+		return nil, fmt.Errorf("invalid max staleness %s", maxStaleness)
+	case maxStaleness == 0:
+		return opts, nil
+	default:
+		return append(opts, client.WithMaxStaleness(maxStaleness)), nil
+	}
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Print the changes of a key as they are applied",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		var opts []client.WatchOption
+		if prefix, _ := cmd.Flags().GetBool("prefix"); prefix {
+			opts = append(opts, client.WithPrefix())
+		}
+		if after, _ := cmd.Flags().GetUint64("after"); after > 0 {
+			opts = append(opts, client.AfterRevision(after))
+		}
+
+		key := cmd.Flag("key").Value.String()
+		err = c.Watch(cmd.Context(), key, func(changes []client.Change) error {
+			for _, change := range changes {
+				if err := printResult(cmd, newChangeResult(change)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, opts...)
+		if cmd.Context().Err() != nil {
+			return nil
+		}
+		return err
+	},
+}
+
+type changeResult struct {
+	Revision uint64 `json:"revision"`
+	Key      string `json:"key"`
+	Value    []byte `json:"value,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"`
+}
+
+func newChangeResult(c client.Change) changeResult {
+	return changeResult{Revision: c.Revision, Key: c.Key, Value: c.Value, Deleted: c.Deleted}
+}
+
+// Table prints the change on a line of its own, so that changes can be
+// printed as they come.
+func (r changeResult) Table(w io.Writer) error {
+	if r.Deleted {
+		_, err := fmt.Fprintf(w, "%d DELETE %s\n", r.Revision, r.Key)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d SET %s %s\n", r.Revision, r.Key, r.Value)
+	return err
+}
+
+// Raw prints the change as a JSON line.
+func (r changeResult) Raw(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
 func init() {
 	kvGetCmd.Flags().String("key", "", "Key to get")
-	kvGetCmd.Flags().String("grpc-addr", ":8080", "gRPC server address")
+	kvGetCmd.Flags().Duration("max-staleness", 0, "Fail unless the node heard from the leader within this duration")
+	kvGetCmd.Flags().Bool("linearizable", false, "Read through the leader, reflecting every acknowledged write")
+	kvGetCmd.Flags().Uint64("revision", 0, "Read the value the key had at this revision")
+	kvGetCmd.MarkFlagsMutuallyExclusive("max-staleness", "linearizable")
+
+	kvRangeCmd.Flags().String("prefix", "", "Key prefix to list")
+	kvRangeCmd.Flags().Int64("limit", 0, "Maximum number of pairs to list")
+	kvRangeCmd.Flags().Duration("max-staleness", 0, "Fail unless the node heard from the leader within this duration")
+	kvRangeCmd.Flags().Bool("linearizable", false, "Read through the leader, reflecting every acknowledged write")
+	kvRangeCmd.Flags().Uint64("revision", 0, "List the pairs as they were at this revision")
+	kvRangeCmd.MarkFlagsMutuallyExclusive("max-staleness", "linearizable")
 
 	kvSetCmd.Flags().String("key", "", "Key to set")
 	kvSetCmd.Flags().String("value", "", "Value to set")
-	kvSetCmd.Flags().String("grpc-addr", ":8080", "gRPC server address")
 
 	deleteCmd.Flags().String("key", "", "Key to delete")
-	deleteCmd.Flags().String("grpc-addr", ":8080", "gRPC server address")
+
+	watchCmd.Flags().String("key", "", "Key to watch")
+	watchCmd.Flags().Bool("prefix", false, "Watch every key starting with the key")
+	watchCmd.Flags().Uint64("after", 0, "Print the changes after this revision as well")
 
 	_ = kvGetCmd.MarkFlagRequired("key")
 	_ = kvSetCmd.MarkFlagRequired("key")
 	_ = kvSetCmd.MarkFlagRequired("value")
 	_ = deleteCmd.MarkFlagRequired("key")
-	_ = kvGetCmd.MarkFlagRequired("grpc-addr")
-	_ = kvSetCmd.MarkFlagRequired("grpc-addr")
-	_ = deleteCmd.MarkFlagRequired("grpc-addr")
-
+	_ = watchCmd.MarkFlagRequired("key")
 }
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logLevel  string
+	logFormat string
+)
+
+var logFormats = []string{"text", "json"}
+
+// addLogFlags adds --log-level and --log-format to a command that runs
+// servers.
+func addLogFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&logLevel, "log-level", "info", "Log level, one of debug, info, warn, error")
+	cmd.Flags().StringVar(&logFormat, "log-format", "text", "Log format, one of "+strings.Join(logFormats, ", "))
+
+	_ = cmd.RegisterFlagCompletionFunc("log-level", cobra.FixedCompletions([]string{"debug", "info", "warn", "error"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("log-format", cobra.FixedCompletions(logFormats, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// newLogger returns a logger writing to stderr as set by the log flags.
+func newLogger() (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", logLevel)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch logFormat {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q, must be one of %s", logFormat, strings.Join(logFormats, ", "))
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/amjadjibon/raftd/server"
+)
+
+var migrateLogStoreCmd = &cobra.Command{
+	Use:   "migrate-log-store",
+	Short: "Convert the raft log of a stopped node to another backend",
+	Long: `Convert the raft log and stable store of a stopped node to another backend.
+
+Stop the node, run migrate-log-store on its raft directory and start it again
+with the new --log-store. The old store is kept with a .migrated suffix and
+can be removed once the node runs. Nodes of a cluster can be migrated one at a
+time, since every node keeps its own log.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("raft-dir")
+		to, _ := cmd.Flags().GetString("to")
+
+		from, err := server.MigrateLogStore(dir, to)
+		if err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: fmt.Sprintf("Log store migrated from %s to %s", from, to)})
+	},
+}
+
+func init() {
+	migrateLogStoreCmd.Flags().String("raft-dir", "/tmp/raft", "Raft data directory")
+	migrateLogStoreCmd.Flags().String("to", "", "Backend to migrate to, "+server.LogStoreBolt+" or "+server.LogStoreWAL)
+	_ = migrateLogStoreCmd.MarkFlagRequired("to")
+	_ = migrateLogStoreCmd.RegisterFlagCompletionFunc("to", cobra.FixedCompletions([]string{server.LogStoreBolt, server.LogStoreWAL}, cobra.ShellCompDirectiveNoFileComp))
+}
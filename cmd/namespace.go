@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/amjadjibon/raftd/client"
+)
+
+var namespaceCmd = &cobra.Command{
+	Use:   "namespace",
+	Short: "Manage namespaces and their quotas",
+}
+
+var namespaceCreateCmd = &cobra.Command{
+	Use:   "create NAME",
+	Short: "Create a namespace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
+		if err := c.CreateNamespace(ctx, args[0], quotaFlags(cmd)); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: fmt.Sprintf("Namespace %s created", args[0])})
+	},
+}
+
+var namespaceGetCmd = &cobra.Command{
+	Use:   "get NAME",
+	Short: "Show a namespace with its quota and usage",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
+		ns, err := c.GetNamespace(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		return printResult(cmd, namespacesResult{newNamespaceResult(ns)})
+	},
+}
+
+var namespaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the namespaces with their quotas and usage",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
+		list, err := c.ListNamespaces(ctx)
+		if err != nil {
+			return err
+		}
+
+		res := make(namespacesResult, 0, len(list))
+		for _, ns := range list {
+			res = append(res, newNamespaceResult(ns))
+		}
+
+		return printResult(cmd, res)
+	},
+}
+
+var namespaceUpdateCmd = &cobra.Command{
+	Use:   "update NAME",
+	Short: "Replace the quota of a namespace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
+		if err := c.UpdateNamespace(ctx, args[0], quotaFlags(cmd)); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: fmt.Sprintf("Namespace %s updated", args[0])})
+	},
+}
+
+var namespaceDeleteCmd = &cobra.Command{
+	Use:   "delete NAME",
+	Short: "Delete a namespace along with its keys",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
+		if err := c.DeleteNamespace(ctx, args[0]); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: fmt.Sprintf("Namespace %s deleted", args[0])})
+	},
+}
+
+func quotaFlags(cmd *cobra.Command) client.Quota {
+	var quota client.Quota
+	quota.MaxKeys, _ = cmd.Flags().GetInt64("max-keys")
+	quota.MaxBytes, _ = cmd.Flags().GetInt64("max-bytes")
+	quota.MaxValueSize, _ = cmd.Flags().GetInt64("max-value-size")
+	return quota
+}
+
+type namespaceResult struct {
+	Name         string `json:"name"`
+	MaxKeys      int64  `json:"max_keys"`
+	MaxBytes     int64  `json:"max_bytes"`
+	MaxValueSize int64  `json:"max_value_size"`
+	Keys         int64  `json:"keys"`
+	Bytes        int64  `json:"bytes"`
+}
+
+func newNamespaceResult(ns *client.Namespace) namespaceResult {
+	return namespaceResult{
+		Name:         ns.Name,
+		MaxKeys:      ns.Quota.MaxKeys,
+		MaxBytes:     ns.Quota.MaxBytes,
+		MaxValueSize: ns.Quota.MaxValueSize,
+		Keys:         ns.Keys,
+		Bytes:        ns.Bytes,
+	}
+}
+
+type namespacesResult []namespaceResult
+
+func (r namespacesResult) Table(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAME\tKEYS\tBYTES\tMAX KEYS\tMAX BYTES\tMAX VALUE SIZE")
+	for _, ns := range r {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\n", ns.Name, ns.Keys, ns.Bytes, limit(ns.MaxKeys), limit(ns.MaxBytes), limit(ns.MaxValueSize))
+	}
+	return tw.Flush()
+}
+
+func (r namespacesResult) Raw(w io.Writer) error {
+	for _, ns := range r {
+		if _, err := fmt.Fprintln(w, ns.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limit prints a quota, zero meaning none.
+func limit(n int64) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{namespaceCreateCmd, namespaceUpdateCmd} {
+		cmd.Flags().Int64("max-keys", 0, "Maximum number of keys, zero for no limit")
+		cmd.Flags().Int64("max-bytes", 0, "Maximum total size of keys and values, zero for no limit")
+		cmd.Flags().Int64("max-value-size", 0, "Maximum size of a value, zero for no limit")
+	}
+
+	namespaceCmd.AddCommand(namespaceCreateCmd)
+	namespaceCmd.AddCommand(namespaceGetCmd)
+	namespaceCmd.AddCommand(namespaceListCmd)
+	namespaceCmd.AddCommand(namespaceUpdateCmd)
+	namespaceCmd.AddCommand(namespaceDeleteCmd)
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputRaw   = "raw"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputRaw}
+
+// result is what a command prints. It is encoded with encoding/json for
+// --output json and printed by Table or Raw otherwise.
+type result interface {
+	Table(w io.Writer) error
+	Raw(w io.Writer) error
+}
+
+func printResult(cmd *cobra.Command, r result) error {
+	w := cmd.OutOrStdout()
+	switch output {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case outputRaw:
+		return r.Raw(w)
+	default:
+		return r.Table(w)
+	}
+}
+
+// messageResult reports a successful command that has nothing to return.
+type messageResult struct {
+	Message string `json:"message"`
+}
+
+func (r messageResult) Table(w io.Writer) error {
+	_, err := fmt.Fprintln(w, r.Message)
+	return err
+}
+
+func (r messageResult) Raw(io.Writer) error {
+	return nil
+}
+
+type valueResult struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+func (r valueResult) Table(w io.Writer) error {
+	_, err := fmt.Fprintln(w, string(r.Value))
+	return err
+}
+
+// Raw writes the value as is, without a trailing newline, so that binary
+// values survive a pipe.
+func (r valueResult) Raw(w io.Writer) error {
+	_, err := w.Write(r.Value)
+	return err
+}
+
+type counterResult struct {
+	Key   string `json:"key"`
+	Value int64  `json:"value"`
+}
+
+func (r counterResult) Table(w io.Writer) error {
+	_, err := fmt.Fprintln(w, r.Value)
+	return err
+}
+
+func (r counterResult) Raw(w io.Writer) error {
+	_, err := fmt.Fprintln(w, r.Value)
+	return err
+}
+
+type rangeResult []valueResult
+
+func (r rangeResult) Table(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "KEY\tVALUE")
+	for _, kv := range r {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\n", kv.Key, kv.Value)
+	}
+	return tw.Flush()
+}
+
+func (r rangeResult) Raw(w io.Writer) error {
+	for _, kv := range r {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", kv.Key, kv.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type peerResult struct {
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	Replica bool   `json:"replica"`
+}
+
+type statusResult struct {
+	Leader     string           `json:"leader"`
+	LeaderID   string           `json:"leader_id"`
+	Peers      []peerResult     `json:"peers"`
+	Namespaces namespacesResult `json:"namespaces"`
+	Storage    storageResult    `json:"storage"`
+
+	Revision        uint64 `json:"revision"`
+	CompactRevision uint64 `json:"compact_revision"`
+}
+
+type storageResult struct {
+	UsedBytes  int64 `json:"used_bytes"`
+	QuotaBytes int64 `json:"quota_bytes"`
+	Alarm      bool  `json:"alarm"`
+}
+
+func (r statusResult) Table(w io.Writer) error {
+	_, _ = fmt.Fprintf(w, "Leader: %s\n", r.Leader)
+	_, _ = fmt.Fprintf(w, "Revision: %d (compacted to %d)\n", r.Revision, r.CompactRevision)
+	_, _ = fmt.Fprintf(w, "Storage: %d bytes used, quota %s", r.Storage.UsedBytes, limit(r.Storage.QuotaBytes))
+	if r.Storage.Alarm {
+		_, _ = fmt.Fprint(w, " (exceeded, writes rejected)")
+	}
+	_, _ = fmt.Fprint(w, "\n\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tADDRESS\tROLE")
+	for _, peer := range r.Peers {
+		role := "voter"
+		if peer.Replica {
+			role = "replica"
+		}
+		if peer.ID == r.LeaderID {
+			role = "leader"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", peer.ID, peer.Address, role)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Namespaces) == 0 {
+		return nil
+	}
+	_, _ = fmt.Fprintln(w)
+	return r.Namespaces.Table(w)
+}
+
+func (r statusResult) Raw(w io.Writer) error {
+	_, err := fmt.Fprintln(w, r.Leader)
+	return err
+}
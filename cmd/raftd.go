@@ -2,83 +2,112 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
-	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
 )
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Get the status of the Raft cluster",
-	Run: func(cmd *cobra.Command, args []string) {
-		grpcAddr = cmd.Flag("grpc-addr").Value.String()
-		client, err := NewRaftServiceClient(grpcAddr)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			return err
 		}
+		defer c.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
 
-		status, err := client.Status(cmd.Context(), &raftdv1.StatusRequest{})
+		status, err := c.Status(ctx)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			return err
 		}
 
-		cmd.Println()
-		cmd.Printf("Leader: %s\n", status.Leader)
-		cmd.Println("Peers:")
+		res := statusResult{
+			Leader:   status.Leader,
+			LeaderID: status.LeaderId,
+			Peers:    make([]peerResult, 0, len(status.Peers)),
+			Storage: storageResult{
+				UsedBytes:  status.Storage.GetUsedBytes(),
+				QuotaBytes: status.Storage.GetQuotaBytes(),
+				Alarm:      status.Storage.GetAlarm(),
+			},
+			Revision:        status.Revision,
+			CompactRevision: status.CompactRevision,
+		}
 		for _, server := range status.Peers {
-			cmd.Printf("  - ID: %s, Address: %s\n", server.Id, server.Address)
+			res.Peers = append(res.Peers, peerResult{
+				ID:      server.Id,
+				Address: server.Address,
+				Replica: server.Replica,
+			})
 		}
+		for _, ns := range status.Namespaces {
+			res.Namespaces = append(res.Namespaces, namespaceResult{
+				Name:         ns.Name,
+				MaxKeys:      ns.Quota.GetMaxKeys(),
+				MaxBytes:     ns.Quota.GetMaxBytes(),
+				MaxValueSize: ns.Quota.GetMaxValueSize(),
+				Keys:         ns.Usage.GetKeys(),
+				Bytes:        ns.Usage.GetBytes(),
+			})
+		}
+
+		return printResult(cmd, res)
 	},
 }
 
 var joinCmd = &cobra.Command{
 	Use:   "join",
 	Short: "Join a Raft node to the cluster",
-	Run: func(cmd *cobra.Command, args []string) {
-		grpcAddr = cmd.Flag("grpc-addr").Value.String()
-		client, err := NewRaftServiceClient(grpcAddr)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			return err
 		}
+		defer c.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
 
 		joinAddr := cmd.Flag("join-addr").Value.String()
 		joinId := cmd.Flag("join-id").Value.String()
-		_, err = client.Join(cmd.Context(), &raftdv1.JoinRequest{
-			Id:      joinId,
-			Address: joinAddr,
-		})
+		nonVoter, _ := cmd.Flags().GetBool("non-voter")
+		if err := c.Join(ctx, joinId, joinAddr, nonVoter); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: "Node joined successfully"})
+	},
+}
+
+var leaveCmd = &cobra.Command{
+	Use:   "leave",
+	Short: "Remove a Raft node from the cluster",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(cmd)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			return err
+		}
+		defer c.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
+		if err := c.Leave(ctx, cmd.Flag("leave-id").Value.String()); err != nil {
+			return err
 		}
 
-		cmd.Println("Node joined successfully")
+		return printResult(cmd, messageResult{Message: "Node left successfully"})
 	},
 }
 
 func init() {
-	statusCmd.Flags().String("grpc-addr", "", "gRPC server address")
-	_ = statusCmd.MarkFlagRequired("grpc-addr")
-
-	joinCmd.Flags().String("grpc-addr", "", "gRPC server address")
 	joinCmd.Flags().String("join-addr", "", "Address of the node to join")
 	joinCmd.Flags().String("join-id", "", "ID of the node to join")
-	_ = joinCmd.MarkFlagRequired("grpc-addr")
+	joinCmd.Flags().Bool("non-voter", false, "Join the node as a read replica")
 	_ = joinCmd.MarkFlagRequired("join-addr")
 	_ = joinCmd.MarkFlagRequired("join-id")
-}
-
-func NewRaftServiceClient(addr string) (raftdv1.RaftServiceClient, error) {
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return nil, err
-	}
-
-	raftClient := raftdv1.NewRaftServiceClient(conn)
 
-	return raftClient, err
+	leaveCmd.Flags().String("leave-id", "", "ID of the node to remove")
+	_ = leaveCmd.MarkFlagRequired("leave-id")
 }
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/raft"
+	"github.com/spf13/cobra"
+
+	"github.com/amjadjibon/raftd/server"
+)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Rewrite the cluster membership of a stopped node",
+	Long: `Rewrite the cluster membership of a stopped node.
+
+Use this when a majority of nodes is lost and the cluster can no longer elect
+a leader. Stop every surviving node, run recover on each of them with the same
+peers file and start them again. The peers file uses the raft peers.json
+format:
+
+  [
+    {"id": "node1", "address": "10.0.0.1:7000", "non_voter": false},
+    {"id": "node2", "address": "10.0.0.2:7000", "non_voter": false}
+  ]`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := cmd.Flag("raft-dir").Value.String()
+
+		newConfig, err := raft.ReadConfigJSON(cmd.Flag("peers").Value.String())
+		if err != nil {
+			return err
+		}
+
+		oldConfig, err := server.Configuration(dir)
+		if err != nil {
+			return err
+		}
+
+		printConfigurationDiff(cmd, oldConfig, newConfig)
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if dryRun {
+			return nil
+		}
+
+		if err := server.Recover(dir, newConfig); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: "Configuration recovered successfully"})
+	},
+}
+
+// printConfigurationDiff prints the servers of both configurations, prefixed
+// with "-" when removed, "+" when added and " " when unchanged.
+func printConfigurationDiff(cmd *cobra.Command, oldConfig, newConfig raft.Configuration) {
+	servers := make(map[raft.ServerID]raft.Server, len(newConfig.Servers))
+	for _, server := range newConfig.Servers {
+		servers[server.ID] = server
+	}
+
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Configuration changes:")
+	for _, old := range oldConfig.Servers {
+		server, ok := servers[old.ID]
+		switch {
+		case !ok:
+			printServer(cmd, "-", old)
+		case server != old:
+			printServer(cmd, "-", old)
+			printServer(cmd, "+", server)
+		default:
+			printServer(cmd, " ", old)
+		}
+		delete(servers, old.ID)
+	}
+
+	for _, server := range newConfig.Servers {
+		if _, ok := servers[server.ID]; ok {
+			printServer(cmd, "+", server)
+		}
+	}
+}
+
+func printServer(cmd *cobra.Command, prefix string, server raft.Server) {
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s ID: %s, Address: %s, Suffrage: %s\n", prefix, server.ID, server.Address, server.Suffrage)
+}
+
+func init() {
+	recoverCmd.Flags().String("raft-dir", "/tmp/raft", "Raft data directory")
+	recoverCmd.Flags().String("peers", "", "Peers file with the new configuration")
+	recoverCmd.Flags().Bool("dry-run", false, "Only print the configuration changes")
+	_ = recoverCmd.MarkFlagRequired("peers")
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	"github.com/amjadjibon/raftd/server"
+	"github.com/amjadjibon/raftd/server/testcluster"
+)
+
+func TestRecover(t *testing.T) {
+	c := testcluster.New(t, 3)
+	ctx := context.Background()
+
+	if err := c.Client().Set(ctx, "k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	c.WaitForApplied()
+
+	// Two of the three nodes are lost for good, and the survivor is
+	// stopped to be recovered.
+	survivor := c.Node(0)
+	for _, node := range c.Nodes() {
+		c.Kill(node)
+	}
+
+	peers := filepath.Join(t.TempDir(), "peers.json")
+	config := fmt.Sprintf(`[{"id": %q, "address": %q, "non_voter": false}]`, survivor.ID, survivor.Addr)
+	if err := os.WriteFile(peers, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(dryRun bool) string {
+		t.Helper()
+
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetErr(&out)
+		rootCmd.SetArgs([]string{"recover", "--raft-dir", survivor.Dir, "--peers", peers, fmt.Sprintf("--dry-run=%t", dryRun)})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("recover: %v\n%s", err, out.String())
+		}
+		return out.String()
+	}
+
+	// A dry run prints the changes and leaves the configuration alone.
+	want := `Configuration changes:
+  ID: node0, Address: node0, Suffrage: Voter
+- ID: node1, Address: node1, Suffrage: Voter
+- ID: node2, Address: node2, Suffrage: Voter
+`
+	if got := run(true); got != want {
+		t.Fatalf("dry run printed:\n%s\nwant:\n%s", got, want)
+	}
+	if config, err := server.Configuration(survivor.Dir); err != nil || len(config.Servers) != 3 {
+		t.Fatalf("configuration after a dry run = %v, %v, want the three nodes", config.Servers, err)
+	}
+
+	if got := run(false); !strings.HasPrefix(got, want) {
+		t.Fatalf("recover printed:\n%s\nwant the changes first:\n%s", got, want)
+	}
+
+	// The survivor elects itself alone and keeps its data.
+	c.Restart(survivor)
+	if leader := c.WaitForLeader(); leader != survivor {
+		t.Fatalf("leader is %s, want %s", leader.ID, survivor.ID)
+	}
+	resp, err := survivor.Raftd().Get(ctx, &raftdv1.GetRequest{Key: "k"})
+	if err != nil || string(resp.Value) != "v" {
+		t.Fatalf("get k = %v, %v, want v", resp, err)
+	}
+	if err := c.Client().Set(ctx, "k", []byte("after")); err != nil {
+		t.Fatal(err)
+	}
+}
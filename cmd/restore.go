@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/amjadjibon/raftd/server"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore the cluster state from a snapshot file",
+	Long: `Restore the cluster state from a snapshot file written by backup.
+
+By default the snapshot is streamed to the leader, which installs it on every
+node of the running cluster. With --raft-node-id, --raft-dir is seeded offline
+instead so that the next start brings up a fresh single-node cluster with the
+snapshot state, which is the way back after quorum has been lost.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from := cmd.Flag("from").Value.String()
+
+		if cmd.Flags().Changed("raft-node-id") {
+			err := server.Seed(
+				cmd.Flag("raft-dir").Value.String(),
+				cmd.Flag("raft-addr").Value.String(),
+				cmd.Flag("raft-node-id").Value.String(),
+				from,
+			)
+			if err != nil {
+				return err
+			}
+
+			return printResult(cmd, messageResult{Message: "Raft directory seeded successfully"})
+		}
+
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		f, err := os.Open(from)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+
+		if err := c.Restore(ctx, f); err != nil {
+			return err
+		}
+
+		return printResult(cmd, messageResult{Message: "Snapshot restored successfully"})
+	},
+}
+
+func init() {
+	restoreCmd.Flags().String("from", "", "Snapshot file written by backup to restore from")
+	restoreCmd.Flags().String("raft-dir", "/tmp/raft", "Raft data directory to seed")
+	restoreCmd.Flags().String("raft-addr", "", "Raft bind address of the seeded node")
+	restoreCmd.Flags().String("raft-node-id", "", "Raft node ID of the seeded node")
+	_ = restoreCmd.MarkFlagRequired("from")
+	restoreCmd.MarkFlagsRequiredTogether("raft-addr", "raft-node-id")
+}
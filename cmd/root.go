@@ -1,32 +1,122 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/amjadjibon/raftd/client"
+)
+
+const defaultEndpoint = "localhost:8080"
+
+var (
+	endpoints   []string
+	contextName string
+	namespace   string
+	timeout     time.Duration
+	output      string
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "raftd",
-	Short: "A brief description of your application",
+	Short: "A distributed key-value store built on raft",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(outputFormats, output) {
+			return fmt.Errorf("invalid output %q, must be one of %s", output, strings.Join(outputFormats, ", "))
+		}
+
+		// Flags parsed fine, so any error from here on is not a usage
+		// error.
+		cmd.SilenceUsage = true
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		_ = cmd.Help()
 	},
 }
 
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
 }
 
+// newClient returns a client for the endpoints given with --endpoints, or
+// else the ones of the selected context.
+func newClient(cmd *cobra.Command) (*client.Client, error) {
+	if cmd.Flags().Changed("endpoints") {
+		return client.New(client.Config{Endpoints: endpoints, Namespace: namespace})
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	name := contextName
+	if name == "" {
+		name = cfg.Current
+	}
+	if name == "" {
+		return client.New(client.Config{Endpoints: []string{defaultEndpoint}, Namespace: namespace})
+	}
+
+	c, ok := cfg.Contexts[name]
+	if !ok {
+		return nil, fmt.Errorf("context %q not found", name)
+	}
+
+	return client.New(client.Config{Endpoints: c.Endpoints, Namespace: namespace})
+}
+
+// requestContext bounds a command's calls to the cluster by --timeout.
+func requestContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(cmd.Context(), timeout)
+}
+
 func init() {
+	rootCmd.PersistentFlags().StringSliceVar(&endpoints, "endpoints", nil, "Comma separated gRPC addresses of the cluster nodes (default \""+defaultEndpoint+"\")")
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "Named cluster from the contexts file to use instead of the current one")
+	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Namespace of key commands (default \"default\")")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 5*time.Second, "Timeout of requests to the cluster")
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", outputTable, "Output format, one of "+strings.Join(outputFormats, ", "))
+
+	_ = rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCmd.RegisterFlagCompletionFunc("context", completeContextNames)
+
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(joinCmd)
+	rootCmd.AddCommand(leaveCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(recoverCmd)
+	rootCmd.AddCommand(migrateLogStoreCmd)
+	rootCmd.AddCommand(contextCmd)
+	rootCmd.AddCommand(devCmd)
+	rootCmd.AddCommand(namespaceCmd)
+	rootCmd.AddCommand(auditCmd)
 
 	rootCmd.AddCommand(kvGetCmd)
+	rootCmd.AddCommand(kvRangeCmd)
 	rootCmd.AddCommand(kvSetCmd)
 	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(incrCmd)
+	rootCmd.AddCommand(decrCmd)
+	rootCmd.AddCommand(compactCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
 }
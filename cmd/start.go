@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -14,32 +18,192 @@ var (
 	raftAddr   string
 	raftNodeID string
 	grpcAddr   string
+	logStore   string
+	nonVoter   bool
+	bootstrap  bool
+
+	maxKeySize   int
+	maxValueSize int
+	maxMsgSize   int
+	storageQuota int64
+
+	rateLimit         float64
+	rateBurst         int
+	clientRateLimit   float64
+	clientRateBurst   int
+	maxInFlightWrites int
+	methodRateLimits  []string
+
+	applyTimeout    time.Duration
+	maxApplyTimeout time.Duration
+
+	compactionRevisions uint64
+	compactionAge       time.Duration
+	compactionInterval  time.Duration
+
+	auditFile       string
+	auditMaxSize    int64
+	auditMaxFiles   int
+	auditReplicated bool
+
+	cdcFile         string
+	cdcFileFormat   string
+	cdcFileMaxSize  int64
+	cdcFileMaxFiles int
+	cdcWebhook      string
+
+	cdcConsumerRetention time.Duration
 )
 
 var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Start the Raft server",
-	Run: func(cmd *cobra.Command, args []string) {
-		err := server.Run(
-			cmd.Context(),
-			raftDir,
-			raftAddr,
-			raftNodeID,
-			grpcAddr,
-		)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger, err := newLogger()
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			return err
 		}
+
+		methodLimits, err := parseMethodRateLimits(methodRateLimits)
+		if err != nil {
+			return err
+		}
+
+		changeSinks, err := newChangeSinks()
+		if err != nil {
+			return err
+		}
+
+		shutdownTracing, err := setupTracing(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		err = server.Run(cmd.Context(), server.Config{
+			RaftDir:    raftDir,
+			LogStore:   logStore,
+			RaftBind:   raftAddr,
+			RaftNodeID: raftNodeID,
+			GRPCAddr:   grpcAddr,
+			Bootstrap:  bootstrap,
+			NonVoter:   nonVoter,
+			Logger:     logger,
+
+			MaxKeySize:   maxKeySize,
+			MaxValueSize: maxValueSize,
+			MaxMsgSize:   maxMsgSize,
+			StorageQuota: storageQuota,
+
+			RateLimit: server.RateLimit{
+				Rate:           rateLimit,
+				Burst:          rateBurst,
+				PerClientRate:  clientRateLimit,
+				PerClientBurst: clientRateBurst,
+			},
+			MethodRateLimits:  methodLimits,
+			MaxInFlightWrites: maxInFlightWrites,
+
+			ApplyTimeout:    applyTimeout,
+			MaxApplyTimeout: maxApplyTimeout,
+
+			CompactionRevisions: compactionRevisions,
+			CompactionAge:       compactionAge,
+			CompactionInterval:  compactionInterval,
+
+			AuditFile:       auditFile,
+			AuditMaxSize:    auditMaxSize,
+			AuditMaxFiles:   auditMaxFiles,
+			AuditReplicated: auditReplicated,
+
+			ChangeSinks:             changeSinks,
+			ChangeConsumerRetention: cdcConsumerRetention,
+		})
+		return errors.Join(err, shutdownTracing(context.WithoutCancel(cmd.Context())))
 	},
 }
 
+// newChangeSinks returns the sinks of the --cdc-file and --cdc-webhook
+// flags, named "file" and "webhook".
+func newChangeSinks() (map[string]server.ChangeSink, error) {
+	sinks := make(map[string]server.ChangeSink)
+	if cdcFile != "" {
+		sink, err := server.NewFileSink(cdcFile, cdcFileFormat, cdcFileMaxSize, cdcFileMaxFiles)
+		if err != nil {
+			return nil, err
+		}
+		sinks["file"] = sink
+	}
+	if cdcWebhook != "" {
+		sinks["webhook"] = server.NewWebhookSink(cdcWebhook, nil)
+	}
+	return sinks, nil
+}
+
 func init() {
 	startCmd.Flags().StringVar(&raftDir, "raft-dir", "/tmp/raft", "Raft data directory")
+	startCmd.Flags().StringVar(&logStore, "log-store", server.LogStoreBolt, "Backend of the raft log, one of "+strings.Join(server.LogStores, ", "))
 	startCmd.Flags().StringVar(&raftAddr, "raft-addr", "", "Raft bind address")
 	startCmd.Flags().StringVar(&raftNodeID, "raft-node-id", "", "Raft node ID")
 	startCmd.Flags().StringVar(&grpcAddr, "grpc-addr", ":8080", "gRPC server address")
+	startCmd.Flags().BoolVar(&bootstrap, "bootstrap", true, "Form a single-node cluster on first start, disable on nodes that will be joined")
+	startCmd.Flags().BoolVar(&nonVoter, "non-voter", false, "Start as a read replica that waits to be joined as a non-voter")
+	startCmd.Flags().IntVar(&maxKeySize, "max-key-size", server.DefaultMaxKeySize, "Maximum key size in bytes")
+	startCmd.Flags().IntVar(&maxValueSize, "max-value-size", server.DefaultMaxValueSize, "Maximum value size in bytes")
+	startCmd.Flags().IntVar(&maxMsgSize, "max-msg-size", server.DefaultMaxMsgSize, "Maximum gRPC message size in bytes")
+	startCmd.Flags().Int64Var(&storageQuota, "storage-quota", 0, "Cluster-wide limit on the size of keys, values and their history in bytes, 0 for none")
+	startCmd.Flags().Float64Var(&rateLimit, "rate-limit", 0, "Calls per second admitted from all clients, 0 for no limit")
+	startCmd.Flags().IntVar(&rateBurst, "rate-burst", 0, "Burst of calls admitted from all clients (default --rate-limit)")
+	startCmd.Flags().Float64Var(&clientRateLimit, "client-rate-limit", 0, "Calls per second admitted from each client, 0 for no limit")
+	startCmd.Flags().IntVar(&clientRateBurst, "client-rate-burst", 0, "Burst of calls admitted from each client (default --client-rate-limit)")
+	startCmd.Flags().IntVar(&maxInFlightWrites, "max-in-flight-writes", 0, "Writes applied at once, 0 for no limit")
+	startCmd.Flags().StringArrayVar(&methodRateLimits, "method-rate-limit", nil, "Rate limit of one RPC as METHOD=RATE[/BURST], such as raftd.v1.KVService/Set=100/200, repeatable")
+	startCmd.Flags().DurationVar(&applyTimeout, "apply-timeout", server.DefaultApplyTimeout, "How long writes wait for raft when the call has no deadline")
+	startCmd.Flags().DurationVar(&maxApplyTimeout, "max-apply-timeout", server.DefaultMaxApplyTimeout, "How long writes wait for raft at most, whatever the deadline of the call")
+	startCmd.Flags().Uint64Var(&compactionRevisions, "compaction-revisions", 0, "Keep the history of the last revisions, 0 to keep it all")
+	startCmd.Flags().DurationVar(&compactionAge, "compaction-age", 0, "Keep the history written within this age, 0 to keep it all")
+	startCmd.Flags().DurationVar(&compactionInterval, "compaction-interval", server.DefaultCompactionInterval, "How often the leader compacts the history")
+	startCmd.Flags().StringVar(&auditFile, "audit-file", "", "JSONL file recording every command applied on the node, empty for none")
+	startCmd.Flags().Int64Var(&auditMaxSize, "audit-max-size", server.DefaultAuditMaxSize, "Size in bytes at which the audit file is rotated")
+	startCmd.Flags().IntVar(&auditMaxFiles, "audit-max-files", server.DefaultAuditMaxFiles, "Rotated audit files to keep")
+	startCmd.Flags().BoolVar(&auditReplicated, "audit-replicated", false, "Record the commands proposed while leader in the replicated audit log")
+	startCmd.Flags().StringVar(&cdcFile, "cdc-file", "", "File the leader appends the changes of every key to, empty for none")
+	startCmd.Flags().StringVar(&cdcFileFormat, "cdc-file-format", server.ChangeFormatJSON, "Format of --cdc-file, one of "+server.ChangeFormatJSON+", "+server.ChangeFormatProto)
+	startCmd.Flags().Int64Var(&cdcFileMaxSize, "cdc-file-max-size", server.DefaultChangeFileMaxSize, "Size in bytes at which the change file is rotated")
+	startCmd.Flags().IntVar(&cdcFileMaxFiles, "cdc-file-max-files", server.DefaultChangeFileMaxFiles, "Rotated change files to keep")
+	startCmd.Flags().StringVar(&cdcWebhook, "cdc-webhook", "", "URL the leader posts the changes of every key to, empty for none")
+	startCmd.Flags().DurationVar(&cdcConsumerRetention, "cdc-consumer-retention", server.DefaultChangeConsumerRetention, "How long a change consumer holds back compaction after its last ack")
+	_ = startCmd.RegisterFlagCompletionFunc("log-store", cobra.FixedCompletions(server.LogStores, cobra.ShellCompDirectiveNoFileComp))
+	addLogFlags(startCmd)
+	addTraceFlags(startCmd)
 
 	_ = startCmd.MarkFlagRequired("raft-addr")
 	_ = startCmd.MarkFlagRequired("raft-node-id")
 }
+
+// parseMethodRateLimits parses the --method-rate-limit flags.
+func parseMethodRateLimits(flags []string) (map[string]server.RateLimit, error) {
+	limits := make(map[string]server.RateLimit, len(flags))
+	for _, flag := range flags {
+		method, limit, ok := strings.Cut(flag, "=")
+		if !ok || method == "" {
+			return nil, fmt.Errorf("invalid --method-rate-limit %q, want METHOD=RATE[/BURST]", flag)
+		}
+		if !strings.HasPrefix(method, "/") {
+			method = "/" + method
+		}
+
+		rate, burst, hasBurst := strings.Cut(limit, "/")
+		var l server.RateLimit
+		var err error
+		if l.Rate, err = strconv.ParseFloat(rate, 64); err != nil || l.Rate < 0 {
+			return nil, fmt.Errorf("invalid rate in --method-rate-limit %q", flag)
+		}
+		if hasBurst {
+			if l.Burst, err = strconv.Atoi(burst); err != nil || l.Burst < 0 {
+				return nil, fmt.Errorf("invalid burst in --method-rate-limit %q", flag)
+			}
+		}
+		limits[method] = l
+	}
+	return limits, nil
+}
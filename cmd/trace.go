@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	traceNone   = "none"
+	traceStdout = "stdout"
+	traceFile   = "file"
+	traceOTLP   = "otlp"
+)
+
+var traceExporters = []string{traceNone, traceStdout, traceFile, traceOTLP}
+
+var (
+	traceExporter string
+	traceFilePath string
+)
+
+// addTraceFlags adds --trace-exporter and --trace-file to a command that
+// runs servers.
+func addTraceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&traceExporter, "trace-exporter", traceNone, "Where to export traces, one of "+strings.Join(traceExporters, ", ")+"; otlp is configured with the OTEL_EXPORTER_OTLP_* environment variables")
+	cmd.Flags().StringVar(&traceFilePath, "trace-file", "raftd-traces.json", "File traces are written to with --trace-exporter file")
+
+	_ = cmd.RegisterFlagCompletionFunc("trace-exporter", cobra.FixedCompletions(traceExporters, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// traceShutdownTimeout bounds flushing the spans left on exit.
+const traceShutdownTimeout = 5 * time.Second
+
+// setupTracing installs the global tracer provider and propagator chosen
+// by the trace flags. The returned function flushes and stops the exporter.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+		err      error
+	)
+	switch traceExporter {
+	case traceNone:
+		return func(context.Context) error { return nil }, nil
+	case traceStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case traceFile:
+		var f *os.File
+		f, err = os.Create(traceFilePath)
+		if err != nil {
+			return nil, err
+		}
+		closer = f
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	case traceOTLP:
+		exporter, err = otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("invalid trace exporter %q, must be one of %s", traceExporter, strings.Join(traceExporters, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", "raftd")))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, traceShutdownTimeout)
+		defer cancel()
+
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
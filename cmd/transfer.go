@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	"github.com/amjadjibon/raftd/client"
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+const (
+	// transferJSONL writes a JSON object per line, with the value base64
+	// encoded.
+	transferJSONL = "jsonl"
+	// transferCSV writes a key,value header and a record per pair, with
+	// the value as text.
+	transferCSV = "csv"
+	// transferProto writes size-delimited raftd.v1.KeyValue messages.
+	transferProto = "proto"
+)
+
+var transferFormats = []string{transferJSONL, transferCSV, transferProto}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the pairs of the namespace to a file",
+	Long: `Write the pairs of the namespace, or those whose key starts with --prefix,
+to a file or stdout. The pairs are read at a single revision, which is
+reported once the export is done.
+
+The export is not bound by --timeout.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		file, _ := cmd.Flags().GetString("file")
+		prefix, _ := cmd.Flags().GetString("prefix")
+
+		opts, err := readOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = cmd.OutOrStdout()
+		if file != "" {
+			f, err := os.Create(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		w := bufio.NewWriter(out)
+
+		write, err := pairWriter(w, format)
+		if err != nil {
+			return err
+		}
+
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		var res transferResult
+		res.Revision, err = c.Export(cmd.Context(), prefix, func(kvs []client.KeyValue) error {
+			for _, kv := range kvs {
+				if err := write(kv); err != nil {
+					return err
+				}
+				res.Keys++
+				res.Bytes += int64(len(kv.Key) + len(kv.Value))
+			}
+			return nil
+		}, opts...)
+		if err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		// The pairs are the output when they go to stdout.
+		if file == "" {
+			return nil
+		}
+		return printResult(cmd, res)
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Set the pairs read from a file",
+	Long: `Set the pairs read from a file or stdin, in any of the formats of export.
+
+The leader writes the pairs in batches of about a megabyte, each of them
+atomically: a batch going over the quota of the namespace is rejected as a
+whole, but the batches before it stay written. With --dry-run, the pairs are
+only validated. The progress is reported to stderr.
+
+The import is not bound by --timeout.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		file, _ := cmd.Flags().GetString("file")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		var in io.Reader = cmd.InOrStdin()
+		if file != "" {
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+
+		next, err := pairReader(bufio.NewReader(in), format)
+		if err != nil {
+			return err
+		}
+
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		stderr := cmd.ErrOrStderr()
+		progress := func(keys uint64, bytes int64) {
+			_, _ = fmt.Fprintf(stderr, "\rsent %d keys, %d bytes", keys, bytes)
+		}
+		result, err := c.Import(cmd.Context(), next, dryRun, progress)
+		_, _ = fmt.Fprintln(stderr)
+		if err != nil {
+			return err
+		}
+
+		return printResult(cmd, transferResult{
+			Keys:     result.Keys,
+			Bytes:    result.Bytes,
+			Revision: result.Revision,
+			DryRun:   dryRun,
+		})
+	},
+}
+
+// pairRecord is a pair of the jsonl format.
+type pairRecord struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// pairWriter returns a function writing pairs to w in format.
+func pairWriter(w io.Writer, format string) (func(client.KeyValue) error, error) {
+	switch format {
+	case transferJSONL:
+		enc := json.NewEncoder(w)
+		return func(kv client.KeyValue) error {
+			return enc.Encode(pairRecord{Key: kv.Key, Value: kv.Value})
+		}, nil
+	case transferCSV:
+		cw := csv.NewWriter(w)
+		header := true
+		return func(kv client.KeyValue) error {
+			if header {
+				if err := cw.Write([]string{"key", "value"}); err != nil {
+					return err
+				}
+				header = false
+			}
+			if err := cw.Write([]string{kv.Key, string(kv.Value)}); err != nil {
+				return err
+			}
+			cw.Flush()
+			return cw.Error()
+		}, nil
+	case transferProto:
+		return func(kv client.KeyValue) error {
+			_, err := protodelim.MarshalTo(w, &raftdv1.KeyValue{Key: kv.Key, Value: kv.Value})
+			return err
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid format %q, want one of %s", format, strings.Join(transferFormats, ", "))
+	}
+}
+
+// pairReader returns a function reading the next pair from r in format, or
+// io.EOF after the last one.
+func pairReader(r *bufio.Reader, format string) (func() (client.KeyValue, error), error) {
+	switch format {
+	case transferJSONL:
+		dec := json.NewDecoder(r)
+		line := 0
+		return func() (client.KeyValue, error) {
+			var record pairRecord
+			line++
+			if err := dec.Decode(&record); err != nil {
+				if errors.Is(err, io.EOF) {
+					return client.KeyValue{}, io.EOF
+				}
+				return client.KeyValue{}, fmt.Errorf("invalid pair %d: %w", line, err)
+			}
+			return client.KeyValue{Key: record.Key, Value: record.Value}, nil
+		}, nil
+	case transferCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = 2
+		header := true
+		return func() (client.KeyValue, error) {
+			record, err := cr.Read()
+			if header && err == nil && record[0] == "key" && record[1] == "value" {
+				record, err = cr.Read()
+			}
+			header = false
+			if errors.Is(err, io.EOF) {
+				return client.KeyValue{}, io.EOF
+			}
+			if err != nil {
+				return client.KeyValue{}, err
+			}
+			return client.KeyValue{Key: record[0], Value: []byte(record[1])}, nil
+		}, nil
+	case transferProto:
+		line := 0
+		return func() (client.KeyValue, error) {
+			var kv raftdv1.KeyValue
+			line++
+			if err := protodelim.UnmarshalFrom(r, &kv); err != nil {
+				if errors.Is(err, io.EOF) {
+					return client.KeyValue{}, io.EOF
+				}
+				return client.KeyValue{}, fmt.Errorf("invalid pair %d: %w", line, err)
+			}
+			return client.KeyValue{Key: kv.Key, Value: kv.Value}, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid format %q, want one of %s", format, strings.Join(transferFormats, ", "))
+	}
+}
+
+type transferResult struct {
+	Keys     uint64 `json:"keys"`
+	Bytes    int64  `json:"bytes"`
+	Revision uint64 `json:"revision,omitempty"`
+	DryRun   bool   `json:"dry_run,omitempty"`
+}
+
+func (r transferResult) Table(w io.Writer) error {
+	var err error
+	if r.DryRun {
+		_, err = fmt.Fprintf(w, "Dry run: %d keys, %d bytes would be imported\n", r.Keys, r.Bytes)
+	} else {
+		_, err = fmt.Fprintf(w, "%d keys, %d bytes at revision %d\n", r.Keys, r.Bytes, r.Revision)
+	}
+	return err
+}
+
+func (r transferResult) Raw(w io.Writer) error {
+	_, err := fmt.Fprintln(w, r.Keys)
+	return err
+}
+
+func init() {
+	exportCmd.Flags().String("prefix", "", "Export only the keys starting with this prefix")
+	exportCmd.Flags().String("format", transferJSONL, "Format of the file, one of "+strings.Join(transferFormats, ", "))
+	exportCmd.Flags().String("file", "", "File to write instead of stdout")
+	exportCmd.Flags().Duration("max-staleness", 0, "Fail unless the node heard from the leader within this duration")
+	exportCmd.Flags().Bool("linearizable", false, "Read through the leader, reflecting every acknowledged write")
+	exportCmd.Flags().Uint64("revision", 0, "Export the pairs as they were at this revision")
+	exportCmd.MarkFlagsMutuallyExclusive("max-staleness", "linearizable")
+	_ = exportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(transferFormats, cobra.ShellCompDirectiveNoFileComp))
+
+	importCmd.Flags().String("format", transferJSONL, "Format of the file, one of "+strings.Join(transferFormats, ", "))
+	importCmd.Flags().String("file", "", "File to read instead of stdin")
+	importCmd.Flags().Bool("dry-run", false, "Validate the pairs without writing them")
+	_ = importCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(transferFormats, cobra.ShellCompDirectiveNoFileComp))
+}
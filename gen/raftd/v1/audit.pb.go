@@ -0,0 +1,184 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: raftd/v1/audit.proto
+
+package raftdv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TailAuditRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Skips the records up to this index.
+	AfterIndex uint64 `protobuf:"varint,1,opt,name=after_index,json=afterIndex,proto3" json:"after_index,omitempty"`
+	// Starts from the last records kept instead of the first ones. Zero
+	// sends them all.
+	Last   int64 `protobuf:"varint,2,opt,name=last,proto3" json:"last,omitempty"`
+	Follow bool  `protobuf:"varint,3,opt,name=follow,proto3" json:"follow,omitempty"`
+}
+
+func (x *TailAuditRequest) Reset() {
+	*x = TailAuditRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_raftd_v1_audit_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TailAuditRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TailAuditRequest) ProtoMessage() {}
+
+func (x *TailAuditRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_raftd_v1_audit_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TailAuditRequest.ProtoReflect.Descriptor instead.
+func (*TailAuditRequest) Descriptor() ([]byte, []int) {
+	return file_raftd_v1_audit_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TailAuditRequest) GetAfterIndex() uint64 {
+	if x != nil {
+		return x.AfterIndex
+	}
+	return 0
+}
+
+func (x *TailAuditRequest) GetLast() int64 {
+	if x != nil {
+		return x.Last
+	}
+	return 0
+}
+
+func (x *TailAuditRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+var File_raftd_v1_audit_proto protoreflect.FileDescriptor
+
+var file_raftd_v1_audit_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x72, 0x61, 0x66, 0x74, 0x64, 0x2f, 0x76, 0x31, 0x2f, 0x61, 0x75, 0x64, 0x69, 0x74,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x72, 0x61, 0x66, 0x74, 0x64, 0x2e, 0x76, 0x31,
+	0x1a, 0x13, 0x72, 0x61, 0x66, 0x74, 0x64, 0x2f, 0x76, 0x31, 0x2f, 0x72, 0x61, 0x66, 0x74, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x5f, 0x0a, 0x10, 0x54, 0x61, 0x69, 0x6c, 0x41, 0x75, 0x64,
+	0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x66, 0x74,
+	0x65, 0x72, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a,
+	0x61, 0x66, 0x74, 0x65, 0x72, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x61,
+	0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6c, 0x61, 0x73, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06,
+	0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x32, 0x52, 0x0a, 0x0c, 0x41, 0x75, 0x64, 0x69, 0x74, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x54, 0x61, 0x69, 0x6c, 0x41, 0x75,
+	0x64, 0x69, 0x74, 0x12, 0x1a, 0x2e, 0x72, 0x61, 0x66, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x54,
+	0x61, 0x69, 0x6c, 0x41, 0x75, 0x64, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x15, 0x2e, 0x72, 0x61, 0x66, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x75, 0x64, 0x69, 0x74,
+	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22, 0x00, 0x30, 0x01, 0x42, 0x8d, 0x01, 0x0a, 0x0c, 0x63,
+	0x6f, 0x6d, 0x2e, 0x72, 0x61, 0x66, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x42, 0x0a, 0x41, 0x75, 0x64,
+	0x69, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x30, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x6d, 0x6a, 0x61, 0x64, 0x6a, 0x69, 0x62, 0x6f, 0x6e,
+	0x2f, 0x72, 0x61, 0x66, 0x74, 0x64, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x72, 0x61, 0x66, 0x74, 0x64,
+	0x2f, 0x76, 0x31, 0x3b, 0x72, 0x61, 0x66, 0x74, 0x64, 0x76, 0x31, 0xa2, 0x02, 0x03, 0x52, 0x58,
+	0x58, 0xaa, 0x02, 0x08, 0x52, 0x61, 0x66, 0x74, 0x64, 0x2e, 0x56, 0x31, 0xca, 0x02, 0x08, 0x52,
+	0x61, 0x66, 0x74, 0x64, 0x5c, 0x56, 0x31, 0xe2, 0x02, 0x14, 0x52, 0x61, 0x66, 0x74, 0x64, 0x5c,
+	0x56, 0x31, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02,
+	0x09, 0x52, 0x61, 0x66, 0x74, 0x64, 0x3a, 0x3a, 0x56, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_raftd_v1_audit_proto_rawDescOnce sync.Once
+	file_raftd_v1_audit_proto_rawDescData = file_raftd_v1_audit_proto_rawDesc
+)
+
+func file_raftd_v1_audit_proto_rawDescGZIP() []byte {
+	file_raftd_v1_audit_proto_rawDescOnce.Do(func() {
+		file_raftd_v1_audit_proto_rawDescData = protoimpl.X.CompressGZIP(file_raftd_v1_audit_proto_rawDescData)
+	})
+	return file_raftd_v1_audit_proto_rawDescData
+}
+
+var file_raftd_v1_audit_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_raftd_v1_audit_proto_goTypes = []any{
+	(*TailAuditRequest)(nil), // 0: raftd.v1.TailAuditRequest
+	(*AuditRecord)(nil),      // 1: raftd.v1.AuditRecord
+}
+var file_raftd_v1_audit_proto_depIdxs = []int32{
+	0, // 0: raftd.v1.AuditService.TailAudit:input_type -> raftd.v1.TailAuditRequest
+	1, // 1: raftd.v1.AuditService.TailAudit:output_type -> raftd.v1.AuditRecord
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_raftd_v1_audit_proto_init() }
+func file_raftd_v1_audit_proto_init() {
+	if File_raftd_v1_audit_proto != nil {
+		return
+	}
+	file_raftd_v1_raft_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_raftd_v1_audit_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*TailAuditRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_raftd_v1_audit_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_raftd_v1_audit_proto_goTypes,
+		DependencyIndexes: file_raftd_v1_audit_proto_depIdxs,
+		MessageInfos:      file_raftd_v1_audit_proto_msgTypes,
+	}.Build()
+	File_raftd_v1_audit_proto = out.File
+	file_raftd_v1_audit_proto_rawDesc = nil
+	file_raftd_v1_audit_proto_goTypes = nil
+	file_raftd_v1_audit_proto_depIdxs = nil
+}
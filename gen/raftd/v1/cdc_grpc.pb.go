@@ -0,0 +1,245 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: raftd/v1/cdc.proto
+
+package raftdv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ChangeService_SubscribeChanges_FullMethodName     = "/raftd.v1.ChangeService/SubscribeChanges"
+	ChangeService_AckChanges_FullMethodName           = "/raftd.v1.ChangeService/AckChanges"
+	ChangeService_RemoveChangeConsumer_FullMethodName = "/raftd.v1.ChangeService/RemoveChangeConsumer"
+)
+
+// ChangeServiceClient is the client API for ChangeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ChangeService streams the changes of every key, in revision order, to
+// consumers that acknowledge what they processed. The acknowledged revision
+// of every consumer is kept in raft, so a consumer resumes after it from
+// any node and after any leader change: changes are delivered at least
+// once.
+//
+// Changes are read from the history of the keys, so they are lost to
+// compaction. The history is not compacted past the checkpoint of any sink
+// of the leader, or of any consumer that acknowledged changes within the
+// retention of the server, which keeps it, and its size under the storage
+// quota, until every one of them acknowledged it. A consumer that
+// subscribes after the compaction point must ack a later revision to
+// resume.
+type ChangeServiceClient interface {
+	// SubscribeChanges streams the changes after the checkpoint of the consumer,
+	// and then every later change, until the call ends. It reads the
+	// local history, so followers serve it as well. A checkpoint before
+	// the compaction point fails with OUT_OF_RANGE.
+	SubscribeChanges(ctx context.Context, in *SubscribeChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeChangesResponse], error)
+	// AckChanges moves the checkpoint of the consumer forward to a
+	// revision.
+	AckChanges(ctx context.Context, in *AckChangesRequest, opts ...grpc.CallOption) (*AckChangesResponse, error)
+	// RemoveChangeConsumer drops the checkpoint of a consumer that will not
+	// come back, so that it no longer holds back compaction. It fails with
+	// NOT_FOUND for a consumer without a checkpoint.
+	RemoveChangeConsumer(ctx context.Context, in *RemoveChangeConsumerRequest, opts ...grpc.CallOption) (*RemoveChangeConsumerResponse, error)
+}
+
+type changeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChangeServiceClient(cc grpc.ClientConnInterface) ChangeServiceClient {
+	return &changeServiceClient{cc}
+}
+
+func (c *changeServiceClient) SubscribeChanges(ctx context.Context, in *SubscribeChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeChangesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ChangeService_ServiceDesc.Streams[0], ChangeService_SubscribeChanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeChangesRequest, SubscribeChangesResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChangeService_SubscribeChangesClient = grpc.ServerStreamingClient[SubscribeChangesResponse]
+
+func (c *changeServiceClient) AckChanges(ctx context.Context, in *AckChangesRequest, opts ...grpc.CallOption) (*AckChangesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AckChangesResponse)
+	err := c.cc.Invoke(ctx, ChangeService_AckChanges_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *changeServiceClient) RemoveChangeConsumer(ctx context.Context, in *RemoveChangeConsumerRequest, opts ...grpc.CallOption) (*RemoveChangeConsumerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveChangeConsumerResponse)
+	err := c.cc.Invoke(ctx, ChangeService_RemoveChangeConsumer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChangeServiceServer is the server API for ChangeService service.
+// All implementations should embed UnimplementedChangeServiceServer
+// for forward compatibility.
+//
+// ChangeService streams the changes of every key, in revision order, to
+// consumers that acknowledge what they processed. The acknowledged revision
+// of every consumer is kept in raft, so a consumer resumes after it from
+// any node and after any leader change: changes are delivered at least
+// once.
+//
+// Changes are read from the history of the keys, so they are lost to
+// compaction. The history is not compacted past the checkpoint of any sink
+// of the leader, or of any consumer that acknowledged changes within the
+// retention of the server, which keeps it, and its size under the storage
+// quota, until every one of them acknowledged it. A consumer that
+// subscribes after the compaction point must ack a later revision to
+// resume.
+type ChangeServiceServer interface {
+	// SubscribeChanges streams the changes after the checkpoint of the consumer,
+	// and then every later change, until the call ends. It reads the
+	// local history, so followers serve it as well. A checkpoint before
+	// the compaction point fails with OUT_OF_RANGE.
+	SubscribeChanges(*SubscribeChangesRequest, grpc.ServerStreamingServer[SubscribeChangesResponse]) error
+	// AckChanges moves the checkpoint of the consumer forward to a
+	// revision.
+	AckChanges(context.Context, *AckChangesRequest) (*AckChangesResponse, error)
+	// RemoveChangeConsumer drops the checkpoint of a consumer that will not
+	// come back, so that it no longer holds back compaction. It fails with
+	// NOT_FOUND for a consumer without a checkpoint.
+	RemoveChangeConsumer(context.Context, *RemoveChangeConsumerRequest) (*RemoveChangeConsumerResponse, error)
+}
+
+// UnimplementedChangeServiceServer should be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedChangeServiceServer struct{}
+
+func (UnimplementedChangeServiceServer) SubscribeChanges(*SubscribeChangesRequest, grpc.ServerStreamingServer[SubscribeChangesResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeChanges not implemented")
+}
+func (UnimplementedChangeServiceServer) AckChanges(context.Context, *AckChangesRequest) (*AckChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AckChanges not implemented")
+}
+func (UnimplementedChangeServiceServer) RemoveChangeConsumer(context.Context, *RemoveChangeConsumerRequest) (*RemoveChangeConsumerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveChangeConsumer not implemented")
+}
+func (UnimplementedChangeServiceServer) testEmbeddedByValue() {}
+
+// UnsafeChangeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChangeServiceServer will
+// result in compilation errors.
+type UnsafeChangeServiceServer interface {
+	mustEmbedUnimplementedChangeServiceServer()
+}
+
+func RegisterChangeServiceServer(s grpc.ServiceRegistrar, srv ChangeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedChangeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ChangeService_ServiceDesc, srv)
+}
+
+func _ChangeService_SubscribeChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChangeServiceServer).SubscribeChanges(m, &grpc.GenericServerStream[SubscribeChangesRequest, SubscribeChangesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChangeService_SubscribeChangesServer = grpc.ServerStreamingServer[SubscribeChangesResponse]
+
+func _ChangeService_AckChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AckChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChangeServiceServer).AckChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChangeService_AckChanges_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChangeServiceServer).AckChanges(ctx, req.(*AckChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChangeService_RemoveChangeConsumer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveChangeConsumerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChangeServiceServer).RemoveChangeConsumer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChangeService_RemoveChangeConsumer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChangeServiceServer).RemoveChangeConsumer(ctx, req.(*RemoveChangeConsumerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ChangeService_ServiceDesc is the grpc.ServiceDesc for ChangeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ChangeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "raftd.v1.ChangeService",
+	HandlerType: (*ChangeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AckChanges",
+			Handler:    _ChangeService_AckChanges_Handler,
+		},
+		{
+			MethodName: "RemoveChangeConsumer",
+			Handler:    _ChangeService_RemoveChangeConsumer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeChanges",
+			Handler:       _ChangeService_SubscribeChanges_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "raftd/v1/cdc.proto",
+}
@@ -19,9 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	RaftService_Join_FullMethodName   = "/raftd.v1.RaftService/Join"
-	RaftService_Leave_FullMethodName  = "/raftd.v1.RaftService/Leave"
-	RaftService_Status_FullMethodName = "/raftd.v1.RaftService/Status"
+	RaftService_Join_FullMethodName    = "/raftd.v1.RaftService/Join"
+	RaftService_Leave_FullMethodName   = "/raftd.v1.RaftService/Leave"
+	RaftService_Status_FullMethodName  = "/raftd.v1.RaftService/Status"
+	RaftService_Restore_FullMethodName = "/raftd.v1.RaftService/Restore"
+	RaftService_Backup_FullMethodName  = "/raftd.v1.RaftService/Backup"
 )
 
 // RaftServiceClient is the client API for RaftService service.
@@ -31,6 +33,8 @@ type RaftServiceClient interface {
 	Join(ctx context.Context, in *JoinRequest, opts ...grpc.CallOption) (*JoinResponse, error)
 	Leave(ctx context.Context, in *LeaveRequest, opts ...grpc.CallOption) (*LeaveResponse, error)
 	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	Restore(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreRequest, RestoreResponse], error)
+	Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupResponse], error)
 }
 
 type raftServiceClient struct {
@@ -71,6 +75,38 @@ func (c *raftServiceClient) Status(ctx context.Context, in *StatusRequest, opts
 	return out, nil
 }
 
+func (c *raftServiceClient) Restore(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreRequest, RestoreResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RaftService_ServiceDesc.Streams[0], RaftService_Restore_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RestoreRequest, RestoreResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RaftService_RestoreClient = grpc.ClientStreamingClient[RestoreRequest, RestoreResponse]
+
+func (c *raftServiceClient) Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RaftService_ServiceDesc.Streams[1], RaftService_Backup_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BackupRequest, BackupResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RaftService_BackupClient = grpc.ServerStreamingClient[BackupResponse]
+
 // RaftServiceServer is the server API for RaftService service.
 // All implementations should embed UnimplementedRaftServiceServer
 // for forward compatibility.
@@ -78,6 +114,8 @@ type RaftServiceServer interface {
 	Join(context.Context, *JoinRequest) (*JoinResponse, error)
 	Leave(context.Context, *LeaveRequest) (*LeaveResponse, error)
 	Status(context.Context, *StatusRequest) (*StatusResponse, error)
+	Restore(grpc.ClientStreamingServer[RestoreRequest, RestoreResponse]) error
+	Backup(*BackupRequest, grpc.ServerStreamingServer[BackupResponse]) error
 }
 
 // UnimplementedRaftServiceServer should be embedded to have
@@ -96,6 +134,12 @@ func (UnimplementedRaftServiceServer) Leave(context.Context, *LeaveRequest) (*Le
 func (UnimplementedRaftServiceServer) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
 }
+func (UnimplementedRaftServiceServer) Restore(grpc.ClientStreamingServer[RestoreRequest, RestoreResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedRaftServiceServer) Backup(*BackupRequest, grpc.ServerStreamingServer[BackupResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Backup not implemented")
+}
 func (UnimplementedRaftServiceServer) testEmbeddedByValue() {}
 
 // UnsafeRaftServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -170,6 +214,24 @@ func _RaftService_Status_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RaftService_Restore_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RaftServiceServer).Restore(&grpc.GenericServerStream[RestoreRequest, RestoreResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RaftService_RestoreServer = grpc.ClientStreamingServer[RestoreRequest, RestoreResponse]
+
+func _RaftService_Backup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RaftServiceServer).Backup(m, &grpc.GenericServerStream[BackupRequest, BackupResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RaftService_BackupServer = grpc.ServerStreamingServer[BackupResponse]
+
 // RaftService_ServiceDesc is the grpc.ServiceDesc for RaftService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -190,6 +252,17 @@ var RaftService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _RaftService_Status_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Restore",
+			Handler:       _RaftService_Restore_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Backup",
+			Handler:       _RaftService_Backup_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "raftd/v1/raft.proto",
 }
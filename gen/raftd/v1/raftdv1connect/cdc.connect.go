@@ -0,0 +1,191 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: raftd/v1/cdc.proto
+
+package raftdv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// ChangeServiceName is the fully-qualified name of the ChangeService service.
+	ChangeServiceName = "raftd.v1.ChangeService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// ChangeServiceSubscribeChangesProcedure is the fully-qualified name of the ChangeService's
+	// SubscribeChanges RPC.
+	ChangeServiceSubscribeChangesProcedure = "/raftd.v1.ChangeService/SubscribeChanges"
+	// ChangeServiceAckChangesProcedure is the fully-qualified name of the ChangeService's AckChanges
+	// RPC.
+	ChangeServiceAckChangesProcedure = "/raftd.v1.ChangeService/AckChanges"
+	// ChangeServiceRemoveChangeConsumerProcedure is the fully-qualified name of the ChangeService's
+	// RemoveChangeConsumer RPC.
+	ChangeServiceRemoveChangeConsumerProcedure = "/raftd.v1.ChangeService/RemoveChangeConsumer"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	changeServiceServiceDescriptor                    = v1.File_raftd_v1_cdc_proto.Services().ByName("ChangeService")
+	changeServiceSubscribeChangesMethodDescriptor     = changeServiceServiceDescriptor.Methods().ByName("SubscribeChanges")
+	changeServiceAckChangesMethodDescriptor           = changeServiceServiceDescriptor.Methods().ByName("AckChanges")
+	changeServiceRemoveChangeConsumerMethodDescriptor = changeServiceServiceDescriptor.Methods().ByName("RemoveChangeConsumer")
+)
+
+// ChangeServiceClient is a client for the raftd.v1.ChangeService service.
+type ChangeServiceClient interface {
+	// SubscribeChanges streams the changes after the checkpoint of the consumer,
+	// and then every later change, until the call ends. It reads the
+	// local history, so followers serve it as well. A checkpoint before
+	// the compaction point fails with OUT_OF_RANGE.
+	SubscribeChanges(context.Context, *connect.Request[v1.SubscribeChangesRequest]) (*connect.ServerStreamForClient[v1.SubscribeChangesResponse], error)
+	// AckChanges moves the checkpoint of the consumer forward to a
+	// revision.
+	AckChanges(context.Context, *connect.Request[v1.AckChangesRequest]) (*connect.Response[v1.AckChangesResponse], error)
+	// RemoveChangeConsumer drops the checkpoint of a consumer that will not
+	// come back, so that it no longer holds back compaction. It fails with
+	// NOT_FOUND for a consumer without a checkpoint.
+	RemoveChangeConsumer(context.Context, *connect.Request[v1.RemoveChangeConsumerRequest]) (*connect.Response[v1.RemoveChangeConsumerResponse], error)
+}
+
+// NewChangeServiceClient constructs a client for the raftd.v1.ChangeService service. By default, it
+// uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewChangeServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) ChangeServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &changeServiceClient{
+		subscribeChanges: connect.NewClient[v1.SubscribeChangesRequest, v1.SubscribeChangesResponse](
+			httpClient,
+			baseURL+ChangeServiceSubscribeChangesProcedure,
+			connect.WithSchema(changeServiceSubscribeChangesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		ackChanges: connect.NewClient[v1.AckChangesRequest, v1.AckChangesResponse](
+			httpClient,
+			baseURL+ChangeServiceAckChangesProcedure,
+			connect.WithSchema(changeServiceAckChangesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		removeChangeConsumer: connect.NewClient[v1.RemoveChangeConsumerRequest, v1.RemoveChangeConsumerResponse](
+			httpClient,
+			baseURL+ChangeServiceRemoveChangeConsumerProcedure,
+			connect.WithSchema(changeServiceRemoveChangeConsumerMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// changeServiceClient implements ChangeServiceClient.
+type changeServiceClient struct {
+	subscribeChanges     *connect.Client[v1.SubscribeChangesRequest, v1.SubscribeChangesResponse]
+	ackChanges           *connect.Client[v1.AckChangesRequest, v1.AckChangesResponse]
+	removeChangeConsumer *connect.Client[v1.RemoveChangeConsumerRequest, v1.RemoveChangeConsumerResponse]
+}
+
+// SubscribeChanges calls raftd.v1.ChangeService.SubscribeChanges.
+func (c *changeServiceClient) SubscribeChanges(ctx context.Context, req *connect.Request[v1.SubscribeChangesRequest]) (*connect.ServerStreamForClient[v1.SubscribeChangesResponse], error) {
+	return c.subscribeChanges.CallServerStream(ctx, req)
+}
+
+// AckChanges calls raftd.v1.ChangeService.AckChanges.
+func (c *changeServiceClient) AckChanges(ctx context.Context, req *connect.Request[v1.AckChangesRequest]) (*connect.Response[v1.AckChangesResponse], error) {
+	return c.ackChanges.CallUnary(ctx, req)
+}
+
+// RemoveChangeConsumer calls raftd.v1.ChangeService.RemoveChangeConsumer.
+func (c *changeServiceClient) RemoveChangeConsumer(ctx context.Context, req *connect.Request[v1.RemoveChangeConsumerRequest]) (*connect.Response[v1.RemoveChangeConsumerResponse], error) {
+	return c.removeChangeConsumer.CallUnary(ctx, req)
+}
+
+// ChangeServiceHandler is an implementation of the raftd.v1.ChangeService service.
+type ChangeServiceHandler interface {
+	// SubscribeChanges streams the changes after the checkpoint of the consumer,
+	// and then every later change, until the call ends. It reads the
+	// local history, so followers serve it as well. A checkpoint before
+	// the compaction point fails with OUT_OF_RANGE.
+	SubscribeChanges(context.Context, *connect.Request[v1.SubscribeChangesRequest], *connect.ServerStream[v1.SubscribeChangesResponse]) error
+	// AckChanges moves the checkpoint of the consumer forward to a
+	// revision.
+	AckChanges(context.Context, *connect.Request[v1.AckChangesRequest]) (*connect.Response[v1.AckChangesResponse], error)
+	// RemoveChangeConsumer drops the checkpoint of a consumer that will not
+	// come back, so that it no longer holds back compaction. It fails with
+	// NOT_FOUND for a consumer without a checkpoint.
+	RemoveChangeConsumer(context.Context, *connect.Request[v1.RemoveChangeConsumerRequest]) (*connect.Response[v1.RemoveChangeConsumerResponse], error)
+}
+
+// NewChangeServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewChangeServiceHandler(svc ChangeServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	changeServiceSubscribeChangesHandler := connect.NewServerStreamHandler(
+		ChangeServiceSubscribeChangesProcedure,
+		svc.SubscribeChanges,
+		connect.WithSchema(changeServiceSubscribeChangesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	changeServiceAckChangesHandler := connect.NewUnaryHandler(
+		ChangeServiceAckChangesProcedure,
+		svc.AckChanges,
+		connect.WithSchema(changeServiceAckChangesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	changeServiceRemoveChangeConsumerHandler := connect.NewUnaryHandler(
+		ChangeServiceRemoveChangeConsumerProcedure,
+		svc.RemoveChangeConsumer,
+		connect.WithSchema(changeServiceRemoveChangeConsumerMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/raftd.v1.ChangeService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case ChangeServiceSubscribeChangesProcedure:
+			changeServiceSubscribeChangesHandler.ServeHTTP(w, r)
+		case ChangeServiceAckChangesProcedure:
+			changeServiceAckChangesHandler.ServeHTTP(w, r)
+		case ChangeServiceRemoveChangeConsumerProcedure:
+			changeServiceRemoveChangeConsumerHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedChangeServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedChangeServiceHandler struct{}
+
+func (UnimplementedChangeServiceHandler) SubscribeChanges(context.Context, *connect.Request[v1.SubscribeChangesRequest], *connect.ServerStream[v1.SubscribeChangesResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.ChangeService.SubscribeChanges is not implemented"))
+}
+
+func (UnimplementedChangeServiceHandler) AckChanges(context.Context, *connect.Request[v1.AckChangesRequest]) (*connect.Response[v1.AckChangesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.ChangeService.AckChanges is not implemented"))
+}
+
+func (UnimplementedChangeServiceHandler) RemoveChangeConsumer(context.Context, *connect.Request[v1.RemoveChangeConsumerRequest]) (*connect.Response[v1.RemoveChangeConsumerResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.ChangeService.RemoveChangeConsumer is not implemented"))
+}
@@ -0,0 +1,354 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: raftd/v1/lock.proto
+
+package raftdv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// LockServiceName is the fully-qualified name of the LockService service.
+	LockServiceName = "raftd.v1.LockService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// LockServiceGrantProcedure is the fully-qualified name of the LockService's Grant RPC.
+	LockServiceGrantProcedure = "/raftd.v1.LockService/Grant"
+	// LockServiceKeepAliveProcedure is the fully-qualified name of the LockService's KeepAlive RPC.
+	LockServiceKeepAliveProcedure = "/raftd.v1.LockService/KeepAlive"
+	// LockServiceRevokeProcedure is the fully-qualified name of the LockService's Revoke RPC.
+	LockServiceRevokeProcedure = "/raftd.v1.LockService/Revoke"
+	// LockServiceLockProcedure is the fully-qualified name of the LockService's Lock RPC.
+	LockServiceLockProcedure = "/raftd.v1.LockService/Lock"
+	// LockServiceTryLockProcedure is the fully-qualified name of the LockService's TryLock RPC.
+	LockServiceTryLockProcedure = "/raftd.v1.LockService/TryLock"
+	// LockServiceUnlockProcedure is the fully-qualified name of the LockService's Unlock RPC.
+	LockServiceUnlockProcedure = "/raftd.v1.LockService/Unlock"
+	// LockServiceCampaignProcedure is the fully-qualified name of the LockService's Campaign RPC.
+	LockServiceCampaignProcedure = "/raftd.v1.LockService/Campaign"
+	// LockServiceObserveProcedure is the fully-qualified name of the LockService's Observe RPC.
+	LockServiceObserveProcedure = "/raftd.v1.LockService/Observe"
+	// LockServiceResignProcedure is the fully-qualified name of the LockService's Resign RPC.
+	LockServiceResignProcedure = "/raftd.v1.LockService/Resign"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	lockServiceServiceDescriptor         = v1.File_raftd_v1_lock_proto.Services().ByName("LockService")
+	lockServiceGrantMethodDescriptor     = lockServiceServiceDescriptor.Methods().ByName("Grant")
+	lockServiceKeepAliveMethodDescriptor = lockServiceServiceDescriptor.Methods().ByName("KeepAlive")
+	lockServiceRevokeMethodDescriptor    = lockServiceServiceDescriptor.Methods().ByName("Revoke")
+	lockServiceLockMethodDescriptor      = lockServiceServiceDescriptor.Methods().ByName("Lock")
+	lockServiceTryLockMethodDescriptor   = lockServiceServiceDescriptor.Methods().ByName("TryLock")
+	lockServiceUnlockMethodDescriptor    = lockServiceServiceDescriptor.Methods().ByName("Unlock")
+	lockServiceCampaignMethodDescriptor  = lockServiceServiceDescriptor.Methods().ByName("Campaign")
+	lockServiceObserveMethodDescriptor   = lockServiceServiceDescriptor.Methods().ByName("Observe")
+	lockServiceResignMethodDescriptor    = lockServiceServiceDescriptor.Methods().ByName("Resign")
+)
+
+// LockServiceClient is a client for the raftd.v1.LockService service.
+type LockServiceClient interface {
+	Grant(context.Context, *connect.Request[v1.GrantRequest]) (*connect.Response[v1.GrantResponse], error)
+	KeepAlive(context.Context, *connect.Request[v1.KeepAliveRequest]) (*connect.Response[v1.KeepAliveResponse], error)
+	Revoke(context.Context, *connect.Request[v1.RevokeRequest]) (*connect.Response[v1.RevokeResponse], error)
+	// Lock waits until the lock is acquired or the call is cancelled.
+	Lock(context.Context, *connect.Request[v1.LockRequest]) (*connect.Response[v1.LockResponse], error)
+	// TryLock acquires the lock if it is free and returns immediately.
+	TryLock(context.Context, *connect.Request[v1.TryLockRequest]) (*connect.Response[v1.TryLockResponse], error)
+	Unlock(context.Context, *connect.Request[v1.UnlockRequest]) (*connect.Response[v1.UnlockResponse], error)
+	// Campaign waits until the caller is elected or the call is cancelled.
+	Campaign(context.Context, *connect.Request[v1.CampaignRequest]) (*connect.Response[v1.CampaignResponse], error)
+	// Observe streams the current leader of an election and every change
+	// of leader. It can be served by any node.
+	Observe(context.Context, *connect.Request[v1.ObserveRequest]) (*connect.ServerStreamForClient[v1.ObserveResponse], error)
+	Resign(context.Context, *connect.Request[v1.ResignRequest]) (*connect.Response[v1.ResignResponse], error)
+}
+
+// NewLockServiceClient constructs a client for the raftd.v1.LockService service. By default, it
+// uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewLockServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) LockServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &lockServiceClient{
+		grant: connect.NewClient[v1.GrantRequest, v1.GrantResponse](
+			httpClient,
+			baseURL+LockServiceGrantProcedure,
+			connect.WithSchema(lockServiceGrantMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		keepAlive: connect.NewClient[v1.KeepAliveRequest, v1.KeepAliveResponse](
+			httpClient,
+			baseURL+LockServiceKeepAliveProcedure,
+			connect.WithSchema(lockServiceKeepAliveMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		revoke: connect.NewClient[v1.RevokeRequest, v1.RevokeResponse](
+			httpClient,
+			baseURL+LockServiceRevokeProcedure,
+			connect.WithSchema(lockServiceRevokeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		lock: connect.NewClient[v1.LockRequest, v1.LockResponse](
+			httpClient,
+			baseURL+LockServiceLockProcedure,
+			connect.WithSchema(lockServiceLockMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		tryLock: connect.NewClient[v1.TryLockRequest, v1.TryLockResponse](
+			httpClient,
+			baseURL+LockServiceTryLockProcedure,
+			connect.WithSchema(lockServiceTryLockMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		unlock: connect.NewClient[v1.UnlockRequest, v1.UnlockResponse](
+			httpClient,
+			baseURL+LockServiceUnlockProcedure,
+			connect.WithSchema(lockServiceUnlockMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		campaign: connect.NewClient[v1.CampaignRequest, v1.CampaignResponse](
+			httpClient,
+			baseURL+LockServiceCampaignProcedure,
+			connect.WithSchema(lockServiceCampaignMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		observe: connect.NewClient[v1.ObserveRequest, v1.ObserveResponse](
+			httpClient,
+			baseURL+LockServiceObserveProcedure,
+			connect.WithSchema(lockServiceObserveMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		resign: connect.NewClient[v1.ResignRequest, v1.ResignResponse](
+			httpClient,
+			baseURL+LockServiceResignProcedure,
+			connect.WithSchema(lockServiceResignMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// lockServiceClient implements LockServiceClient.
+type lockServiceClient struct {
+	grant     *connect.Client[v1.GrantRequest, v1.GrantResponse]
+	keepAlive *connect.Client[v1.KeepAliveRequest, v1.KeepAliveResponse]
+	revoke    *connect.Client[v1.RevokeRequest, v1.RevokeResponse]
+	lock      *connect.Client[v1.LockRequest, v1.LockResponse]
+	tryLock   *connect.Client[v1.TryLockRequest, v1.TryLockResponse]
+	unlock    *connect.Client[v1.UnlockRequest, v1.UnlockResponse]
+	campaign  *connect.Client[v1.CampaignRequest, v1.CampaignResponse]
+	observe   *connect.Client[v1.ObserveRequest, v1.ObserveResponse]
+	resign    *connect.Client[v1.ResignRequest, v1.ResignResponse]
+}
+
+// Grant calls raftd.v1.LockService.Grant.
+func (c *lockServiceClient) Grant(ctx context.Context, req *connect.Request[v1.GrantRequest]) (*connect.Response[v1.GrantResponse], error) {
+	return c.grant.CallUnary(ctx, req)
+}
+
+// KeepAlive calls raftd.v1.LockService.KeepAlive.
+func (c *lockServiceClient) KeepAlive(ctx context.Context, req *connect.Request[v1.KeepAliveRequest]) (*connect.Response[v1.KeepAliveResponse], error) {
+	return c.keepAlive.CallUnary(ctx, req)
+}
+
+// Revoke calls raftd.v1.LockService.Revoke.
+func (c *lockServiceClient) Revoke(ctx context.Context, req *connect.Request[v1.RevokeRequest]) (*connect.Response[v1.RevokeResponse], error) {
+	return c.revoke.CallUnary(ctx, req)
+}
+
+// Lock calls raftd.v1.LockService.Lock.
+func (c *lockServiceClient) Lock(ctx context.Context, req *connect.Request[v1.LockRequest]) (*connect.Response[v1.LockResponse], error) {
+	return c.lock.CallUnary(ctx, req)
+}
+
+// TryLock calls raftd.v1.LockService.TryLock.
+func (c *lockServiceClient) TryLock(ctx context.Context, req *connect.Request[v1.TryLockRequest]) (*connect.Response[v1.TryLockResponse], error) {
+	return c.tryLock.CallUnary(ctx, req)
+}
+
+// Unlock calls raftd.v1.LockService.Unlock.
+func (c *lockServiceClient) Unlock(ctx context.Context, req *connect.Request[v1.UnlockRequest]) (*connect.Response[v1.UnlockResponse], error) {
+	return c.unlock.CallUnary(ctx, req)
+}
+
+// Campaign calls raftd.v1.LockService.Campaign.
+func (c *lockServiceClient) Campaign(ctx context.Context, req *connect.Request[v1.CampaignRequest]) (*connect.Response[v1.CampaignResponse], error) {
+	return c.campaign.CallUnary(ctx, req)
+}
+
+// Observe calls raftd.v1.LockService.Observe.
+func (c *lockServiceClient) Observe(ctx context.Context, req *connect.Request[v1.ObserveRequest]) (*connect.ServerStreamForClient[v1.ObserveResponse], error) {
+	return c.observe.CallServerStream(ctx, req)
+}
+
+// Resign calls raftd.v1.LockService.Resign.
+func (c *lockServiceClient) Resign(ctx context.Context, req *connect.Request[v1.ResignRequest]) (*connect.Response[v1.ResignResponse], error) {
+	return c.resign.CallUnary(ctx, req)
+}
+
+// LockServiceHandler is an implementation of the raftd.v1.LockService service.
+type LockServiceHandler interface {
+	Grant(context.Context, *connect.Request[v1.GrantRequest]) (*connect.Response[v1.GrantResponse], error)
+	KeepAlive(context.Context, *connect.Request[v1.KeepAliveRequest]) (*connect.Response[v1.KeepAliveResponse], error)
+	Revoke(context.Context, *connect.Request[v1.RevokeRequest]) (*connect.Response[v1.RevokeResponse], error)
+	// Lock waits until the lock is acquired or the call is cancelled.
+	Lock(context.Context, *connect.Request[v1.LockRequest]) (*connect.Response[v1.LockResponse], error)
+	// TryLock acquires the lock if it is free and returns immediately.
+	TryLock(context.Context, *connect.Request[v1.TryLockRequest]) (*connect.Response[v1.TryLockResponse], error)
+	Unlock(context.Context, *connect.Request[v1.UnlockRequest]) (*connect.Response[v1.UnlockResponse], error)
+	// Campaign waits until the caller is elected or the call is cancelled.
+	Campaign(context.Context, *connect.Request[v1.CampaignRequest]) (*connect.Response[v1.CampaignResponse], error)
+	// Observe streams the current leader of an election and every change
+	// of leader. It can be served by any node.
+	Observe(context.Context, *connect.Request[v1.ObserveRequest], *connect.ServerStream[v1.ObserveResponse]) error
+	Resign(context.Context, *connect.Request[v1.ResignRequest]) (*connect.Response[v1.ResignResponse], error)
+}
+
+// NewLockServiceHandler builds an HTTP handler from the service implementation. It returns the path
+// on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewLockServiceHandler(svc LockServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	lockServiceGrantHandler := connect.NewUnaryHandler(
+		LockServiceGrantProcedure,
+		svc.Grant,
+		connect.WithSchema(lockServiceGrantMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceKeepAliveHandler := connect.NewUnaryHandler(
+		LockServiceKeepAliveProcedure,
+		svc.KeepAlive,
+		connect.WithSchema(lockServiceKeepAliveMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceRevokeHandler := connect.NewUnaryHandler(
+		LockServiceRevokeProcedure,
+		svc.Revoke,
+		connect.WithSchema(lockServiceRevokeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceLockHandler := connect.NewUnaryHandler(
+		LockServiceLockProcedure,
+		svc.Lock,
+		connect.WithSchema(lockServiceLockMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceTryLockHandler := connect.NewUnaryHandler(
+		LockServiceTryLockProcedure,
+		svc.TryLock,
+		connect.WithSchema(lockServiceTryLockMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceUnlockHandler := connect.NewUnaryHandler(
+		LockServiceUnlockProcedure,
+		svc.Unlock,
+		connect.WithSchema(lockServiceUnlockMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceCampaignHandler := connect.NewUnaryHandler(
+		LockServiceCampaignProcedure,
+		svc.Campaign,
+		connect.WithSchema(lockServiceCampaignMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceObserveHandler := connect.NewServerStreamHandler(
+		LockServiceObserveProcedure,
+		svc.Observe,
+		connect.WithSchema(lockServiceObserveMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceResignHandler := connect.NewUnaryHandler(
+		LockServiceResignProcedure,
+		svc.Resign,
+		connect.WithSchema(lockServiceResignMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/raftd.v1.LockService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case LockServiceGrantProcedure:
+			lockServiceGrantHandler.ServeHTTP(w, r)
+		case LockServiceKeepAliveProcedure:
+			lockServiceKeepAliveHandler.ServeHTTP(w, r)
+		case LockServiceRevokeProcedure:
+			lockServiceRevokeHandler.ServeHTTP(w, r)
+		case LockServiceLockProcedure:
+			lockServiceLockHandler.ServeHTTP(w, r)
+		case LockServiceTryLockProcedure:
+			lockServiceTryLockHandler.ServeHTTP(w, r)
+		case LockServiceUnlockProcedure:
+			lockServiceUnlockHandler.ServeHTTP(w, r)
+		case LockServiceCampaignProcedure:
+			lockServiceCampaignHandler.ServeHTTP(w, r)
+		case LockServiceObserveProcedure:
+			lockServiceObserveHandler.ServeHTTP(w, r)
+		case LockServiceResignProcedure:
+			lockServiceResignHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedLockServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedLockServiceHandler struct{}
+
+func (UnimplementedLockServiceHandler) Grant(context.Context, *connect.Request[v1.GrantRequest]) (*connect.Response[v1.GrantResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.LockService.Grant is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) KeepAlive(context.Context, *connect.Request[v1.KeepAliveRequest]) (*connect.Response[v1.KeepAliveResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.LockService.KeepAlive is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) Revoke(context.Context, *connect.Request[v1.RevokeRequest]) (*connect.Response[v1.RevokeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.LockService.Revoke is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) Lock(context.Context, *connect.Request[v1.LockRequest]) (*connect.Response[v1.LockResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.LockService.Lock is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) TryLock(context.Context, *connect.Request[v1.TryLockRequest]) (*connect.Response[v1.TryLockResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.LockService.TryLock is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) Unlock(context.Context, *connect.Request[v1.UnlockRequest]) (*connect.Response[v1.UnlockResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.LockService.Unlock is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) Campaign(context.Context, *connect.Request[v1.CampaignRequest]) (*connect.Response[v1.CampaignResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.LockService.Campaign is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) Observe(context.Context, *connect.Request[v1.ObserveRequest], *connect.ServerStream[v1.ObserveResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.LockService.Observe is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) Resign(context.Context, *connect.Request[v1.ResignRequest]) (*connect.Response[v1.ResignResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.LockService.Resign is not implemented"))
+}
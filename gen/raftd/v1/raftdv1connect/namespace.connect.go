@@ -0,0 +1,241 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: raftd/v1/namespace.proto
+
+package raftdv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// NamespaceServiceName is the fully-qualified name of the NamespaceService service.
+	NamespaceServiceName = "raftd.v1.NamespaceService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// NamespaceServiceCreateNamespaceProcedure is the fully-qualified name of the NamespaceService's
+	// CreateNamespace RPC.
+	NamespaceServiceCreateNamespaceProcedure = "/raftd.v1.NamespaceService/CreateNamespace"
+	// NamespaceServiceGetNamespaceProcedure is the fully-qualified name of the NamespaceService's
+	// GetNamespace RPC.
+	NamespaceServiceGetNamespaceProcedure = "/raftd.v1.NamespaceService/GetNamespace"
+	// NamespaceServiceListNamespacesProcedure is the fully-qualified name of the NamespaceService's
+	// ListNamespaces RPC.
+	NamespaceServiceListNamespacesProcedure = "/raftd.v1.NamespaceService/ListNamespaces"
+	// NamespaceServiceUpdateNamespaceProcedure is the fully-qualified name of the NamespaceService's
+	// UpdateNamespace RPC.
+	NamespaceServiceUpdateNamespaceProcedure = "/raftd.v1.NamespaceService/UpdateNamespace"
+	// NamespaceServiceDeleteNamespaceProcedure is the fully-qualified name of the NamespaceService's
+	// DeleteNamespace RPC.
+	NamespaceServiceDeleteNamespaceProcedure = "/raftd.v1.NamespaceService/DeleteNamespace"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	namespaceServiceServiceDescriptor               = v1.File_raftd_v1_namespace_proto.Services().ByName("NamespaceService")
+	namespaceServiceCreateNamespaceMethodDescriptor = namespaceServiceServiceDescriptor.Methods().ByName("CreateNamespace")
+	namespaceServiceGetNamespaceMethodDescriptor    = namespaceServiceServiceDescriptor.Methods().ByName("GetNamespace")
+	namespaceServiceListNamespacesMethodDescriptor  = namespaceServiceServiceDescriptor.Methods().ByName("ListNamespaces")
+	namespaceServiceUpdateNamespaceMethodDescriptor = namespaceServiceServiceDescriptor.Methods().ByName("UpdateNamespace")
+	namespaceServiceDeleteNamespaceMethodDescriptor = namespaceServiceServiceDescriptor.Methods().ByName("DeleteNamespace")
+)
+
+// NamespaceServiceClient is a client for the raftd.v1.NamespaceService service.
+type NamespaceServiceClient interface {
+	CreateNamespace(context.Context, *connect.Request[v1.CreateNamespaceRequest]) (*connect.Response[v1.CreateNamespaceResponse], error)
+	// GetNamespace and ListNamespaces read the local state of any node.
+	GetNamespace(context.Context, *connect.Request[v1.GetNamespaceRequest]) (*connect.Response[v1.GetNamespaceResponse], error)
+	ListNamespaces(context.Context, *connect.Request[v1.ListNamespacesRequest]) (*connect.Response[v1.ListNamespacesResponse], error)
+	UpdateNamespace(context.Context, *connect.Request[v1.UpdateNamespaceRequest]) (*connect.Response[v1.UpdateNamespaceResponse], error)
+	// DeleteNamespace deletes the namespace along with its keys, whose
+	// deletions reach the consumers of changes at the revision of the
+	// namespace deletion.
+	DeleteNamespace(context.Context, *connect.Request[v1.DeleteNamespaceRequest]) (*connect.Response[v1.DeleteNamespaceResponse], error)
+}
+
+// NewNamespaceServiceClient constructs a client for the raftd.v1.NamespaceService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewNamespaceServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) NamespaceServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &namespaceServiceClient{
+		createNamespace: connect.NewClient[v1.CreateNamespaceRequest, v1.CreateNamespaceResponse](
+			httpClient,
+			baseURL+NamespaceServiceCreateNamespaceProcedure,
+			connect.WithSchema(namespaceServiceCreateNamespaceMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getNamespace: connect.NewClient[v1.GetNamespaceRequest, v1.GetNamespaceResponse](
+			httpClient,
+			baseURL+NamespaceServiceGetNamespaceProcedure,
+			connect.WithSchema(namespaceServiceGetNamespaceMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listNamespaces: connect.NewClient[v1.ListNamespacesRequest, v1.ListNamespacesResponse](
+			httpClient,
+			baseURL+NamespaceServiceListNamespacesProcedure,
+			connect.WithSchema(namespaceServiceListNamespacesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		updateNamespace: connect.NewClient[v1.UpdateNamespaceRequest, v1.UpdateNamespaceResponse](
+			httpClient,
+			baseURL+NamespaceServiceUpdateNamespaceProcedure,
+			connect.WithSchema(namespaceServiceUpdateNamespaceMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		deleteNamespace: connect.NewClient[v1.DeleteNamespaceRequest, v1.DeleteNamespaceResponse](
+			httpClient,
+			baseURL+NamespaceServiceDeleteNamespaceProcedure,
+			connect.WithSchema(namespaceServiceDeleteNamespaceMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// namespaceServiceClient implements NamespaceServiceClient.
+type namespaceServiceClient struct {
+	createNamespace *connect.Client[v1.CreateNamespaceRequest, v1.CreateNamespaceResponse]
+	getNamespace    *connect.Client[v1.GetNamespaceRequest, v1.GetNamespaceResponse]
+	listNamespaces  *connect.Client[v1.ListNamespacesRequest, v1.ListNamespacesResponse]
+	updateNamespace *connect.Client[v1.UpdateNamespaceRequest, v1.UpdateNamespaceResponse]
+	deleteNamespace *connect.Client[v1.DeleteNamespaceRequest, v1.DeleteNamespaceResponse]
+}
+
+// CreateNamespace calls raftd.v1.NamespaceService.CreateNamespace.
+func (c *namespaceServiceClient) CreateNamespace(ctx context.Context, req *connect.Request[v1.CreateNamespaceRequest]) (*connect.Response[v1.CreateNamespaceResponse], error) {
+	return c.createNamespace.CallUnary(ctx, req)
+}
+
+// GetNamespace calls raftd.v1.NamespaceService.GetNamespace.
+func (c *namespaceServiceClient) GetNamespace(ctx context.Context, req *connect.Request[v1.GetNamespaceRequest]) (*connect.Response[v1.GetNamespaceResponse], error) {
+	return c.getNamespace.CallUnary(ctx, req)
+}
+
+// ListNamespaces calls raftd.v1.NamespaceService.ListNamespaces.
+func (c *namespaceServiceClient) ListNamespaces(ctx context.Context, req *connect.Request[v1.ListNamespacesRequest]) (*connect.Response[v1.ListNamespacesResponse], error) {
+	return c.listNamespaces.CallUnary(ctx, req)
+}
+
+// UpdateNamespace calls raftd.v1.NamespaceService.UpdateNamespace.
+func (c *namespaceServiceClient) UpdateNamespace(ctx context.Context, req *connect.Request[v1.UpdateNamespaceRequest]) (*connect.Response[v1.UpdateNamespaceResponse], error) {
+	return c.updateNamespace.CallUnary(ctx, req)
+}
+
+// DeleteNamespace calls raftd.v1.NamespaceService.DeleteNamespace.
+func (c *namespaceServiceClient) DeleteNamespace(ctx context.Context, req *connect.Request[v1.DeleteNamespaceRequest]) (*connect.Response[v1.DeleteNamespaceResponse], error) {
+	return c.deleteNamespace.CallUnary(ctx, req)
+}
+
+// NamespaceServiceHandler is an implementation of the raftd.v1.NamespaceService service.
+type NamespaceServiceHandler interface {
+	CreateNamespace(context.Context, *connect.Request[v1.CreateNamespaceRequest]) (*connect.Response[v1.CreateNamespaceResponse], error)
+	// GetNamespace and ListNamespaces read the local state of any node.
+	GetNamespace(context.Context, *connect.Request[v1.GetNamespaceRequest]) (*connect.Response[v1.GetNamespaceResponse], error)
+	ListNamespaces(context.Context, *connect.Request[v1.ListNamespacesRequest]) (*connect.Response[v1.ListNamespacesResponse], error)
+	UpdateNamespace(context.Context, *connect.Request[v1.UpdateNamespaceRequest]) (*connect.Response[v1.UpdateNamespaceResponse], error)
+	// DeleteNamespace deletes the namespace along with its keys, whose
+	// deletions reach the consumers of changes at the revision of the
+	// namespace deletion.
+	DeleteNamespace(context.Context, *connect.Request[v1.DeleteNamespaceRequest]) (*connect.Response[v1.DeleteNamespaceResponse], error)
+}
+
+// NewNamespaceServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewNamespaceServiceHandler(svc NamespaceServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	namespaceServiceCreateNamespaceHandler := connect.NewUnaryHandler(
+		NamespaceServiceCreateNamespaceProcedure,
+		svc.CreateNamespace,
+		connect.WithSchema(namespaceServiceCreateNamespaceMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	namespaceServiceGetNamespaceHandler := connect.NewUnaryHandler(
+		NamespaceServiceGetNamespaceProcedure,
+		svc.GetNamespace,
+		connect.WithSchema(namespaceServiceGetNamespaceMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	namespaceServiceListNamespacesHandler := connect.NewUnaryHandler(
+		NamespaceServiceListNamespacesProcedure,
+		svc.ListNamespaces,
+		connect.WithSchema(namespaceServiceListNamespacesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	namespaceServiceUpdateNamespaceHandler := connect.NewUnaryHandler(
+		NamespaceServiceUpdateNamespaceProcedure,
+		svc.UpdateNamespace,
+		connect.WithSchema(namespaceServiceUpdateNamespaceMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	namespaceServiceDeleteNamespaceHandler := connect.NewUnaryHandler(
+		NamespaceServiceDeleteNamespaceProcedure,
+		svc.DeleteNamespace,
+		connect.WithSchema(namespaceServiceDeleteNamespaceMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/raftd.v1.NamespaceService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case NamespaceServiceCreateNamespaceProcedure:
+			namespaceServiceCreateNamespaceHandler.ServeHTTP(w, r)
+		case NamespaceServiceGetNamespaceProcedure:
+			namespaceServiceGetNamespaceHandler.ServeHTTP(w, r)
+		case NamespaceServiceListNamespacesProcedure:
+			namespaceServiceListNamespacesHandler.ServeHTTP(w, r)
+		case NamespaceServiceUpdateNamespaceProcedure:
+			namespaceServiceUpdateNamespaceHandler.ServeHTTP(w, r)
+		case NamespaceServiceDeleteNamespaceProcedure:
+			namespaceServiceDeleteNamespaceHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedNamespaceServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedNamespaceServiceHandler struct{}
+
+func (UnimplementedNamespaceServiceHandler) CreateNamespace(context.Context, *connect.Request[v1.CreateNamespaceRequest]) (*connect.Response[v1.CreateNamespaceResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.NamespaceService.CreateNamespace is not implemented"))
+}
+
+func (UnimplementedNamespaceServiceHandler) GetNamespace(context.Context, *connect.Request[v1.GetNamespaceRequest]) (*connect.Response[v1.GetNamespaceResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.NamespaceService.GetNamespace is not implemented"))
+}
+
+func (UnimplementedNamespaceServiceHandler) ListNamespaces(context.Context, *connect.Request[v1.ListNamespacesRequest]) (*connect.Response[v1.ListNamespacesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.NamespaceService.ListNamespaces is not implemented"))
+}
+
+func (UnimplementedNamespaceServiceHandler) UpdateNamespace(context.Context, *connect.Request[v1.UpdateNamespaceRequest]) (*connect.Response[v1.UpdateNamespaceResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.NamespaceService.UpdateNamespace is not implemented"))
+}
+
+func (UnimplementedNamespaceServiceHandler) DeleteNamespace(context.Context, *connect.Request[v1.DeleteNamespaceRequest]) (*connect.Response[v1.DeleteNamespaceResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.NamespaceService.DeleteNamespace is not implemented"))
+}
@@ -0,0 +1,263 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: raftd/v1/queue.proto
+
+package raftdv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// QueueServiceName is the fully-qualified name of the QueueService service.
+	QueueServiceName = "raftd.v1.QueueService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// QueueServiceEnqueueProcedure is the fully-qualified name of the QueueService's Enqueue RPC.
+	QueueServiceEnqueueProcedure = "/raftd.v1.QueueService/Enqueue"
+	// QueueServiceDequeueProcedure is the fully-qualified name of the QueueService's Dequeue RPC.
+	QueueServiceDequeueProcedure = "/raftd.v1.QueueService/Dequeue"
+	// QueueServiceAckProcedure is the fully-qualified name of the QueueService's Ack RPC.
+	QueueServiceAckProcedure = "/raftd.v1.QueueService/Ack"
+	// QueueServiceNackProcedure is the fully-qualified name of the QueueService's Nack RPC.
+	QueueServiceNackProcedure = "/raftd.v1.QueueService/Nack"
+	// QueueServicePeekProcedure is the fully-qualified name of the QueueService's Peek RPC.
+	QueueServicePeekProcedure = "/raftd.v1.QueueService/Peek"
+	// QueueServiceLengthProcedure is the fully-qualified name of the QueueService's Length RPC.
+	QueueServiceLengthProcedure = "/raftd.v1.QueueService/Length"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	queueServiceServiceDescriptor       = v1.File_raftd_v1_queue_proto.Services().ByName("QueueService")
+	queueServiceEnqueueMethodDescriptor = queueServiceServiceDescriptor.Methods().ByName("Enqueue")
+	queueServiceDequeueMethodDescriptor = queueServiceServiceDescriptor.Methods().ByName("Dequeue")
+	queueServiceAckMethodDescriptor     = queueServiceServiceDescriptor.Methods().ByName("Ack")
+	queueServiceNackMethodDescriptor    = queueServiceServiceDescriptor.Methods().ByName("Nack")
+	queueServicePeekMethodDescriptor    = queueServiceServiceDescriptor.Methods().ByName("Peek")
+	queueServiceLengthMethodDescriptor  = queueServiceServiceDescriptor.Methods().ByName("Length")
+)
+
+// QueueServiceClient is a client for the raftd.v1.QueueService service.
+type QueueServiceClient interface {
+	Enqueue(context.Context, *connect.Request[v1.EnqueueRequest]) (*connect.Response[v1.EnqueueResponse], error)
+	Dequeue(context.Context, *connect.Request[v1.DequeueRequest]) (*connect.Response[v1.DequeueResponse], error)
+	Ack(context.Context, *connect.Request[v1.AckRequest]) (*connect.Response[v1.AckResponse], error)
+	Nack(context.Context, *connect.Request[v1.NackRequest]) (*connect.Response[v1.NackResponse], error)
+	// Peek returns the next message Dequeue would hand out, leaving it
+	// in place. Like Length, it reads with the requested consistency, as
+	// KVService.Get does.
+	Peek(context.Context, *connect.Request[v1.PeekRequest]) (*connect.Response[v1.PeekResponse], error)
+	Length(context.Context, *connect.Request[v1.LengthRequest]) (*connect.Response[v1.LengthResponse], error)
+}
+
+// NewQueueServiceClient constructs a client for the raftd.v1.QueueService service. By default, it
+// uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewQueueServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) QueueServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &queueServiceClient{
+		enqueue: connect.NewClient[v1.EnqueueRequest, v1.EnqueueResponse](
+			httpClient,
+			baseURL+QueueServiceEnqueueProcedure,
+			connect.WithSchema(queueServiceEnqueueMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		dequeue: connect.NewClient[v1.DequeueRequest, v1.DequeueResponse](
+			httpClient,
+			baseURL+QueueServiceDequeueProcedure,
+			connect.WithSchema(queueServiceDequeueMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		ack: connect.NewClient[v1.AckRequest, v1.AckResponse](
+			httpClient,
+			baseURL+QueueServiceAckProcedure,
+			connect.WithSchema(queueServiceAckMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		nack: connect.NewClient[v1.NackRequest, v1.NackResponse](
+			httpClient,
+			baseURL+QueueServiceNackProcedure,
+			connect.WithSchema(queueServiceNackMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		peek: connect.NewClient[v1.PeekRequest, v1.PeekResponse](
+			httpClient,
+			baseURL+QueueServicePeekProcedure,
+			connect.WithSchema(queueServicePeekMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		length: connect.NewClient[v1.LengthRequest, v1.LengthResponse](
+			httpClient,
+			baseURL+QueueServiceLengthProcedure,
+			connect.WithSchema(queueServiceLengthMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// queueServiceClient implements QueueServiceClient.
+type queueServiceClient struct {
+	enqueue *connect.Client[v1.EnqueueRequest, v1.EnqueueResponse]
+	dequeue *connect.Client[v1.DequeueRequest, v1.DequeueResponse]
+	ack     *connect.Client[v1.AckRequest, v1.AckResponse]
+	nack    *connect.Client[v1.NackRequest, v1.NackResponse]
+	peek    *connect.Client[v1.PeekRequest, v1.PeekResponse]
+	length  *connect.Client[v1.LengthRequest, v1.LengthResponse]
+}
+
+// Enqueue calls raftd.v1.QueueService.Enqueue.
+func (c *queueServiceClient) Enqueue(ctx context.Context, req *connect.Request[v1.EnqueueRequest]) (*connect.Response[v1.EnqueueResponse], error) {
+	return c.enqueue.CallUnary(ctx, req)
+}
+
+// Dequeue calls raftd.v1.QueueService.Dequeue.
+func (c *queueServiceClient) Dequeue(ctx context.Context, req *connect.Request[v1.DequeueRequest]) (*connect.Response[v1.DequeueResponse], error) {
+	return c.dequeue.CallUnary(ctx, req)
+}
+
+// Ack calls raftd.v1.QueueService.Ack.
+func (c *queueServiceClient) Ack(ctx context.Context, req *connect.Request[v1.AckRequest]) (*connect.Response[v1.AckResponse], error) {
+	return c.ack.CallUnary(ctx, req)
+}
+
+// Nack calls raftd.v1.QueueService.Nack.
+func (c *queueServiceClient) Nack(ctx context.Context, req *connect.Request[v1.NackRequest]) (*connect.Response[v1.NackResponse], error) {
+	return c.nack.CallUnary(ctx, req)
+}
+
+// Peek calls raftd.v1.QueueService.Peek.
+func (c *queueServiceClient) Peek(ctx context.Context, req *connect.Request[v1.PeekRequest]) (*connect.Response[v1.PeekResponse], error) {
+	return c.peek.CallUnary(ctx, req)
+}
+
+// Length calls raftd.v1.QueueService.Length.
+func (c *queueServiceClient) Length(ctx context.Context, req *connect.Request[v1.LengthRequest]) (*connect.Response[v1.LengthResponse], error) {
+	return c.length.CallUnary(ctx, req)
+}
+
+// QueueServiceHandler is an implementation of the raftd.v1.QueueService service.
+type QueueServiceHandler interface {
+	Enqueue(context.Context, *connect.Request[v1.EnqueueRequest]) (*connect.Response[v1.EnqueueResponse], error)
+	Dequeue(context.Context, *connect.Request[v1.DequeueRequest]) (*connect.Response[v1.DequeueResponse], error)
+	Ack(context.Context, *connect.Request[v1.AckRequest]) (*connect.Response[v1.AckResponse], error)
+	Nack(context.Context, *connect.Request[v1.NackRequest]) (*connect.Response[v1.NackResponse], error)
+	// Peek returns the next message Dequeue would hand out, leaving it
+	// in place. Like Length, it reads with the requested consistency, as
+	// KVService.Get does.
+	Peek(context.Context, *connect.Request[v1.PeekRequest]) (*connect.Response[v1.PeekResponse], error)
+	Length(context.Context, *connect.Request[v1.LengthRequest]) (*connect.Response[v1.LengthResponse], error)
+}
+
+// NewQueueServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewQueueServiceHandler(svc QueueServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	queueServiceEnqueueHandler := connect.NewUnaryHandler(
+		QueueServiceEnqueueProcedure,
+		svc.Enqueue,
+		connect.WithSchema(queueServiceEnqueueMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	queueServiceDequeueHandler := connect.NewUnaryHandler(
+		QueueServiceDequeueProcedure,
+		svc.Dequeue,
+		connect.WithSchema(queueServiceDequeueMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	queueServiceAckHandler := connect.NewUnaryHandler(
+		QueueServiceAckProcedure,
+		svc.Ack,
+		connect.WithSchema(queueServiceAckMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	queueServiceNackHandler := connect.NewUnaryHandler(
+		QueueServiceNackProcedure,
+		svc.Nack,
+		connect.WithSchema(queueServiceNackMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	queueServicePeekHandler := connect.NewUnaryHandler(
+		QueueServicePeekProcedure,
+		svc.Peek,
+		connect.WithSchema(queueServicePeekMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	queueServiceLengthHandler := connect.NewUnaryHandler(
+		QueueServiceLengthProcedure,
+		svc.Length,
+		connect.WithSchema(queueServiceLengthMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/raftd.v1.QueueService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case QueueServiceEnqueueProcedure:
+			queueServiceEnqueueHandler.ServeHTTP(w, r)
+		case QueueServiceDequeueProcedure:
+			queueServiceDequeueHandler.ServeHTTP(w, r)
+		case QueueServiceAckProcedure:
+			queueServiceAckHandler.ServeHTTP(w, r)
+		case QueueServiceNackProcedure:
+			queueServiceNackHandler.ServeHTTP(w, r)
+		case QueueServicePeekProcedure:
+			queueServicePeekHandler.ServeHTTP(w, r)
+		case QueueServiceLengthProcedure:
+			queueServiceLengthHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedQueueServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedQueueServiceHandler struct{}
+
+func (UnimplementedQueueServiceHandler) Enqueue(context.Context, *connect.Request[v1.EnqueueRequest]) (*connect.Response[v1.EnqueueResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.QueueService.Enqueue is not implemented"))
+}
+
+func (UnimplementedQueueServiceHandler) Dequeue(context.Context, *connect.Request[v1.DequeueRequest]) (*connect.Response[v1.DequeueResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.QueueService.Dequeue is not implemented"))
+}
+
+func (UnimplementedQueueServiceHandler) Ack(context.Context, *connect.Request[v1.AckRequest]) (*connect.Response[v1.AckResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.QueueService.Ack is not implemented"))
+}
+
+func (UnimplementedQueueServiceHandler) Nack(context.Context, *connect.Request[v1.NackRequest]) (*connect.Response[v1.NackResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.QueueService.Nack is not implemented"))
+}
+
+func (UnimplementedQueueServiceHandler) Peek(context.Context, *connect.Request[v1.PeekRequest]) (*connect.Response[v1.PeekResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.QueueService.Peek is not implemented"))
+}
+
+func (UnimplementedQueueServiceHandler) Length(context.Context, *connect.Request[v1.LengthRequest]) (*connect.Response[v1.LengthResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.QueueService.Length is not implemented"))
+}
@@ -39,14 +39,20 @@ const (
 	RaftServiceLeaveProcedure = "/raftd.v1.RaftService/Leave"
 	// RaftServiceStatusProcedure is the fully-qualified name of the RaftService's Status RPC.
 	RaftServiceStatusProcedure = "/raftd.v1.RaftService/Status"
+	// RaftServiceRestoreProcedure is the fully-qualified name of the RaftService's Restore RPC.
+	RaftServiceRestoreProcedure = "/raftd.v1.RaftService/Restore"
+	// RaftServiceBackupProcedure is the fully-qualified name of the RaftService's Backup RPC.
+	RaftServiceBackupProcedure = "/raftd.v1.RaftService/Backup"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
 var (
-	raftServiceServiceDescriptor      = v1.File_raftd_v1_raft_proto.Services().ByName("RaftService")
-	raftServiceJoinMethodDescriptor   = raftServiceServiceDescriptor.Methods().ByName("Join")
-	raftServiceLeaveMethodDescriptor  = raftServiceServiceDescriptor.Methods().ByName("Leave")
-	raftServiceStatusMethodDescriptor = raftServiceServiceDescriptor.Methods().ByName("Status")
+	raftServiceServiceDescriptor       = v1.File_raftd_v1_raft_proto.Services().ByName("RaftService")
+	raftServiceJoinMethodDescriptor    = raftServiceServiceDescriptor.Methods().ByName("Join")
+	raftServiceLeaveMethodDescriptor   = raftServiceServiceDescriptor.Methods().ByName("Leave")
+	raftServiceStatusMethodDescriptor  = raftServiceServiceDescriptor.Methods().ByName("Status")
+	raftServiceRestoreMethodDescriptor = raftServiceServiceDescriptor.Methods().ByName("Restore")
+	raftServiceBackupMethodDescriptor  = raftServiceServiceDescriptor.Methods().ByName("Backup")
 )
 
 // RaftServiceClient is a client for the raftd.v1.RaftService service.
@@ -54,6 +60,8 @@ type RaftServiceClient interface {
 	Join(context.Context, *connect.Request[v1.JoinRequest]) (*connect.Response[v1.JoinResponse], error)
 	Leave(context.Context, *connect.Request[v1.LeaveRequest]) (*connect.Response[v1.LeaveResponse], error)
 	Status(context.Context, *connect.Request[v1.StatusRequest]) (*connect.Response[v1.StatusResponse], error)
+	Restore(context.Context) *connect.ClientStreamForClient[v1.RestoreRequest, v1.RestoreResponse]
+	Backup(context.Context, *connect.Request[v1.BackupRequest]) (*connect.ServerStreamForClient[v1.BackupResponse], error)
 }
 
 // NewRaftServiceClient constructs a client for the raftd.v1.RaftService service. By default, it
@@ -84,14 +92,28 @@ func NewRaftServiceClient(httpClient connect.HTTPClient, baseURL string, opts ..
 			connect.WithSchema(raftServiceStatusMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		restore: connect.NewClient[v1.RestoreRequest, v1.RestoreResponse](
+			httpClient,
+			baseURL+RaftServiceRestoreProcedure,
+			connect.WithSchema(raftServiceRestoreMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		backup: connect.NewClient[v1.BackupRequest, v1.BackupResponse](
+			httpClient,
+			baseURL+RaftServiceBackupProcedure,
+			connect.WithSchema(raftServiceBackupMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // raftServiceClient implements RaftServiceClient.
 type raftServiceClient struct {
-	join   *connect.Client[v1.JoinRequest, v1.JoinResponse]
-	leave  *connect.Client[v1.LeaveRequest, v1.LeaveResponse]
-	status *connect.Client[v1.StatusRequest, v1.StatusResponse]
+	join    *connect.Client[v1.JoinRequest, v1.JoinResponse]
+	leave   *connect.Client[v1.LeaveRequest, v1.LeaveResponse]
+	status  *connect.Client[v1.StatusRequest, v1.StatusResponse]
+	restore *connect.Client[v1.RestoreRequest, v1.RestoreResponse]
+	backup  *connect.Client[v1.BackupRequest, v1.BackupResponse]
 }
 
 // Join calls raftd.v1.RaftService.Join.
@@ -109,11 +131,23 @@ func (c *raftServiceClient) Status(ctx context.Context, req *connect.Request[v1.
 	return c.status.CallUnary(ctx, req)
 }
 
+// Restore calls raftd.v1.RaftService.Restore.
+func (c *raftServiceClient) Restore(ctx context.Context) *connect.ClientStreamForClient[v1.RestoreRequest, v1.RestoreResponse] {
+	return c.restore.CallClientStream(ctx)
+}
+
+// Backup calls raftd.v1.RaftService.Backup.
+func (c *raftServiceClient) Backup(ctx context.Context, req *connect.Request[v1.BackupRequest]) (*connect.ServerStreamForClient[v1.BackupResponse], error) {
+	return c.backup.CallServerStream(ctx, req)
+}
+
 // RaftServiceHandler is an implementation of the raftd.v1.RaftService service.
 type RaftServiceHandler interface {
 	Join(context.Context, *connect.Request[v1.JoinRequest]) (*connect.Response[v1.JoinResponse], error)
 	Leave(context.Context, *connect.Request[v1.LeaveRequest]) (*connect.Response[v1.LeaveResponse], error)
 	Status(context.Context, *connect.Request[v1.StatusRequest]) (*connect.Response[v1.StatusResponse], error)
+	Restore(context.Context, *connect.ClientStream[v1.RestoreRequest]) (*connect.Response[v1.RestoreResponse], error)
+	Backup(context.Context, *connect.Request[v1.BackupRequest], *connect.ServerStream[v1.BackupResponse]) error
 }
 
 // NewRaftServiceHandler builds an HTTP handler from the service implementation. It returns the path
@@ -140,6 +174,18 @@ func NewRaftServiceHandler(svc RaftServiceHandler, opts ...connect.HandlerOption
 		connect.WithSchema(raftServiceStatusMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	raftServiceRestoreHandler := connect.NewClientStreamHandler(
+		RaftServiceRestoreProcedure,
+		svc.Restore,
+		connect.WithSchema(raftServiceRestoreMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	raftServiceBackupHandler := connect.NewServerStreamHandler(
+		RaftServiceBackupProcedure,
+		svc.Backup,
+		connect.WithSchema(raftServiceBackupMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/raftd.v1.RaftService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case RaftServiceJoinProcedure:
@@ -148,6 +194,10 @@ func NewRaftServiceHandler(svc RaftServiceHandler, opts ...connect.HandlerOption
 			raftServiceLeaveHandler.ServeHTTP(w, r)
 		case RaftServiceStatusProcedure:
 			raftServiceStatusHandler.ServeHTTP(w, r)
+		case RaftServiceRestoreProcedure:
+			raftServiceRestoreHandler.ServeHTTP(w, r)
+		case RaftServiceBackupProcedure:
+			raftServiceBackupHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -168,3 +218,11 @@ func (UnimplementedRaftServiceHandler) Leave(context.Context, *connect.Request[v
 func (UnimplementedRaftServiceHandler) Status(context.Context, *connect.Request[v1.StatusRequest]) (*connect.Response[v1.StatusResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.RaftService.Status is not implemented"))
 }
+
+func (UnimplementedRaftServiceHandler) Restore(context.Context, *connect.ClientStream[v1.RestoreRequest]) (*connect.Response[v1.RestoreResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.RaftService.Restore is not implemented"))
+}
+
+func (UnimplementedRaftServiceHandler) Backup(context.Context, *connect.Request[v1.BackupRequest], *connect.ServerStream[v1.BackupResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.RaftService.Backup is not implemented"))
+}
@@ -39,14 +39,42 @@ const (
 	KVServiceGetProcedure = "/raftd.v1.KVService/Get"
 	// KVServiceDeleteProcedure is the fully-qualified name of the KVService's Delete RPC.
 	KVServiceDeleteProcedure = "/raftd.v1.KVService/Delete"
+	// KVServiceRangeProcedure is the fully-qualified name of the KVService's Range RPC.
+	KVServiceRangeProcedure = "/raftd.v1.KVService/Range"
+	// KVServiceCompareAndSwapProcedure is the fully-qualified name of the KVService's CompareAndSwap
+	// RPC.
+	KVServiceCompareAndSwapProcedure = "/raftd.v1.KVService/CompareAndSwap"
+	// KVServiceIncrementProcedure is the fully-qualified name of the KVService's Increment RPC.
+	KVServiceIncrementProcedure = "/raftd.v1.KVService/Increment"
+	// KVServiceDecrementProcedure is the fully-qualified name of the KVService's Decrement RPC.
+	KVServiceDecrementProcedure = "/raftd.v1.KVService/Decrement"
+	// KVServiceCompactProcedure is the fully-qualified name of the KVService's Compact RPC.
+	KVServiceCompactProcedure = "/raftd.v1.KVService/Compact"
+	// KVServiceExportProcedure is the fully-qualified name of the KVService's Export RPC.
+	KVServiceExportProcedure = "/raftd.v1.KVService/Export"
+	// KVServiceImportProcedure is the fully-qualified name of the KVService's Import RPC.
+	KVServiceImportProcedure = "/raftd.v1.KVService/Import"
+	// KVServiceTxnProcedure is the fully-qualified name of the KVService's Txn RPC.
+	KVServiceTxnProcedure = "/raftd.v1.KVService/Txn"
+	// KVServiceWatchProcedure is the fully-qualified name of the KVService's Watch RPC.
+	KVServiceWatchProcedure = "/raftd.v1.KVService/Watch"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
 var (
-	kVServiceServiceDescriptor      = v1.File_raftd_v1_store_proto.Services().ByName("KVService")
-	kVServiceSetMethodDescriptor    = kVServiceServiceDescriptor.Methods().ByName("Set")
-	kVServiceGetMethodDescriptor    = kVServiceServiceDescriptor.Methods().ByName("Get")
-	kVServiceDeleteMethodDescriptor = kVServiceServiceDescriptor.Methods().ByName("Delete")
+	kVServiceServiceDescriptor              = v1.File_raftd_v1_store_proto.Services().ByName("KVService")
+	kVServiceSetMethodDescriptor            = kVServiceServiceDescriptor.Methods().ByName("Set")
+	kVServiceGetMethodDescriptor            = kVServiceServiceDescriptor.Methods().ByName("Get")
+	kVServiceDeleteMethodDescriptor         = kVServiceServiceDescriptor.Methods().ByName("Delete")
+	kVServiceRangeMethodDescriptor          = kVServiceServiceDescriptor.Methods().ByName("Range")
+	kVServiceCompareAndSwapMethodDescriptor = kVServiceServiceDescriptor.Methods().ByName("CompareAndSwap")
+	kVServiceIncrementMethodDescriptor      = kVServiceServiceDescriptor.Methods().ByName("Increment")
+	kVServiceDecrementMethodDescriptor      = kVServiceServiceDescriptor.Methods().ByName("Decrement")
+	kVServiceCompactMethodDescriptor        = kVServiceServiceDescriptor.Methods().ByName("Compact")
+	kVServiceExportMethodDescriptor         = kVServiceServiceDescriptor.Methods().ByName("Export")
+	kVServiceImportMethodDescriptor         = kVServiceServiceDescriptor.Methods().ByName("Import")
+	kVServiceTxnMethodDescriptor            = kVServiceServiceDescriptor.Methods().ByName("Txn")
+	kVServiceWatchMethodDescriptor          = kVServiceServiceDescriptor.Methods().ByName("Watch")
 )
 
 // KVServiceClient is a client for the raftd.v1.KVService service.
@@ -54,6 +82,28 @@ type KVServiceClient interface {
 	Set(context.Context, *connect.Request[v1.SetRequest]) (*connect.Response[v1.SetResponse], error)
 	Get(context.Context, *connect.Request[v1.GetRequest]) (*connect.Response[v1.GetResponse], error)
 	Delete(context.Context, *connect.Request[v1.DeleteRequest]) (*connect.Response[v1.DeleteResponse], error)
+	Range(context.Context, *connect.Request[v1.RangeRequest]) (*connect.Response[v1.RangeResponse], error)
+	CompareAndSwap(context.Context, *connect.Request[v1.CompareAndSwapRequest]) (*connect.Response[v1.CompareAndSwapResponse], error)
+	Increment(context.Context, *connect.Request[v1.IncrementRequest]) (*connect.Response[v1.IncrementResponse], error)
+	Decrement(context.Context, *connect.Request[v1.DecrementRequest]) (*connect.Response[v1.DecrementResponse], error)
+	Compact(context.Context, *connect.Request[v1.CompactRequest]) (*connect.Response[v1.CompactResponse], error)
+	// Export streams the pairs of a namespace, or of a prefix of it, in
+	// key order.
+	Export(context.Context, *connect.Request[v1.ExportRequest]) (*connect.ServerStreamForClient[v1.ExportResponse], error)
+	// Import writes the pairs it receives in batches, each applied
+	// atomically as a single raft entry. A failure leaves the batches
+	// applied before it in place.
+	Import(context.Context) *connect.ClientStreamForClient[v1.ImportRequest, v1.ImportResponse]
+	// Txn applies the operations of success if every comparison holds,
+	// and those of failure otherwise, atomically as a single raft entry.
+	Txn(context.Context, *connect.Request[v1.TxnRequest]) (*connect.Response[v1.TxnResponse], error)
+	// Watch streams the changes of a key, or of the keys starting with a
+	// prefix, after a revision, and then every later change until the
+	// call ends. It reads the local history, so followers serve it as
+	// well. A revision before the compaction point fails with
+	// OUT_OF_RANGE. Once the namespace is deleted, the call ends with
+	// NOT_FOUND after the deletions of its keys.
+	Watch(context.Context, *connect.Request[v1.WatchRequest]) (*connect.ServerStreamForClient[v1.WatchResponse], error)
 }
 
 // NewKVServiceClient constructs a client for the raftd.v1.KVService service. By default, it uses
@@ -84,14 +134,77 @@ func NewKVServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...c
 			connect.WithSchema(kVServiceDeleteMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		_range: connect.NewClient[v1.RangeRequest, v1.RangeResponse](
+			httpClient,
+			baseURL+KVServiceRangeProcedure,
+			connect.WithSchema(kVServiceRangeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		compareAndSwap: connect.NewClient[v1.CompareAndSwapRequest, v1.CompareAndSwapResponse](
+			httpClient,
+			baseURL+KVServiceCompareAndSwapProcedure,
+			connect.WithSchema(kVServiceCompareAndSwapMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		increment: connect.NewClient[v1.IncrementRequest, v1.IncrementResponse](
+			httpClient,
+			baseURL+KVServiceIncrementProcedure,
+			connect.WithSchema(kVServiceIncrementMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		decrement: connect.NewClient[v1.DecrementRequest, v1.DecrementResponse](
+			httpClient,
+			baseURL+KVServiceDecrementProcedure,
+			connect.WithSchema(kVServiceDecrementMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		compact: connect.NewClient[v1.CompactRequest, v1.CompactResponse](
+			httpClient,
+			baseURL+KVServiceCompactProcedure,
+			connect.WithSchema(kVServiceCompactMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		export: connect.NewClient[v1.ExportRequest, v1.ExportResponse](
+			httpClient,
+			baseURL+KVServiceExportProcedure,
+			connect.WithSchema(kVServiceExportMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		_import: connect.NewClient[v1.ImportRequest, v1.ImportResponse](
+			httpClient,
+			baseURL+KVServiceImportProcedure,
+			connect.WithSchema(kVServiceImportMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		txn: connect.NewClient[v1.TxnRequest, v1.TxnResponse](
+			httpClient,
+			baseURL+KVServiceTxnProcedure,
+			connect.WithSchema(kVServiceTxnMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		watch: connect.NewClient[v1.WatchRequest, v1.WatchResponse](
+			httpClient,
+			baseURL+KVServiceWatchProcedure,
+			connect.WithSchema(kVServiceWatchMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // kVServiceClient implements KVServiceClient.
 type kVServiceClient struct {
-	set    *connect.Client[v1.SetRequest, v1.SetResponse]
-	get    *connect.Client[v1.GetRequest, v1.GetResponse]
-	delete *connect.Client[v1.DeleteRequest, v1.DeleteResponse]
+	set            *connect.Client[v1.SetRequest, v1.SetResponse]
+	get            *connect.Client[v1.GetRequest, v1.GetResponse]
+	delete         *connect.Client[v1.DeleteRequest, v1.DeleteResponse]
+	_range         *connect.Client[v1.RangeRequest, v1.RangeResponse]
+	compareAndSwap *connect.Client[v1.CompareAndSwapRequest, v1.CompareAndSwapResponse]
+	increment      *connect.Client[v1.IncrementRequest, v1.IncrementResponse]
+	decrement      *connect.Client[v1.DecrementRequest, v1.DecrementResponse]
+	compact        *connect.Client[v1.CompactRequest, v1.CompactResponse]
+	export         *connect.Client[v1.ExportRequest, v1.ExportResponse]
+	_import        *connect.Client[v1.ImportRequest, v1.ImportResponse]
+	txn            *connect.Client[v1.TxnRequest, v1.TxnResponse]
+	watch          *connect.Client[v1.WatchRequest, v1.WatchResponse]
 }
 
 // Set calls raftd.v1.KVService.Set.
@@ -109,11 +222,78 @@ func (c *kVServiceClient) Delete(ctx context.Context, req *connect.Request[v1.De
 	return c.delete.CallUnary(ctx, req)
 }
 
+// Range calls raftd.v1.KVService.Range.
+func (c *kVServiceClient) Range(ctx context.Context, req *connect.Request[v1.RangeRequest]) (*connect.Response[v1.RangeResponse], error) {
+	return c._range.CallUnary(ctx, req)
+}
+
+// CompareAndSwap calls raftd.v1.KVService.CompareAndSwap.
+func (c *kVServiceClient) CompareAndSwap(ctx context.Context, req *connect.Request[v1.CompareAndSwapRequest]) (*connect.Response[v1.CompareAndSwapResponse], error) {
+	return c.compareAndSwap.CallUnary(ctx, req)
+}
+
+// Increment calls raftd.v1.KVService.Increment.
+func (c *kVServiceClient) Increment(ctx context.Context, req *connect.Request[v1.IncrementRequest]) (*connect.Response[v1.IncrementResponse], error) {
+	return c.increment.CallUnary(ctx, req)
+}
+
+// Decrement calls raftd.v1.KVService.Decrement.
+func (c *kVServiceClient) Decrement(ctx context.Context, req *connect.Request[v1.DecrementRequest]) (*connect.Response[v1.DecrementResponse], error) {
+	return c.decrement.CallUnary(ctx, req)
+}
+
+// Compact calls raftd.v1.KVService.Compact.
+func (c *kVServiceClient) Compact(ctx context.Context, req *connect.Request[v1.CompactRequest]) (*connect.Response[v1.CompactResponse], error) {
+	return c.compact.CallUnary(ctx, req)
+}
+
+// Export calls raftd.v1.KVService.Export.
+func (c *kVServiceClient) Export(ctx context.Context, req *connect.Request[v1.ExportRequest]) (*connect.ServerStreamForClient[v1.ExportResponse], error) {
+	return c.export.CallServerStream(ctx, req)
+}
+
+// Import calls raftd.v1.KVService.Import.
+func (c *kVServiceClient) Import(ctx context.Context) *connect.ClientStreamForClient[v1.ImportRequest, v1.ImportResponse] {
+	return c._import.CallClientStream(ctx)
+}
+
+// Txn calls raftd.v1.KVService.Txn.
+func (c *kVServiceClient) Txn(ctx context.Context, req *connect.Request[v1.TxnRequest]) (*connect.Response[v1.TxnResponse], error) {
+	return c.txn.CallUnary(ctx, req)
+}
+
+// Watch calls raftd.v1.KVService.Watch.
+func (c *kVServiceClient) Watch(ctx context.Context, req *connect.Request[v1.WatchRequest]) (*connect.ServerStreamForClient[v1.WatchResponse], error) {
+	return c.watch.CallServerStream(ctx, req)
+}
+
 // KVServiceHandler is an implementation of the raftd.v1.KVService service.
 type KVServiceHandler interface {
 	Set(context.Context, *connect.Request[v1.SetRequest]) (*connect.Response[v1.SetResponse], error)
 	Get(context.Context, *connect.Request[v1.GetRequest]) (*connect.Response[v1.GetResponse], error)
 	Delete(context.Context, *connect.Request[v1.DeleteRequest]) (*connect.Response[v1.DeleteResponse], error)
+	Range(context.Context, *connect.Request[v1.RangeRequest]) (*connect.Response[v1.RangeResponse], error)
+	CompareAndSwap(context.Context, *connect.Request[v1.CompareAndSwapRequest]) (*connect.Response[v1.CompareAndSwapResponse], error)
+	Increment(context.Context, *connect.Request[v1.IncrementRequest]) (*connect.Response[v1.IncrementResponse], error)
+	Decrement(context.Context, *connect.Request[v1.DecrementRequest]) (*connect.Response[v1.DecrementResponse], error)
+	Compact(context.Context, *connect.Request[v1.CompactRequest]) (*connect.Response[v1.CompactResponse], error)
+	// Export streams the pairs of a namespace, or of a prefix of it, in
+	// key order.
+	Export(context.Context, *connect.Request[v1.ExportRequest], *connect.ServerStream[v1.ExportResponse]) error
+	// Import writes the pairs it receives in batches, each applied
+	// atomically as a single raft entry. A failure leaves the batches
+	// applied before it in place.
+	Import(context.Context, *connect.ClientStream[v1.ImportRequest]) (*connect.Response[v1.ImportResponse], error)
+	// Txn applies the operations of success if every comparison holds,
+	// and those of failure otherwise, atomically as a single raft entry.
+	Txn(context.Context, *connect.Request[v1.TxnRequest]) (*connect.Response[v1.TxnResponse], error)
+	// Watch streams the changes of a key, or of the keys starting with a
+	// prefix, after a revision, and then every later change until the
+	// call ends. It reads the local history, so followers serve it as
+	// well. A revision before the compaction point fails with
+	// OUT_OF_RANGE. Once the namespace is deleted, the call ends with
+	// NOT_FOUND after the deletions of its keys.
+	Watch(context.Context, *connect.Request[v1.WatchRequest], *connect.ServerStream[v1.WatchResponse]) error
 }
 
 // NewKVServiceHandler builds an HTTP handler from the service implementation. It returns the path
@@ -140,6 +320,60 @@ func NewKVServiceHandler(svc KVServiceHandler, opts ...connect.HandlerOption) (s
 		connect.WithSchema(kVServiceDeleteMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	kVServiceRangeHandler := connect.NewUnaryHandler(
+		KVServiceRangeProcedure,
+		svc.Range,
+		connect.WithSchema(kVServiceRangeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	kVServiceCompareAndSwapHandler := connect.NewUnaryHandler(
+		KVServiceCompareAndSwapProcedure,
+		svc.CompareAndSwap,
+		connect.WithSchema(kVServiceCompareAndSwapMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	kVServiceIncrementHandler := connect.NewUnaryHandler(
+		KVServiceIncrementProcedure,
+		svc.Increment,
+		connect.WithSchema(kVServiceIncrementMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	kVServiceDecrementHandler := connect.NewUnaryHandler(
+		KVServiceDecrementProcedure,
+		svc.Decrement,
+		connect.WithSchema(kVServiceDecrementMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	kVServiceCompactHandler := connect.NewUnaryHandler(
+		KVServiceCompactProcedure,
+		svc.Compact,
+		connect.WithSchema(kVServiceCompactMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	kVServiceExportHandler := connect.NewServerStreamHandler(
+		KVServiceExportProcedure,
+		svc.Export,
+		connect.WithSchema(kVServiceExportMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	kVServiceImportHandler := connect.NewClientStreamHandler(
+		KVServiceImportProcedure,
+		svc.Import,
+		connect.WithSchema(kVServiceImportMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	kVServiceTxnHandler := connect.NewUnaryHandler(
+		KVServiceTxnProcedure,
+		svc.Txn,
+		connect.WithSchema(kVServiceTxnMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	kVServiceWatchHandler := connect.NewServerStreamHandler(
+		KVServiceWatchProcedure,
+		svc.Watch,
+		connect.WithSchema(kVServiceWatchMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/raftd.v1.KVService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case KVServiceSetProcedure:
@@ -148,6 +382,24 @@ func NewKVServiceHandler(svc KVServiceHandler, opts ...connect.HandlerOption) (s
 			kVServiceGetHandler.ServeHTTP(w, r)
 		case KVServiceDeleteProcedure:
 			kVServiceDeleteHandler.ServeHTTP(w, r)
+		case KVServiceRangeProcedure:
+			kVServiceRangeHandler.ServeHTTP(w, r)
+		case KVServiceCompareAndSwapProcedure:
+			kVServiceCompareAndSwapHandler.ServeHTTP(w, r)
+		case KVServiceIncrementProcedure:
+			kVServiceIncrementHandler.ServeHTTP(w, r)
+		case KVServiceDecrementProcedure:
+			kVServiceDecrementHandler.ServeHTTP(w, r)
+		case KVServiceCompactProcedure:
+			kVServiceCompactHandler.ServeHTTP(w, r)
+		case KVServiceExportProcedure:
+			kVServiceExportHandler.ServeHTTP(w, r)
+		case KVServiceImportProcedure:
+			kVServiceImportHandler.ServeHTTP(w, r)
+		case KVServiceTxnProcedure:
+			kVServiceTxnHandler.ServeHTTP(w, r)
+		case KVServiceWatchProcedure:
+			kVServiceWatchHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -168,3 +420,39 @@ func (UnimplementedKVServiceHandler) Get(context.Context, *connect.Request[v1.Ge
 func (UnimplementedKVServiceHandler) Delete(context.Context, *connect.Request[v1.DeleteRequest]) (*connect.Response[v1.DeleteResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.KVService.Delete is not implemented"))
 }
+
+func (UnimplementedKVServiceHandler) Range(context.Context, *connect.Request[v1.RangeRequest]) (*connect.Response[v1.RangeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.KVService.Range is not implemented"))
+}
+
+func (UnimplementedKVServiceHandler) CompareAndSwap(context.Context, *connect.Request[v1.CompareAndSwapRequest]) (*connect.Response[v1.CompareAndSwapResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.KVService.CompareAndSwap is not implemented"))
+}
+
+func (UnimplementedKVServiceHandler) Increment(context.Context, *connect.Request[v1.IncrementRequest]) (*connect.Response[v1.IncrementResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.KVService.Increment is not implemented"))
+}
+
+func (UnimplementedKVServiceHandler) Decrement(context.Context, *connect.Request[v1.DecrementRequest]) (*connect.Response[v1.DecrementResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.KVService.Decrement is not implemented"))
+}
+
+func (UnimplementedKVServiceHandler) Compact(context.Context, *connect.Request[v1.CompactRequest]) (*connect.Response[v1.CompactResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.KVService.Compact is not implemented"))
+}
+
+func (UnimplementedKVServiceHandler) Export(context.Context, *connect.Request[v1.ExportRequest], *connect.ServerStream[v1.ExportResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.KVService.Export is not implemented"))
+}
+
+func (UnimplementedKVServiceHandler) Import(context.Context, *connect.ClientStream[v1.ImportRequest]) (*connect.Response[v1.ImportResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.KVService.Import is not implemented"))
+}
+
+func (UnimplementedKVServiceHandler) Txn(context.Context, *connect.Request[v1.TxnRequest]) (*connect.Response[v1.TxnResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.KVService.Txn is not implemented"))
+}
+
+func (UnimplementedKVServiceHandler) Watch(context.Context, *connect.Request[v1.WatchRequest], *connect.ServerStream[v1.WatchResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("raftd.v1.KVService.Watch is not implemented"))
+}
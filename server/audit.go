@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+const (
+	// DefaultAuditMaxSize is the default of Config.AuditMaxSize.
+	DefaultAuditMaxSize = 100 << 20
+
+	// DefaultAuditMaxFiles is the default of Config.AuditMaxFiles.
+	DefaultAuditMaxFiles = 10
+)
+
+// auditLog is the replicated audit log of the FSM, in index order. The
+// leader decides whether commands are recorded, see Command.audit, so every
+// node agrees on it; compacting the history drops the records up to the
+// compaction point.
+//
+// It is read by TailAudit, so it is guarded by a mutex, and it signals
+// every change to the calls following it.
+type auditLog struct {
+	mu      sync.Mutex
+	records []*raftdv1.AuditRecord
+	changed chan struct{}
+}
+
+func newAuditLog() *auditLog {
+	return &auditLog{changed: make(chan struct{})}
+}
+
+// notify wakes up the calls following the log. It must be called with mu
+// held.
+func (a *auditLog) notify() {
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+func (a *auditLog) append(record *raftdv1.AuditRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.records = append(a.records, record)
+	a.notify()
+}
+
+// trim drops the records at or below the compaction point.
+func (a *auditLog) trim(compacted uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	i := sort.Search(len(a.records), func(i int) bool {
+		return a.records[i].Index > compacted
+	})
+	a.records = append([]*raftdv1.AuditRecord(nil), a.records[i:]...)
+}
+
+// tail returns the records after index after, only the last ones of them
+// when last is positive, along with a channel closed on the next change.
+func (a *auditLog) tail(after uint64, last int) ([]*raftdv1.AuditRecord, <-chan struct{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	i := sort.Search(len(a.records), func(i int) bool {
+		return a.records[i].Index > after
+	})
+	records := a.records[i:]
+	if last > 0 && len(records) > last {
+		records = records[len(records)-last:]
+	}
+	return append([]*raftdv1.AuditRecord(nil), records...), a.changed
+}
+
+// list returns every record, for snapshots.
+func (a *auditLog) list() []*raftdv1.AuditRecord {
+	records, _ := a.tail(0, 0)
+	return records
+}
+
+// replace swaps in the records restored from a snapshot.
+func (a *auditLog) replace(records []*raftdv1.AuditRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.records = records
+	a.notify()
+}
+
+// auditFile appends the audit records of a node to a JSONL rollingFile.
+//
+// Every node writes the commands as it applies them, so each keeps its own
+// file. The entries applied again on restart are skipped, but those a node
+// receives through a snapshot are missing from its file.
+type auditFile struct {
+	file   *rollingFile
+	logger *slog.Logger
+	// last is the index of the last record written.
+	last uint64
+}
+
+func openAuditFile(path string, maxSize int64, maxFiles int, logger *slog.Logger) (*auditFile, error) {
+	a := &auditFile{logger: logger}
+
+	// The last record is in the rotated file if the current one is empty.
+	for _, name := range []string{path + ".1", path} {
+		last, err := lastAuditIndex(name)
+		if err != nil {
+			return nil, err
+		}
+		a.last = max(a.last, last)
+	}
+
+	file, err := openRollingFile(path, maxSize, maxFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	a.file = file
+	return a, nil
+}
+
+// lastAuditIndex returns the index of the last record of an audit file, or
+// zero if it does not exist. A record cut short by a crash ends the file.
+func lastAuditIndex(name string) (uint64, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var last uint64
+	dec := json.NewDecoder(f)
+	for {
+		var record raftdv1.AuditRecord
+		if err := dec.Decode(&record); err != nil {
+			return last, nil
+		}
+		last = record.Index
+	}
+}
+
+// write appends the record unless it was written before. Since the FSM
+// cannot fail, errors are logged.
+func (a *auditFile) write(record *raftdv1.AuditRecord) {
+	if record.Index <= a.last {
+		return
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		a.logger.Error("failed to encode audit record", "index", record.Index, "error", err)
+		return
+	}
+	if err := a.file.write(append(line, '\n')); err != nil {
+		a.logger.Error("failed to write audit record", "index", record.Index, "error", err)
+		return
+	}
+	a.last = record.Index
+}
+
+func (a *auditFile) Close() error {
+	if a == nil {
+		return nil
+	}
+	return a.file.Close()
+}
+
+// caller returns the principal and address of the client of a call: the
+// subject of its verified TLS certificate, if any, and its peer address.
+func caller(ctx context.Context) (principal, addr string) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", ""
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+		principal = info.State.VerifiedChains[0][0].Subject.String()
+	}
+	if p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	return principal, addr
+}
+
+// TailAudit implements raftdv1.AuditServiceServer. It reads the local audit
+// log, so followers serve it as well, lagging behind the leader by their
+// replication delay.
+func (s *Raftd) TailAudit(req *raftdv1.TailAuditRequest, stream grpc.ServerStreamingServer[raftdv1.AuditRecord]) error {
+	if req.Last < 0 {
+		return status.Errorf(codes.InvalidArgument, "last must not be negative")
+	}
+
+	ctx := stream.Context()
+	after, last := req.AfterIndex, int(req.Last)
+	for {
+		records, changed := s.fsm.audit.tail(after, last)
+		for _, record := range records {
+			if err := stream.Send(record); err != nil {
+				return err
+			}
+			after = record.Index
+		}
+		if !req.Follow {
+			return nil
+		}
+		last = 0
+
+		select {
+		case <-changed:
+		case <-s.shutdown:
+			return status.Errorf(codes.Unavailable, "server is shutting down")
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+const (
+	// changeBatchSize bounds the changes delivered at once.
+	changeBatchSize = 1000
+
+	// changeDeliveryInterval is how often the leader delivers the new
+	// changes to its sinks.
+	changeDeliveryInterval = 100 * time.Millisecond
+
+	// changeDeliveryTimeout bounds the delivery of a batch to a sink.
+	changeDeliveryTimeout = 10 * time.Second
+)
+
+// DefaultChangeConsumerRetention is the default of
+// Config.ChangeConsumerRetention.
+const DefaultChangeConsumerRetention = 7 * 24 * time.Hour
+
+// ChangeSink receives the changes of every key, see Config.ChangeSinks.
+type ChangeSink interface {
+	// Deliver delivers a batch of changes in revision order. Until it
+	// succeeds, the same changes are delivered again, possibly along with
+	// later ones and by the next leader.
+	Deliver(ctx context.Context, changes []*raftdv1.Change) error
+	Close() error
+}
+
+// changeFeed is the table of delivery checkpoints of the FSM: the last
+// revision acknowledged by every sink and consumer of ChangeService.
+//
+// It is read by the delivery tasks and RPC handlers, so it is guarded by a
+// mutex, and it signals every change of the keys and checkpoints to the
+// calls streaming changes.
+type changeFeed struct {
+	mu          sync.Mutex
+	checkpoints map[string]consumerCheckpoint
+	changed     chan struct{}
+}
+
+// consumerCheckpoint is the last revision a sink or consumer acknowledged,
+// and when, in the leader's clock.
+type consumerCheckpoint struct {
+	revision uint64
+	ackedAt  int64
+}
+
+func newChangeFeed() *changeFeed {
+	return &changeFeed{
+		checkpoints: make(map[string]consumerCheckpoint),
+		changed:     make(chan struct{}),
+	}
+}
+
+// notify wakes up the calls streaming changes.
+func (c *changeFeed) notify() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// checkpoint returns the checkpoint of a consumer, along with a channel
+// closed on the next change.
+func (c *changeFeed) checkpoint(consumer string) (uint64, <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checkpoints[consumer].revision, c.changed
+}
+
+// next returns a channel closed on the next change.
+func (c *changeFeed) next() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.changed
+}
+
+// ack moves the checkpoint of a consumer forward to rev, acknowledged at
+// appliedAt. Checkpoints never move back.
+func (c *changeFeed) ack(consumer string, rev uint64, appliedAt int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp := c.checkpoints[consumer]
+	c.checkpoints[consumer] = consumerCheckpoint{
+		revision: max(cp.revision, rev),
+		ackedAt:  max(cp.ackedAt, appliedAt),
+	}
+}
+
+// remove drops the checkpoint of a consumer, and reports whether it had
+// one.
+func (c *changeFeed) remove(consumer string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.checkpoints[consumer]; !ok {
+		return false
+	}
+	delete(c.checkpoints, consumer)
+	return true
+}
+
+// list returns every checkpoint, for snapshots.
+func (c *changeFeed) list() map[string]consumerCheckpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	checkpoints := make(map[string]consumerCheckpoint, len(c.checkpoints))
+	for consumer, cp := range c.checkpoints {
+		checkpoints[consumer] = cp
+	}
+	return checkpoints
+}
+
+// replace swaps in the checkpoints restored from a snapshot.
+func (c *changeFeed) replace(checkpoints map[string]consumerCheckpoint) {
+	c.mu.Lock()
+	c.checkpoints = checkpoints
+	c.mu.Unlock()
+	c.notify()
+}
+
+// deliverChanges returns the leader task delivering the changes after the
+// checkpoint of a sink and then acknowledging them.
+func (s *Raftd) deliverChanges(name string, sink ChangeSink) func(leaderSince, now time.Time) {
+	return func(_, _ time.Time) {
+		checkpoint, _ := s.fsm.changes.checkpoint(name)
+		changes := s.fsm.namespaces.changes(checkpoint, changeBatchSize)
+		if len(changes) == 0 {
+			return
+		}
+		if compacted := s.fsm.namespaces.compactRevision(); checkpoint < compacted {
+			s.logger.Warn("changes were compacted before their delivery", "sink", name, "checkpoint", checkpoint, "compacted", compacted)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), changeDeliveryTimeout)
+		defer cancel()
+
+		last := changes[len(changes)-1].Revision
+		if err := sink.Deliver(ctx, changes); err != nil {
+			s.logger.Warn("failed to deliver changes", "sink", name, "from", changes[0].Revision, "to", last, "error", err)
+			return
+		}
+		if _, err := s.apply(ctx, &raftdv1.Command{Op: "changes_ack", Key: name, Revision: last}); err != nil {
+			s.logger.Warn("failed to acknowledge changes", "sink", name, "revision", last, "error", err)
+		}
+	}
+}
+
+// changeCheckpoint returns the lowest checkpoint of the sinks of the node
+// and of the consumers of ChangeService that acknowledged changes within
+// the retention, which compaction must not go past, and false without any.
+// The checkpoints of the sinks of other nodes count as those of consumers,
+// so that a removed sink does not hold the history forever.
+func (s *Raftd) changeCheckpoint(now time.Time) (uint64, bool) {
+	var lowest uint64
+	found := false
+	keep := func(checkpoint uint64) {
+		if !found || checkpoint < lowest {
+			lowest, found = checkpoint, true
+		}
+	}
+
+	checkpoints := s.fsm.changes.list()
+	for name := range s.changeSinks {
+		// A sink that acknowledged nothing yet waits for every change.
+		keep(checkpoints[name].revision)
+	}
+	for name, cp := range checkpoints {
+		if _, ok := s.changeSinks[name]; ok {
+			continue
+		}
+		if now.Sub(time.Unix(0, cp.ackedAt)) > s.changeRetention {
+			continue
+		}
+		keep(cp.revision)
+	}
+	return lowest, found
+}
+
+// SubscribeChanges implements raftdv1.ChangeServiceServer.
+func (s *Raftd) SubscribeChanges(req *raftdv1.SubscribeChangesRequest, stream grpc.ServerStreamingServer[raftdv1.SubscribeChangesResponse]) error {
+	if req.Consumer == "" {
+		return status.Errorf(codes.InvalidArgument, "consumer is required")
+	}
+
+	ctx := stream.Context()
+	after, _ := s.fsm.changes.checkpoint(req.Consumer)
+	for {
+		// Take the channel before reading, so that a change applied in
+		// between is not missed.
+		_, changed := s.fsm.changes.checkpoint(req.Consumer)
+
+		if compacted := s.fsm.namespaces.compactRevision(); after < compacted {
+			return status.Errorf(codes.OutOfRange, "changes after revision %d are compacted, ack revision %d or later to resume", after, compacted)
+		}
+
+		changes := s.fsm.namespaces.changes(after, changeBatchSize)
+		if len(changes) > 0 {
+			if err := stream.Send(&raftdv1.SubscribeChangesResponse{Changes: changes}); err != nil {
+				return err
+			}
+			after = changes[len(changes)-1].Revision
+			continue
+		}
+
+		select {
+		case <-changed:
+		case <-s.shutdown:
+			return status.Errorf(codes.Unavailable, "server is shutting down")
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// AckChanges implements raftdv1.ChangeServiceServer.
+func (s *Raftd) AckChanges(ctx context.Context, req *raftdv1.AckChangesRequest) (*raftdv1.AckChangesResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	if req.Consumer == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "consumer is required")
+	}
+
+	if _, err := s.apply(ctx, &raftdv1.Command{Op: "changes_ack", Key: req.Consumer, Revision: req.Revision}); err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.AckChangesResponse{}, nil
+}
+
+// RemoveChangeConsumer implements raftdv1.ChangeServiceServer.
+func (s *Raftd) RemoveChangeConsumer(ctx context.Context, req *raftdv1.RemoveChangeConsumerRequest) (*raftdv1.RemoveChangeConsumerResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	if req.Consumer == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "consumer is required")
+	}
+
+	if _, err := s.apply(ctx, &raftdv1.Command{Op: "changes_remove", Key: req.Consumer}); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("removed change consumer", "consumer", req.Consumer)
+	return &raftdv1.RemoveChangeConsumerResponse{}, nil
+}
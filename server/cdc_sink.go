@@ -0,0 +1,154 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// The formats of FileSink.
+const (
+	// ChangeFormatJSON writes a change per line as JSON.
+	ChangeFormatJSON = "jsonl"
+
+	// ChangeFormatProto writes varint length-delimited Change messages.
+	ChangeFormatProto = "proto"
+)
+
+const (
+	// DefaultChangeFileMaxSize is the default maximum size of a FileSink.
+	DefaultChangeFileMaxSize = 100 << 20
+
+	// DefaultChangeFileMaxFiles is the default number of rotated files a
+	// FileSink keeps.
+	DefaultChangeFileMaxFiles = 10
+)
+
+// FileSink appends the changes to a file of the leader, rotated once it
+// reaches maxSize bytes: the file is renamed path.1, the previous path.1
+// renamed path.2 and so on, keeping maxFiles rotated files. A batch is
+// synced to disk before it is acknowledged.
+//
+// Every node writes to its own file while it is the leader, so the changes
+// are spread over the files of the nodes that led the cluster, and a batch
+// a leader wrote without acknowledging it is written again by the next.
+type FileSink struct {
+	format string
+
+	mu   sync.Mutex
+	file *rollingFile
+}
+
+var _ ChangeSink = (*FileSink)(nil)
+
+// NewFileSink opens the file of a FileSink. DefaultChangeFileMaxSize and
+// DefaultChangeFileMaxFiles are used when maxSize and maxFiles are zero.
+func NewFileSink(path, format string, maxSize int64, maxFiles int) (*FileSink, error) {
+	if format != ChangeFormatJSON && format != ChangeFormatProto {
+		return nil, fmt.Errorf("unknown change format %q", format)
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultChangeFileMaxSize
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultChangeFileMaxFiles
+	}
+
+	file, err := openRollingFile(path, maxSize, maxFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open change file: %w", err)
+	}
+	return &FileSink{format: format, file: file}, nil
+}
+
+// Deliver implements ChangeSink.
+func (s *FileSink) Deliver(_ context.Context, changes []*raftdv1.Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, change := range changes {
+		buf.Reset()
+		switch s.format {
+		case ChangeFormatProto:
+			if _, err := protodelim.MarshalTo(&buf, change); err != nil {
+				return err
+			}
+		default:
+			if err := json.NewEncoder(&buf).Encode(change); err != nil {
+				return err
+			}
+		}
+		if err := s.file.write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return s.file.sync()
+}
+
+// Close implements ChangeSink.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// WebhookSink posts every batch of changes to a URL, as a JSON
+// SubscribeChangesResponse. A batch is delivered once the URL answers with
+// a 2xx status.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+var _ ChangeSink = (*WebhookSink)(nil)
+
+// NewWebhookSink returns a WebhookSink posting to url with client, or with
+// http.DefaultClient when nil.
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookSink{url: url, client: client}
+}
+
+// Deliver implements ChangeSink.
+func (s *WebhookSink) Deliver(ctx context.Context, changes []*raftdv1.Change) error {
+	body, err := json.Marshal(&raftdv1.SubscribeChangesResponse{Changes: changes})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Close implements ChangeSink.
+func (s *WebhookSink) Close() error {
+	return nil
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// DefaultCompactionInterval is the default of Config.CompactionInterval.
+const DefaultCompactionInterval = 5 * time.Minute
+
+// checkpointInterval is how far apart in the leader's clock the timeline
+// records revisions.
+const checkpointInterval = time.Second
+
+// compactionPolicy is the history retention of Config.
+type compactionPolicy struct {
+	revisions uint64
+	age       time.Duration
+}
+
+// timeline maps revisions to the leader's clock, so that the history can be
+// compacted by age. It records a revision every checkpointInterval at
+// most, and forgets those at or below the compaction point.
+//
+// It is read by the leader's compaction task, so it is guarded by a mutex.
+type timeline struct {
+	mu          sync.Mutex
+	checkpoints []checkpoint
+}
+
+// checkpoint records that revision was appended at appliedAt.
+type checkpoint struct {
+	revision  uint64
+	appliedAt int64
+}
+
+func (t *timeline) record(revision uint64, appliedAt int64) {
+	if appliedAt == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if n := len(t.checkpoints); n > 0 && appliedAt-t.checkpoints[n-1].appliedAt < int64(checkpointInterval) {
+		return
+	}
+	t.checkpoints = append(t.checkpoints, checkpoint{revision: revision, appliedAt: appliedAt})
+}
+
+// before returns the last revision recorded at or before t, or zero.
+func (t *timeline) before(appliedAt int64) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := sort.Search(len(t.checkpoints), func(i int) bool {
+		return t.checkpoints[i].appliedAt > appliedAt
+	})
+	if i == 0 {
+		return 0
+	}
+	return t.checkpoints[i-1].revision
+}
+
+// trim forgets the revisions at or below the compaction point.
+func (t *timeline) trim(compacted uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := sort.Search(len(t.checkpoints), func(i int) bool {
+		return t.checkpoints[i].revision > compacted
+	})
+	t.checkpoints = append([]checkpoint(nil), t.checkpoints[i:]...)
+}
+
+// list returns every checkpoint, for snapshots.
+func (t *timeline) list() []checkpoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]checkpoint(nil), t.checkpoints...)
+}
+
+// replace swaps in the checkpoints restored from a snapshot.
+func (t *timeline) replace(checkpoints []checkpoint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.checkpoints = checkpoints
+}
+
+// Compact implements raftdv1.KVServiceServer.
+func (s *Raftd) Compact(ctx context.Context, req *raftdv1.CompactRequest) (*raftdv1.CompactResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	if req.Revision == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "revision is required")
+	}
+	if checkpoint, ok := s.changeCheckpoint(time.Now()); ok && req.Revision > checkpoint {
+		return nil, status.Errorf(codes.FailedPrecondition, "changes after revision %d are not acknowledged by every sink and consumer yet", checkpoint)
+	}
+
+	result, err := s.apply(ctx, &raftdv1.Command{
+		Op:       "compact",
+		Revision: req.Revision,
+		ClientId: req.Session.GetClientId(),
+		Sequence: req.Session.GetSequence(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Code == raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED {
+		return nil, status.Errorf(codes.FailedPrecondition, "revision %d is already compacted", req.Revision)
+	}
+
+	s.logger.Info("compacted history", "revision", req.Revision)
+	return &raftdv1.CompactResponse{Revision: result.Revision}, nil
+}
+
+// autoCompact proposes compacting the history the retention policy no
+// longer asks for. With both policies, history is kept as long as either
+// asks for it, and in any case until the change sinks and consumers
+// received it.
+func (s *Raftd) autoCompact(_, now time.Time) {
+	var target uint64
+	if s.compaction.revisions > 0 {
+		applied := s.raftEngine.AppliedIndex()
+		if applied <= s.compaction.revisions {
+			return
+		}
+		target = applied - s.compaction.revisions
+	}
+	if s.compaction.age > 0 {
+		rev := s.fsm.timeline.before(now.Add(-s.compaction.age).UnixNano())
+		if target == 0 || rev < target {
+			target = rev
+		}
+	}
+	if checkpoint, ok := s.changeCheckpoint(now); ok {
+		target = min(target, checkpoint)
+	}
+	if target <= s.fsm.namespaces.compactRevision() {
+		return
+	}
+
+	result, err := s.apply(context.Background(), &raftdv1.Command{Op: "compact", Revision: target})
+	if err != nil {
+		s.logger.Warn("failed to compact history", "revision", target, "error", err)
+		return
+	}
+	if result.Code == raftdv1.ApplyCode_APPLY_CODE_OK {
+		s.logger.Debug("compacted history", "revision", target)
+	}
+}
@@ -1,92 +1,703 @@
 package server
 
 import (
+	"bytes"
+	"cmp"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math"
+	"slices"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/hashicorp/raft"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
 	"github.com/amjadjibon/raftd/store"
 )
 
 type FSM struct {
-	store *store.Store
+	namespaces *namespaces
+	sessions   *sessions
+	leases     *leases
+	queues     *queues
+	timeline   *timeline
+	audit      *auditLog
+	changes    *changeFeed
+
+	// auditFile, when set, records the commands applied on this node.
+	auditFile *auditFile
+
+	// revision is the index of the last entry applied, up to which reads
+	// at a revision are answered.
+	revision atomic.Uint64
+
+	// alarm is set while writes are rejected because the storage quota
+	// was exceeded, see Raftd.checkStorage.
+	alarm atomic.Bool
 }
 
 var _ raft.FSM = (*FSM)(nil)
 
+// NewFSM returns an FSM whose default namespace is kept in store.
 func NewFSM(store *store.Store) *FSM {
-	return &FSM{store: store}
+	return &FSM{
+		namespaces: newNamespaces(store),
+		sessions:   newSessions(),
+		leases:     newLeases(),
+		queues:     newQueues(),
+		timeline:   &timeline{},
+		audit:      newAuditLog(),
+		changes:    newChangeFeed(),
+	}
 }
 
-// Apply implements raft.FSM.
+// Apply implements raft.FSM. Commands return an *raftdv1.ApplyResult. A
+// command that cannot be applied is rejected the same way on every node
+// and leaves the store untouched. A command carrying a client ID and
+// sequence that was applied before returns the earlier result.
 func (f *FSM) Apply(raftLog *raft.Log) interface{} {
-	switch raftLog.Type {
-	case raft.LogCommand:
-		var c raftdv1.Command
-		if err := json.Unmarshal(raftLog.Data, &c); err != nil {
-			return err
+	if raftLog.Type != raft.LogCommand {
+		return nil
+	}
+	defer f.revision.Store(raftLog.Index)
+
+	var appliedAt int64
+	if !raftLog.AppendedAt.IsZero() {
+		appliedAt = raftLog.AppendedAt.UnixNano()
+	}
+	f.sessions.prune(raftLog.Index, appliedAt)
+	f.timeline.record(raftLog.Index, appliedAt)
+
+	var c raftdv1.Command
+	if err := json.Unmarshal(raftLog.Data, &c); err != nil {
+		return &raftdv1.ApplyResult{
+			Code:     raftdv1.ApplyCode_APPLY_CODE_INVALID_COMMAND,
+			Message:  err.Error(),
+			Revision: raftLog.Index,
+		}
+	}
+
+	if len(raftLog.Extensions) > 0 {
+		_, span := tracer.Start(traceContext(raftLog.Extensions), "fsm.Apply", trace.WithAttributes(
+			attribute.String("raftd.op", c.Op),
+			attribute.Int64("raft.index", int64(raftLog.Index)),
+		))
+		defer span.End()
+	}
+
+	dedup := c.ClientId != "" && c.Sequence != 0
+	if dedup {
+		if result := f.sessions.get(c.ClientId, c.Sequence, appliedAt); result != nil {
+			return result
+		}
+	}
+
+	result := f.applyCommand(&c, raftLog.Index, appliedAt)
+	f.record(raftLog, &c, result, appliedAt)
+	if dedup {
+		f.sessions.put(c.ClientId, c.Sequence, result, appliedAt)
+	}
+	return result
+}
+
+// record adds the command to the audit file of the node and, if the leader
+// asked for it, to the replicated audit log.
+func (f *FSM) record(raftLog *raft.Log, c *raftdv1.Command, result *raftdv1.ApplyResult, appliedAt int64) {
+	if !c.Audit && f.auditFile == nil {
+		return
+	}
+
+	record := &raftdv1.AuditRecord{
+		Index:      raftLog.Index,
+		Term:       raftLog.Term,
+		Time:       appliedAt,
+		Op:         c.Op,
+		Namespace:  c.Namespace,
+		Key:        c.Key,
+		Keys:       writtenKeys(c, result),
+		Principal:  c.Principal,
+		ClientAddr: c.ClientAddr,
+		Code:       result.Code,
+	}
+	if c.Audit {
+		f.audit.append(record)
+	}
+	if f.auditFile != nil {
+		f.auditFile.write(record)
+	}
+}
+
+// writtenKeys returns the keys the commands that write several set or
+// delete, or would have if they failed: those of an "import", and those of
+// the branch of a "txn" its comparisons chose.
+func writtenKeys(c *raftdv1.Command, result *raftdv1.ApplyResult) []string {
+	var keys []string
+	switch c.Op {
+	case "import":
+		seen := make(map[string]bool, len(c.Kvs))
+		for _, pair := range c.Kvs {
+			if !seen[pair.Key] {
+				seen[pair.Key] = true
+				keys = append(keys, pair.Key)
+			}
+		}
+	case "txn":
+		ops := c.Success
+		if !result.Succeeded {
+			ops = c.Failure
+		}
+		for _, op := range ops {
+			keys = append(keys, op.Key)
+		}
+	}
+	return keys
+}
+
+func (f *FSM) applyCommand(c *raftdv1.Command, index uint64, appliedAt int64) *raftdv1.ApplyResult {
+	result := &raftdv1.ApplyResult{
+		Code:     raftdv1.ApplyCode_APPLY_CODE_OK,
+		Revision: index,
+	}
+	switch c.Op {
+	case "set", "del", "cas", "incr":
+		f.applyKeyCommand(c, index, result)
+		f.changes.notify()
+	case "import":
+		f.applyImport(c, index, result)
+		f.changes.notify()
+	case "txn":
+		f.applyTxn(c, index, result)
+		f.changes.notify()
+	case "changes_ack":
+		if c.Revision >= index {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_OUT_OF_RANGE
+			result.Message = fmt.Sprintf("revision %d is not applied yet", c.Revision)
+			break
 		}
-		switch c.Op {
-		case "set":
-			return f.store.Set(c.Key, c.Value)
-		case "del":
-			return f.store.Delete(c.Key)
+		f.changes.ack(c.Key, c.Revision, appliedAt)
+	case "changes_remove":
+		if !f.changes.remove(c.Key) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND
+			result.Message = "consumer not found"
+		}
+	case "compact":
+		switch {
+		case c.Revision >= index:
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_OUT_OF_RANGE
+			result.Message = fmt.Sprintf("revision %d is not applied yet", c.Revision)
+		case c.Revision <= f.namespaces.compactRevision():
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED
+		default:
+			f.namespaces.compact(c.Revision)
+			f.timeline.trim(c.Revision)
+			f.audit.trim(c.Revision)
+		}
+	case "ns_create":
+		if !f.namespaces.create(c.Namespace, c.Quota) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_ALREADY_EXISTS
+			result.Message = "namespace already exists"
+		}
+	case "ns_update":
+		if !f.namespaces.update(c.Namespace, c.Quota) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND
+			result.Message = "namespace not found"
+		}
+	case "ns_delete":
+		if !f.namespaces.delete(c.Namespace, index) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND
+			result.Message = "namespace not found"
+			break
+		}
+		f.changes.notify()
+	case "alarm_raise":
+		f.alarm.Store(true)
+	case "alarm_clear":
+		f.alarm.Store(false)
+	case "lease_grant":
+		f.leases.grant(index, c.Ttl, appliedAt)
+	case "lease_keepalive":
+		if !f.leases.keepAlive(c.LeaseId, appliedAt) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND
+			result.Message = "lease not found"
+		}
+	case "lease_revoke":
+		if !f.leases.revoke(c.LeaseId) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND
+			result.Message = "lease not found"
+		}
+	case "lease_expire":
+		// The lease may have been kept alive since the leader proposed
+		// this, so the deadline is checked again.
+		if !f.leases.expire(c.LeaseId, appliedAt) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED
+		}
+	case "lock_acquire":
+		holder, ok := f.leases.acquire(c.Key, c.LeaseId, index, c.Value)
+		switch {
+		case !ok:
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND
+			result.Message = "lease not found"
+		case holder.LeaseId != c.LeaseId:
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED
+		default:
+			result.Token = holder.Token
+		}
+	case "lock_release":
+		if !f.leases.release(c.Key, c.LeaseId) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED
+		}
+	case "queue_enqueue":
+		f.queues.enqueue(c.Key, index, c.Value)
+	case "queue_dequeue":
+		result.QueueMessage = f.queues.dequeue(c.Key, index, deadline(appliedAt, c.Ttl))
+	case "queue_ack":
+		if !f.queues.ack(c.Key, c.MessageId, c.Receipt) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED
+		}
+	case "queue_nack":
+		if !f.queues.nack(c.Key, c.MessageId, c.Receipt) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED
+		}
+	case "queue_timeout":
+		// The delivery may have been acknowledged since the leader
+		// proposed this, so it is checked again.
+		if !f.queues.timeout(c.Key, c.MessageId, c.Receipt, appliedAt) {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED
 		}
 	default:
-		return nil
+		result.Code = raftdv1.ApplyCode_APPLY_CODE_UNKNOWN_OP
+		result.Message = fmt.Sprintf("unknown op %q", c.Op)
+	}
+	return result
+}
+
+// applyImport writes the pairs of an "import" command, all of them or none.
+func (f *FSM) applyImport(c *raftdv1.Command, index uint64, result *raftdv1.ApplyResult) {
+	kv := f.namespaces.store(c.Namespace)
+	if kv == nil {
+		result.Code = raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND
+		result.Message = "namespace not found"
+		return
+	}
+
+	// A key given more than once takes its last value.
+	last := make(map[string]int, len(c.Kvs))
+	for i, pair := range c.Kvs {
+		last[pair.Key] = i
+	}
+
+	keys, bytes := f.namespaces.usage(c.Namespace)
+	written := make([]string, 0, len(last))
+	for i, pair := range c.Kvs {
+		if last[pair.Key] != i {
+			continue
+		}
+		prev, ok := kv.Set(pair.Key, pair.Value, index)
+		written = append(written, pair.Key)
+		if err := f.namespaces.charge(c.Namespace, pair.Key, prev, ok, pair.Value, true); err != nil {
+			for j := len(written) - 1; j >= 0; j-- {
+				kv.Undo(written[j], index)
+			}
+			f.namespaces.setUsage(c.Namespace, keys, bytes)
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_QUOTA_EXCEEDED
+			result.Message = err.Error()
+			return
+		}
+	}
+}
+
+// applyTxn applies the operations of a "txn" command its comparisons
+// choose, all of them or none.
+func (f *FSM) applyTxn(c *raftdv1.Command, index uint64, result *raftdv1.ApplyResult) {
+	kv := f.namespaces.store(c.Namespace)
+	if kv == nil {
+		result.Code = raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND
+		result.Message = "namespace not found"
+		return
+	}
+
+	result.Succeeded = true
+	for _, compare := range c.Compares {
+		value, err := kv.Get(compare.Key)
+		if compare.Missing != (err != nil) || err == nil && !bytes.Equal(value, compare.Value) {
+			result.Succeeded = false
+			break
+		}
+	}
+	ops := c.Success
+	if !result.Succeeded {
+		ops = c.Failure
+	}
+
+	keys, size := f.namespaces.usage(c.Namespace)
+	written := make([]string, 0, len(ops))
+	for _, op := range ops {
+		var prev []byte
+		var ok bool
+		if op.Delete {
+			prev, ok = kv.Delete(op.Key, index)
+		} else {
+			prev, ok = kv.Set(op.Key, op.Value, index)
+		}
+		written = append(written, op.Key)
+		if err := f.namespaces.charge(c.Namespace, op.Key, prev, ok, op.Value, !op.Delete); err != nil {
+			for j := len(written) - 1; j >= 0; j-- {
+				kv.Undo(written[j], index)
+			}
+			f.namespaces.setUsage(c.Namespace, keys, size)
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_QUOTA_EXCEEDED
+			result.Message = err.Error()
+			return
+		}
 	}
-	return nil
 }
 
-// Restore implements raft.FSM.
+// applyKeyCommand applies a command on a key of its namespace at index and
+// charges the change to the quotas of the namespace, undoing it if it
+// exceeds them.
+func (f *FSM) applyKeyCommand(c *raftdv1.Command, index uint64, result *raftdv1.ApplyResult) {
+	kv := f.namespaces.store(c.Namespace)
+	if kv == nil {
+		result.Code = raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND
+		result.Message = "namespace not found"
+		return
+	}
+
+	// The value of the key after the command, if it exists.
+	var value []byte
+	var exists bool
+	switch c.Op {
+	case "set":
+		result.PrevValue, result.PrevExists = kv.Set(c.Key, c.Value, index)
+		value, exists = c.Value, true
+	case "del":
+		result.PrevValue, result.PrevExists = kv.Delete(c.Key, index)
+	case "cas":
+		expected := c.Expected
+		if expected == nil && !c.ExpectMissing {
+			// An empty expected value does not survive JSON.
+			expected = []byte{}
+		}
+		var swapped bool
+		result.PrevValue, result.PrevExists, swapped = kv.CompareAndSwap(c.Key, expected, c.Value, index)
+		if !swapped {
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED
+			return
+		}
+		value, exists = c.Value, true
+	case "incr":
+		min, max := int64(math.MinInt64), int64(math.MaxInt64)
+		if c.Min != nil {
+			min = *c.Min
+		}
+		if c.Max != nil {
+			max = *c.Max
+		}
+		prev, ok, n, err := kv.Add(c.Key, c.Delta, c.Initial, min, max, index)
+		result.PrevValue, result.PrevExists = prev, ok
+		switch {
+		case errors.Is(err, store.ErrNotInteger):
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_INVALID_VALUE
+			result.Message = err.Error()
+			return
+		case errors.Is(err, store.ErrOutOfRange):
+			result.Code = raftdv1.ApplyCode_APPLY_CODE_OUT_OF_RANGE
+			result.Message = err.Error()
+			return
+		}
+		result.Value = []byte(strconv.FormatInt(n, 10))
+		value, exists = result.Value, true
+	}
+
+	if err := f.namespaces.charge(c.Namespace, c.Key, result.PrevValue, result.PrevExists, value, exists); err != nil {
+		kv.Undo(c.Key, index)
+		result.Code = raftdv1.ApplyCode_APPLY_CODE_QUOTA_EXCEEDED
+		result.Message = err.Error()
+		result.Value = nil
+	}
+}
+
+// Restore implements raft.FSM. The revision of the restored FSM is the
+// highest raft index the snapshot refers to, as a key revision, lease ID,
+// fencing token or queue message ID, which snapshots before version 8 do
+// not record.
 func (f *FSM) Restore(snapshot io.ReadCloser) error {
 	defer func() {
 		_ = snapshot.Close()
 	}()
 
 	dec := json.NewDecoder(snapshot)
+
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("failed to read snapshot header: %w", err)
+	}
+	if header.Format != snapshotFormat {
+		return fmt.Errorf("unknown snapshot format %q", header.Format)
+	}
+	if header.Version < 1 || header.Version > snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+
+	quotas := make(map[string]*raftdv1.Quota)
+	history := make(map[string][]store.Version)
+	retired := make(map[string][]store.Version)
+	var compacted, revision uint64
+	timeline := &timeline{}
+	sessions := newSessions()
+	leases := make(map[uint64]*raftdv1.Lease)
+	locks := make(map[string]*raftdv1.LockHolder)
+	var messages []*raftdv1.QueueMessage
+	var records []*raftdv1.AuditRecord
+	changeCheckpoints := make(map[string]consumerCheckpoint)
+	var alarm bool
 	for dec.More() {
 		var c raftdv1.Command
 		if err := dec.Decode(&c); err != nil {
 			return err
 		}
 
-		switch c.Op {
-		case "set":
-			if err := f.store.Set(c.Key, c.Value); err != nil {
-				return err
-			}
-		case "del":
-			if err := f.store.Delete(c.Key); err != nil {
-				return err
+		switch {
+		case c.Op == "set", c.Op == "del" && header.Version >= 7:
+			name := namespaceName(c.Namespace)
+			history[name] = append(history[name], store.Version{
+				Key:      c.Key,
+				Value:    c.Value,
+				Revision: c.Revision,
+				Deleted:  c.Op == "del",
+			})
+			revision = max(revision, c.Revision)
+		case c.Op == "retired_set" && header.Version >= 11, c.Op == "retired_del" && header.Version >= 11:
+			retired[c.Namespace] = append(retired[c.Namespace], store.Version{
+				Key:      c.Key,
+				Value:    c.Value,
+				Revision: c.Revision,
+				Deleted:  c.Op == "retired_del",
+			})
+			revision = max(revision, c.Revision)
+		case c.Op == "compacted" && header.Version >= 7:
+			compacted = c.Revision
+			revision = max(revision, c.Revision)
+		case c.Op == "revision" && header.Version >= 8:
+			revision = max(revision, c.Revision)
+		case c.Op == "checkpoint" && header.Version >= 7:
+			timeline.checkpoints = append(timeline.checkpoints, checkpoint{revision: c.Revision, appliedAt: c.AppliedAt})
+		case c.Op == "namespace" && header.Version >= 5:
+			quotas[namespaceName(c.Namespace)] = c.Quota
+		case c.Op == "session" && header.Version >= 2 && c.Session != nil:
+			sessions.put(c.Session.ClientId, c.Session.Sequence, c.Session.Result, c.Session.AppliedAt)
+		case c.Op == "lease" && header.Version >= 3 && c.Lease != nil:
+			leases[c.Lease.Id] = c.Lease
+			revision = max(revision, c.Lease.Id)
+		case c.Op == "lock" && header.Version >= 3 && c.Lock != nil:
+			locks[c.Lock.Name] = c.Lock
+			revision = max(revision, c.Lock.Token)
+		case c.Op == "queue_message" && header.Version >= 4 && c.QueueMessage != nil:
+			messages = append(messages, c.QueueMessage)
+			revision = max(revision, c.QueueMessage.Id, c.QueueMessage.Receipt)
+		case c.Op == "audit" && header.Version >= 9 && c.AuditRecord != nil:
+			records = append(records, c.AuditRecord)
+		case c.Op == "changes_checkpoint" && header.Version >= 10:
+			changeCheckpoints[c.Key] = consumerCheckpoint{revision: c.Revision, ackedAt: c.AppliedAt}
+		case c.Op == "alarm" && header.Version >= 6:
+			alarm = true
+		default:
+			return fmt.Errorf("unexpected snapshot op %q", c.Op)
+		}
+	}
+	if header.Version < 12 {
+		// The time of the last ack was not recorded: the checkpoints count
+		// as acknowledged at the last time the timeline has.
+		if n := len(timeline.checkpoints); n > 0 {
+			for consumer, cp := range changeCheckpoints {
+				cp.ackedAt = timeline.checkpoints[n-1].appliedAt
+				changeCheckpoints[consumer] = cp
 			}
 		}
 	}
 
+	f.namespaces.replace(quotas, history, retired, compacted)
+	f.timeline.replace(timeline.checkpoints)
+	f.revision.Store(revision)
+	f.audit.replace(records)
+	f.changes.replace(changeCheckpoints)
+	f.sessions = sessions
+	f.leases.replace(leases, locks)
+	f.queues.replace(messages)
+	f.alarm.Store(alarm)
 	return nil
 }
 
 // Snapshot implements raft.FSM.
 func (f *FSM) Snapshot() (raft.FSMSnapshot, error) {
-	return newSnapshot(f.store), nil
+	quotas, history, retired, compacted := f.namespaces.snapshot()
+	leases, locks := f.leases.list()
+	return &snapshot{
+		quotas:      quotas,
+		history:     history,
+		retired:     retired,
+		compacted:   compacted,
+		revision:    f.revision.Load(),
+		checkpoints: f.timeline.list(),
+		sessions:    f.sessions.list(),
+		leases:      leases,
+		locks:       locks,
+		messages:    f.queues.list(),
+		records:     f.audit.list(),
+		changes:     f.changes.list(),
+		alarm:       f.alarm.Load(),
+	}, nil
+}
+
+const (
+	snapshotFormat  = "raftd-snapshot"
+	snapshotVersion = 12
+)
+
+// snapshotHeader is the first record of every snapshot. It is followed by
+// one "set" command per key, since version 2 one "session" command per
+// entry of the dedup table, since version 3 one "lease" and "lock" command
+// per lease and held lock, and since version 4 one "queue_message" command
+// per queued message. Since version 5, "set" commands carry their
+// namespace unless it is the default one, and one "namespace" command per
+// namespace records its quota. Since version 6, an "alarm" command records
+// a raised storage alarm. Since version 7, "set" commands carry their
+// revision and are joined by "del" commands, recording every version kept
+// in the history of the key in revision order, and "compacted" and
+// "checkpoint" commands record the compaction point and revision timeline.
+// Since version 8, a "revision" command records the last revision applied.
+// Since version 9, one "audit" command per record of the replicated audit
+// log restores it in index order. Since version 10, one
+// "changes_checkpoint" command per sink or consumer of changes records its
+// checkpoint. Since version 11, "retired_set" and "retired_del" commands
+// record the history kept of deleted namespaces like "set" and "del".
+// Since version 12, "changes_checkpoint" commands carry the time of the
+// last ack in AppliedAt.
+type snapshotHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
 }
 
 type snapshot struct {
-	store *store.Store
+	quotas      map[string]*raftdv1.Quota
+	history     map[string][]store.Version
+	retired     map[string][]store.Version
+	compacted   uint64
+	revision    uint64
+	checkpoints []checkpoint
+	sessions    []*raftdv1.Session
+	leases      []*raftdv1.Lease
+	locks       []*raftdv1.LockHolder
+	messages    []*raftdv1.QueueMessage
+	records     []*raftdv1.AuditRecord
+	changes     map[string]consumerCheckpoint
+	alarm       bool
 }
 
 func (s snapshot) Persist(sink raft.SnapshotSink) error {
 	err := func() error {
-		b, err := json.Marshal(s.store)
-		if err != nil {
+		enc := json.NewEncoder(sink)
+		if err := enc.Encode(snapshotHeader{Format: snapshotFormat, Version: snapshotVersion}); err != nil {
 			return err
 		}
 
-		if _, err := sink.Write(b); err != nil {
+		for name, quota := range s.quotas {
+			if err := enc.Encode(&raftdv1.Command{Op: "namespace", Namespace: name, Quota: quota}); err != nil {
+				return err
+			}
+		}
+
+		for name, versions := range s.history {
+			if name == defaultNamespace {
+				name = ""
+			}
+			slices.SortFunc(versions, func(a, b store.Version) int {
+				return cmp.Compare(a.Revision, b.Revision)
+			})
+			for _, v := range versions {
+				op := "set"
+				if v.Deleted {
+					op = "del"
+				}
+				if err := enc.Encode(&raftdv1.Command{Op: op, Namespace: name, Key: v.Key, Value: v.Value, Revision: v.Revision}); err != nil {
+					return err
+				}
+			}
+		}
+
+		for name, versions := range s.retired {
+			slices.SortFunc(versions, func(a, b store.Version) int {
+				return cmp.Compare(a.Revision, b.Revision)
+			})
+			for _, v := range versions {
+				op := "retired_set"
+				if v.Deleted {
+					op = "retired_del"
+				}
+				if err := enc.Encode(&raftdv1.Command{Op: op, Namespace: name, Key: v.Key, Value: v.Value, Revision: v.Revision}); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := enc.Encode(&raftdv1.Command{Op: "compacted", Revision: s.compacted}); err != nil {
+			return err
+		}
+		if err := enc.Encode(&raftdv1.Command{Op: "revision", Revision: s.revision}); err != nil {
 			return err
 		}
+		for _, cp := range s.checkpoints {
+			if err := enc.Encode(&raftdv1.Command{Op: "checkpoint", Revision: cp.revision, AppliedAt: cp.appliedAt}); err != nil {
+				return err
+			}
+		}
+
+		for _, session := range s.sessions {
+			if err := enc.Encode(&raftdv1.Command{Op: "session", Session: session}); err != nil {
+				return err
+			}
+		}
+
+		for _, lease := range s.leases {
+			if err := enc.Encode(&raftdv1.Command{Op: "lease", Lease: lease}); err != nil {
+				return err
+			}
+		}
+
+		for _, lock := range s.locks {
+			if err := enc.Encode(&raftdv1.Command{Op: "lock", Lock: lock}); err != nil {
+				return err
+			}
+		}
+
+		for _, msg := range s.messages {
+			if err := enc.Encode(&raftdv1.Command{Op: "queue_message", QueueMessage: msg}); err != nil {
+				return err
+			}
+		}
+
+		for _, record := range s.records {
+			if err := enc.Encode(&raftdv1.Command{Op: "audit", AuditRecord: record}); err != nil {
+				return err
+			}
+		}
+
+		for consumer, cp := range s.changes {
+			if err := enc.Encode(&raftdv1.Command{Op: "changes_checkpoint", Key: consumer, Revision: cp.revision, AppliedAt: cp.ackedAt}); err != nil {
+				return err
+			}
+		}
+
+		if s.alarm {
+			if err := enc.Encode(&raftdv1.Command{Op: "alarm"}); err != nil {
+				return err
+			}
+		}
 
 		return sink.Close()
 	}()
@@ -99,7 +710,3 @@ func (s snapshot) Persist(sink raft.SnapshotSink) error {
 }
 
 func (s snapshot) Release() {}
-
-func newSnapshot(store *store.Store) raft.FSMSnapshot {
-	return &snapshot{store: store}
-}
@@ -0,0 +1,463 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	"github.com/amjadjibon/raftd/store"
+)
+
+// applyCommand applies cmd as the entry at index, appended by the leader at
+// the time at, which may be zero.
+func applyCommand(t *testing.T, fsm *FSM, index uint64, at time.Time, cmd *raftdv1.Command) *raftdv1.ApplyResult {
+	t.Helper()
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fsm.Apply(&raft.Log{Index: index, Type: raft.LogCommand, Data: data, AppendedAt: at}).(*raftdv1.ApplyResult)
+}
+
+func TestFSMApply(t *testing.T) {
+	s := store.New()
+	fsm := NewFSM(s)
+
+	result := applyCommand(t, fsm, 1, time.Time{}, &raftdv1.Command{Op: "set", Key: "k", Value: []byte("1")})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_OK || result.Revision != 1 || result.PrevExists {
+		t.Fatalf("first set: %v", result)
+	}
+
+	result = applyCommand(t, fsm, 2, time.Time{}, &raftdv1.Command{Op: "set", Key: "k", Value: []byte("2")})
+	if !result.PrevExists || string(result.PrevValue) != "1" {
+		t.Fatalf("second set: %v", result)
+	}
+
+	result = applyCommand(t, fsm, 3, time.Time{}, &raftdv1.Command{Op: "cas", Key: "k", Expected: []byte("1"), Value: []byte("3")})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED || string(result.PrevValue) != "2" {
+		t.Fatalf("failed cas: %v", result)
+	}
+
+	result = applyCommand(t, fsm, 4, time.Time{}, &raftdv1.Command{Op: "frobnicate", Key: "k", Value: []byte("4")})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_UNKNOWN_OP {
+		t.Fatalf("unknown op: %v", result)
+	}
+
+	result = fsm.Apply(&raft.Log{Index: 5, Type: raft.LogCommand, Data: []byte("{")}).(*raftdv1.ApplyResult)
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_INVALID_COMMAND {
+		t.Fatalf("invalid command: %v", result)
+	}
+
+	if value, err := s.Get("k"); err != nil || string(value) != "2" {
+		t.Fatalf("get k = %q, %v, want 2", value, err)
+	}
+}
+
+// memorySink collects a snapshot in memory.
+type memorySink struct {
+	bytes.Buffer
+}
+
+func (s *memorySink) ID() string    { return "memory" }
+func (s *memorySink) Cancel() error { return nil }
+func (s *memorySink) Close() error  { return nil }
+
+// restoreCopy returns a new FSM restored from a snapshot of fsm.
+func restoreCopy(t *testing.T, fsm *FSM) *FSM {
+	t.Helper()
+
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sink memorySink
+	if err := snap.Persist(&sink); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewFSM(store.New())
+	if err := restored.Restore(io.NopCloser(&sink)); err != nil {
+		t.Fatal(err)
+	}
+	return restored
+}
+
+func TestFSMDedup(t *testing.T) {
+	fsm := NewFSM(store.New())
+	start := time.Now()
+
+	cas := &raftdv1.Command{Op: "cas", Key: "k", ExpectMissing: true, Value: []byte("1"), ClientId: "c", Sequence: 1}
+	if result := applyCommand(t, fsm, 1, start, cas); result.Code != raftdv1.ApplyCode_APPLY_CODE_OK {
+		t.Fatalf("first attempt: %v", result)
+	}
+	if result := applyCommand(t, fsm, 2, start, cas); result.Code != raftdv1.ApplyCode_APPLY_CODE_OK || result.Revision != 1 {
+		t.Fatalf("retry: %v, want the first result", result)
+	}
+
+	// The table survives a snapshot.
+	fsm = restoreCopy(t, fsm)
+	if result := applyCommand(t, fsm, 3, start, cas); result.Revision != 1 {
+		t.Fatalf("retry after restore: %v, want the first result", result)
+	}
+
+	// Once expired, a retry is applied again and fails its condition.
+	later := start.Add(sessionTTL + time.Second)
+	if result := applyCommand(t, fsm, 4, later, cas); result.Code != raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED {
+		t.Fatalf("retry after expiry: %v", result)
+	}
+}
+
+func TestFSMDedupGrantCompact(t *testing.T) {
+	fsm := NewFSM(store.New())
+	start := time.Now()
+
+	// A retried grant returns the lease of the first attempt rather than
+	// granting another one.
+	grant := &raftdv1.Command{Op: "lease_grant", Ttl: int64(time.Minute), ClientId: "c", Sequence: 1}
+	first := applyCommand(t, fsm, 1, start, grant)
+	if result := applyCommand(t, fsm, 2, start, grant); result.Revision != first.Revision {
+		t.Fatalf("retried grant: lease %d, want %d", result.Revision, first.Revision)
+	}
+
+	// A retried compaction succeeds like the first attempt.
+	applyCommand(t, fsm, 3, start, &raftdv1.Command{Op: "set", Key: "k", Value: []byte("1")})
+	compact := &raftdv1.Command{Op: "compact", Revision: 3, ClientId: "c", Sequence: 2}
+	if result := applyCommand(t, fsm, 4, start, compact); result.Code != raftdv1.ApplyCode_APPLY_CODE_OK {
+		t.Fatalf("compact: %v", result)
+	}
+	if result := applyCommand(t, fsm, 5, start, compact); result.Code != raftdv1.ApplyCode_APPLY_CODE_OK {
+		t.Fatalf("retried compact: %v, want the first result", result)
+	}
+}
+
+func TestFSMDedupExpiryAfterRestore(t *testing.T) {
+	start := time.Now()
+	cas := &raftdv1.Command{Op: "cas", Key: "k", ExpectMissing: true, Value: []byte("1"), ClientId: "c", Sequence: 1}
+	other := &raftdv1.Command{Op: "set", Key: "other", Value: []byte("1")}
+
+	replica := NewFSM(store.New())
+	applyCommand(t, replica, 1, start, cas)
+	// Prune the table shortly before the result expires, then restore a
+	// copy between two prunes.
+	for index := uint64(2); index <= sessionPruneEntries+1; index++ {
+		applyCommand(t, replica, index, start.Add(sessionTTL-time.Second), other)
+	}
+	restored := restoreCopy(t, replica)
+
+	// Every node applies a retry after the expiry again, whether it pruned
+	// the result yet or not.
+	later := start.Add(sessionTTL + time.Second)
+	for name, fsm := range map[string]*FSM{"replica": replica, "restored": restored} {
+		result := applyCommand(t, fsm, sessionPruneEntries+2, later, cas)
+		if result.Code != raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED {
+			t.Fatalf("%s: retry after expiry: %v", name, result)
+		}
+	}
+}
+
+func TestFSMLeases(t *testing.T) {
+	fsm := NewFSM(store.New())
+	start := time.Now()
+
+	applyCommand(t, fsm, 1, start, &raftdv1.Command{Op: "lease_grant", Ttl: int64(time.Second)})
+	applyCommand(t, fsm, 2, start, &raftdv1.Command{Op: "lease_grant", Ttl: int64(time.Second)})
+	if result := applyCommand(t, fsm, 3, start, &raftdv1.Command{Op: "lock_acquire", Key: "l", LeaseId: 1}); result.Token != 3 {
+		t.Fatalf("acquire: %v, want token 3", result)
+	}
+	if result := applyCommand(t, fsm, 4, start, &raftdv1.Command{Op: "lock_acquire", Key: "l", LeaseId: 2}); result.Code != raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED {
+		t.Fatalf("acquire of a held lock: %v", result)
+	}
+	if result := applyCommand(t, fsm, 5, start, &raftdv1.Command{Op: "lock_acquire", Key: "l", LeaseId: 9}); result.Code != raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND {
+		t.Fatalf("acquire with an unknown lease: %v", result)
+	}
+
+	// Leases and locks survive a snapshot.
+	fsm = restoreCopy(t, fsm)
+	if holder, _ := fsm.leases.holder("l"); holder.GetLeaseId() != 1 || holder.GetToken() != 3 {
+		t.Fatalf("holder after restore = %v", holder)
+	}
+
+	// A lease kept alive since the expiry was proposed is not expired.
+	later := start.Add(time.Second)
+	applyCommand(t, fsm, 6, later, &raftdv1.Command{Op: "lease_keepalive", LeaseId: 1})
+	if result := applyCommand(t, fsm, 7, later, &raftdv1.Command{Op: "lease_expire", LeaseId: 1}); result.Code != raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED {
+		t.Fatalf("expire of a renewed lease: %v", result)
+	}
+
+	if result := applyCommand(t, fsm, 8, later.Add(time.Second), &raftdv1.Command{Op: "lease_expire", LeaseId: 1}); result.Code != raftdv1.ApplyCode_APPLY_CODE_OK {
+		t.Fatalf("expire: %v", result)
+	}
+	if holder, _ := fsm.leases.holder("l"); holder != nil {
+		t.Fatalf("holder after expiry = %v, want none", holder)
+	}
+}
+
+func TestFSMQueues(t *testing.T) {
+	fsm := NewFSM(store.New())
+	start := time.Now()
+
+	applyCommand(t, fsm, 1, start, &raftdv1.Command{Op: "queue_enqueue", Key: "q", Value: []byte("a")})
+	applyCommand(t, fsm, 2, start, &raftdv1.Command{Op: "queue_enqueue", Key: "q", Value: []byte("b")})
+	result := applyCommand(t, fsm, 3, start, &raftdv1.Command{Op: "queue_dequeue", Key: "q", Ttl: int64(time.Second)})
+	if msg := result.QueueMessage; msg.GetId() != 1 || msg.GetReceipt() != 3 {
+		t.Fatalf("dequeue: %v, want message 1 with receipt 3", result)
+	}
+
+	// Queues survive a snapshot.
+	fsm = restoreCopy(t, fsm)
+	if visible, inFlight := fsm.queues.length("q"); visible != 1 || inFlight != 1 {
+		t.Fatalf("length after restore = %d, %d, want 1, 1", visible, inFlight)
+	}
+
+	if result := applyCommand(t, fsm, 4, start, &raftdv1.Command{Op: "queue_timeout", Key: "q", MessageId: 1, Receipt: 3}); result.Code != raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED {
+		t.Fatalf("timeout before the deadline: %v", result)
+	}
+	if result := applyCommand(t, fsm, 5, start.Add(time.Second), &raftdv1.Command{Op: "queue_timeout", Key: "q", MessageId: 1, Receipt: 3}); result.Code != raftdv1.ApplyCode_APPLY_CODE_OK {
+		t.Fatalf("timeout: %v", result)
+	}
+	if result := applyCommand(t, fsm, 6, start, &raftdv1.Command{Op: "queue_ack", Key: "q", MessageId: 1, Receipt: 3}); result.Code != raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED {
+		t.Fatalf("ack of a timed out delivery: %v", result)
+	}
+	if msg := fsm.queues.peek("q"); msg.GetId() != 1 || msg.GetAttempts() != 1 {
+		t.Fatalf("peek = %v, want message 1 first again", msg)
+	}
+}
+
+func TestFSMNamespaces(t *testing.T) {
+	fsm := NewFSM(store.New())
+
+	applyCommand(t, fsm, 1, time.Time{}, &raftdv1.Command{Op: "ns_create", Namespace: "a", Quota: &raftdv1.Quota{MaxBytes: 4}})
+	applyCommand(t, fsm, 2, time.Time{}, &raftdv1.Command{Op: "set", Namespace: "a", Key: "k", Value: []byte("v")})
+	applyCommand(t, fsm, 3, time.Time{}, &raftdv1.Command{Op: "set", Key: "k", Value: []byte("default")})
+	result := applyCommand(t, fsm, 4, time.Time{}, &raftdv1.Command{Op: "incr", Namespace: "a", Key: "n", Delta: 100})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_QUOTA_EXCEEDED {
+		t.Fatalf("incr over the quota: %v", result)
+	}
+	if _, err := fsm.namespaces.store("a").Get("n"); err == nil {
+		t.Fatal("rejected incr left its key behind")
+	}
+	result = applyCommand(t, fsm, 5, time.Time{}, &raftdv1.Command{Op: "set", Namespace: "b", Key: "k"})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND {
+		t.Fatalf("set in a missing namespace: %v", result)
+	}
+
+	// Namespaces, their quotas and usage survive a snapshot.
+	fsm = restoreCopy(t, fsm)
+
+	ns := fsm.namespaces.get("a")
+	if ns.GetQuota().GetMaxBytes() != 4 || ns.GetUsage().GetKeys() != 1 || ns.GetUsage().GetBytes() != 2 {
+		t.Fatalf("namespace after restore = %v", ns)
+	}
+	if value, err := fsm.namespaces.store("").Get("k"); err != nil || string(value) != "default" {
+		t.Fatalf("get k = %q, %v, want default", value, err)
+	}
+}
+
+func TestFSMNamespaceDeleteChanges(t *testing.T) {
+	fsm := NewFSM(store.New())
+
+	applyCommand(t, fsm, 1, time.Time{}, &raftdv1.Command{Op: "ns_create", Namespace: "a"})
+	applyCommand(t, fsm, 2, time.Time{}, &raftdv1.Command{Op: "set", Namespace: "a", Key: "x", Value: []byte("1")})
+	applyCommand(t, fsm, 3, time.Time{}, &raftdv1.Command{Op: "set", Namespace: "a", Key: "y", Value: []byte("1")})
+	applyCommand(t, fsm, 4, time.Time{}, &raftdv1.Command{Op: "ns_delete", Namespace: "a"})
+	applyCommand(t, fsm, 5, time.Time{}, &raftdv1.Command{Op: "ns_create", Namespace: "a"})
+	applyCommand(t, fsm, 6, time.Time{}, &raftdv1.Command{Op: "set", Namespace: "a", Key: "x", Value: []byte("2")})
+	applyCommand(t, fsm, 7, time.Time{}, &raftdv1.Command{Op: "ns_delete", Namespace: "a"})
+
+	// Deleting a namespace deletes its keys, and the changes of both
+	// namespaces named a survive a snapshot.
+	fsm = restoreCopy(t, fsm)
+
+	var got []string
+	for _, change := range fsm.namespaces.changes(0, 0) {
+		op := "set"
+		if change.Deleted {
+			op = "del"
+		}
+		got = append(got, fmt.Sprintf("%d %s %s/%s", change.Revision, op, change.Namespace, change.Key))
+	}
+	slices.Sort(got)
+	want := []string{"2 set a/x", "3 set a/y", "4 del a/x", "4 del a/y", "6 set a/x", "7 del a/x"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("changes = %q, want %q", got, want)
+	}
+
+	// A batch does not split the deletions of a namespace.
+	if changes := fsm.namespaces.changes(3, 1); len(changes) != 2 {
+		t.Fatalf("batch after 3 has %d changes, want 2", len(changes))
+	}
+
+	// Compaction drops the history of the deleted namespaces.
+	applyCommand(t, fsm, 8, time.Time{}, &raftdv1.Command{Op: "compact", Revision: 7})
+	if changes := fsm.namespaces.changes(0, 0); len(changes) != 0 || fsm.namespaces.size() != 0 {
+		t.Fatalf("changes after compaction = %v, size %d", changes, fsm.namespaces.size())
+	}
+}
+
+func TestFSMTxn(t *testing.T) {
+	fsm := NewFSM(store.New())
+	kv := fsm.namespaces.store("")
+
+	applyCommand(t, fsm, 1, time.Time{}, &raftdv1.Command{Op: "set", Key: "a", Value: []byte("1")})
+	txn := func(index uint64, compares ...*raftdv1.Compare) *raftdv1.ApplyResult {
+		return applyCommand(t, fsm, index, time.Time{}, &raftdv1.Command{
+			Op:       "txn",
+			Compares: compares,
+			Success: []*raftdv1.TxnOp{
+				{Key: "a", Delete: true},
+				{Key: "b", Value: []byte("2")},
+			},
+			Failure: []*raftdv1.TxnOp{{Key: "failed", Value: []byte("yes")}},
+		})
+	}
+
+	result := txn(2, &raftdv1.Compare{Key: "a", Value: []byte("0")})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_OK || result.Succeeded {
+		t.Fatalf("txn with a failed comparison: %v", result)
+	}
+	if _, err := kv.Get("failed"); err != nil {
+		t.Fatal("the failure operations were not applied")
+	}
+
+	result = txn(3, &raftdv1.Compare{Key: "a", Value: []byte("1")}, &raftdv1.Compare{Key: "b", Missing: true})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_OK || !result.Succeeded {
+		t.Fatalf("txn: %v", result)
+	}
+	if _, err := kv.Get("a"); err == nil {
+		t.Fatal("a was not deleted")
+	}
+	if value, err := kv.Get("b"); err != nil || string(value) != "2" {
+		t.Fatalf("get b = %q, %v, want 2", value, err)
+	}
+
+	// A transaction over the quota changes nothing.
+	applyCommand(t, fsm, 4, time.Time{}, &raftdv1.Command{Op: "ns_create", Namespace: "small", Quota: &raftdv1.Quota{MaxKeys: 1}})
+	result = applyCommand(t, fsm, 5, time.Time{}, &raftdv1.Command{
+		Op:        "txn",
+		Namespace: "small",
+		Success:   []*raftdv1.TxnOp{{Key: "x", Value: []byte("1")}, {Key: "y", Value: []byte("1")}},
+	})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_QUOTA_EXCEEDED {
+		t.Fatalf("txn over the quota: %v", result)
+	}
+	if ns := fsm.namespaces.get("small"); len(fsm.namespaces.store("small").Keys()) != 0 || ns.Usage.Keys != 0 {
+		t.Fatalf("txn over the quota left %v", ns)
+	}
+}
+
+func TestFSMChangeCheckpoints(t *testing.T) {
+	fsm := NewFSM(store.New())
+	start := time.Now()
+
+	applyCommand(t, fsm, 1, start, &raftdv1.Command{Op: "set", Key: "k", Value: []byte("1")})
+	applyCommand(t, fsm, 2, start, &raftdv1.Command{Op: "changes_ack", Key: "a", Revision: 1})
+	applyCommand(t, fsm, 3, start.Add(time.Minute), &raftdv1.Command{Op: "changes_ack", Key: "b", Revision: 1})
+
+	// The checkpoints and the time of their last ack survive a snapshot.
+	fsm = restoreCopy(t, fsm)
+	want := map[string]consumerCheckpoint{
+		"a": {revision: 1, ackedAt: start.UnixNano()},
+		"b": {revision: 1, ackedAt: start.Add(time.Minute).UnixNano()},
+	}
+	if got := fsm.changes.list(); !maps.Equal(got, want) {
+		t.Fatalf("checkpoints = %v, want %v", got, want)
+	}
+
+	remove := &raftdv1.Command{Op: "changes_remove", Key: "a"}
+	if result := applyCommand(t, fsm, 4, start, remove); result.Code != raftdv1.ApplyCode_APPLY_CODE_OK {
+		t.Fatalf("remove: %v", result)
+	}
+	if result := applyCommand(t, fsm, 5, start, remove); result.Code != raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND {
+		t.Fatalf("remove of a removed consumer: %v", result)
+	}
+	if _, ok := fsm.changes.list()["a"]; ok {
+		t.Fatal("the removed checkpoint is still there")
+	}
+}
+
+func TestFSMAuditKeys(t *testing.T) {
+	fsm := NewFSM(store.New())
+
+	applyCommand(t, fsm, 1, time.Time{}, &raftdv1.Command{
+		Op:    "import",
+		Audit: true,
+		Kvs:   []*raftdv1.KeyValue{{Key: "a"}, {Key: "b"}, {Key: "a"}},
+	})
+	applyCommand(t, fsm, 2, time.Time{}, &raftdv1.Command{
+		Op:       "txn",
+		Audit:    true,
+		Compares: []*raftdv1.Compare{{Key: "c", Missing: true}},
+		Success:  []*raftdv1.TxnOp{{Key: "a", Delete: true}, {Key: "c", Value: []byte("1")}},
+		Failure:  []*raftdv1.TxnOp{{Key: "d"}},
+	})
+
+	// Every key written is recorded, those of the txn branch applied.
+	records := fsm.audit.list()
+	if len(records) != 2 || !slices.Equal(records[0].Keys, []string{"a", "b"}) || !slices.Equal(records[1].Keys, []string{"a", "c"}) {
+		t.Fatalf("audit records = %v", records)
+	}
+}
+
+func TestFSMCompaction(t *testing.T) {
+	fsm := NewFSM(store.New())
+
+	applyCommand(t, fsm, 1, time.Time{}, &raftdv1.Command{Op: "set", Key: "a", Value: []byte("1")})
+	applyCommand(t, fsm, 2, time.Time{}, &raftdv1.Command{Op: "set", Key: "a", Value: []byte("2")})
+	applyCommand(t, fsm, 3, time.Time{}, &raftdv1.Command{Op: "set", Key: "b", Value: []byte("1")})
+	applyCommand(t, fsm, 4, time.Time{}, &raftdv1.Command{Op: "del", Key: "b"})
+	if n := len(fsm.namespaces.store("").History()); n != 4 {
+		t.Fatalf("history has %d versions, want 4", n)
+	}
+	// The superseded versions and the deletion count toward the storage.
+	if size := fsm.namespaces.size(); size != 7 {
+		t.Fatalf("size with history = %d, want 7", size)
+	}
+
+	result := applyCommand(t, fsm, 5, time.Time{}, &raftdv1.Command{Op: "compact", Revision: 5})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_OUT_OF_RANGE {
+		t.Fatalf("compact at the current revision: %v", result)
+	}
+	result = applyCommand(t, fsm, 6, time.Time{}, &raftdv1.Command{Op: "compact", Revision: 4})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_OK {
+		t.Fatalf("compact: %v", result)
+	}
+	result = applyCommand(t, fsm, 7, time.Time{}, &raftdv1.Command{Op: "compact", Revision: 3})
+	if result.Code != raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED {
+		t.Fatalf("compact below the compaction point: %v", result)
+	}
+
+	// Only the current version of a survives, the deletion of b is dropped.
+	history := fsm.namespaces.store("").History()
+	if len(history) != 1 || history[0].Key != "a" || history[0].Revision != 2 {
+		t.Fatalf("history after compaction = %v", history)
+	}
+	if size := fsm.namespaces.size(); size != 2 {
+		t.Fatalf("size after compaction = %d, want 2", size)
+	}
+
+	// The history and the compaction point survive a snapshot.
+	applyCommand(t, fsm, 8, time.Time{}, &raftdv1.Command{Op: "set", Key: "a", Value: []byte("3")})
+	fsm = restoreCopy(t, fsm)
+
+	kv := fsm.namespaces.store("")
+	if history := kv.History(); len(history) != 2 || history[1].Revision != 8 {
+		t.Fatalf("history after restore = %v", history)
+	}
+	if size := fsm.namespaces.size(); size != 4 {
+		t.Fatalf("size after restore = %d, want 4", size)
+	}
+	if kv.Compacted() != 4 || fsm.namespaces.compactRevision() != 4 {
+		t.Fatalf("compaction point after restore = %d", kv.Compacted())
+	}
+	if value, err := kv.Get("a"); err != nil || string(value) != "3" {
+		t.Fatalf("get a = %q, %v, want 3", value, err)
+	}
+}
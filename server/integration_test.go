@@ -0,0 +1,1571 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/amjadjibon/raftd/client"
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	"github.com/amjadjibon/raftd/server"
+	"github.com/amjadjibon/raftd/server/testcluster"
+)
+
+// requireValue checks key on every running node.
+func requireValue(t *testing.T, c *testcluster.Cluster, key, want string) {
+	t.Helper()
+
+	for _, node := range c.Nodes() {
+		raftd := node.Raftd()
+		if raftd == nil {
+			continue
+		}
+
+		resp, err := raftd.Get(context.Background(), &raftdv1.GetRequest{Key: key})
+		if err != nil {
+			t.Fatalf("%s: get %s: %v", node.ID, key, err)
+		}
+		if string(resp.Value) != want {
+			t.Fatalf("%s: get %s = %q, want %q", node.ID, key, resp.Value, want)
+		}
+	}
+}
+
+// requireMissing checks that key does not exist on any running node.
+func requireMissing(t *testing.T, c *testcluster.Cluster, key string) {
+	t.Helper()
+
+	for _, node := range c.Nodes() {
+		raftd := node.Raftd()
+		if raftd == nil {
+			continue
+		}
+
+		_, err := raftd.Get(context.Background(), &raftdv1.GetRequest{Key: key})
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("%s: get %s: got %v, want NotFound", node.ID, key, err)
+		}
+	}
+}
+
+func servers(t *testing.T, c *testcluster.Cluster) []raft.Server {
+	t.Helper()
+
+	future := c.WaitForLeader().Raftd().Raft().GetConfiguration()
+	if err := future.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return future.Configuration().Servers
+}
+
+func TestReplication(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if err := cl.Set(ctx, fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cl.Delete(ctx, "key0"); err != nil {
+		t.Fatal(err)
+	}
+
+	c.WaitForApplied()
+	requireMissing(t, c, "key0")
+	for i := 1; i < 10; i++ {
+		requireValue(t, c, fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+}
+
+func TestWriteOnFollower(t *testing.T) {
+	c := testcluster.New(t, 3)
+	c.WaitForLeader()
+
+	follower := c.Followers()[0]
+	_, err := follower.Raftd().Set(context.Background(), &raftdv1.SetRequest{Key: "k", Value: []byte("v")})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("set on follower: got %v, want FailedPrecondition", err)
+	}
+}
+
+func TestJoinLeave(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	if err := cl.Set(ctx, "before", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	voter := c.AddNode(false)
+	replica := c.AddNode(true)
+	if err := cl.Set(ctx, "after", []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+
+	c.WaitForApplied()
+	requireValue(t, c, "before", "1")
+	requireValue(t, c, "after", "2")
+
+	if got := len(servers(t, c)); got != 5 {
+		t.Fatalf("got %d servers, want 5", got)
+	}
+
+	st, err := cl.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, peer := range st.Peers {
+		if peer.Replica != (peer.Id == replica.ID) {
+			t.Fatalf("peer %s: replica = %v", peer.Id, peer.Replica)
+		}
+	}
+
+	if err := cl.Leave(ctx, voter.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Leave(ctx, voter.ID); status.Code(err) != codes.NotFound {
+		t.Fatalf("second leave: got %v, want NotFound", err)
+	}
+
+	for _, server := range servers(t, c) {
+		if string(server.ID) == voter.ID {
+			t.Fatalf("%s still in the configuration", voter.ID)
+		}
+	}
+
+	_, err = c.WaitForLeader().Raftd().Join(ctx, &raftdv1.JoinRequest{Id: replica.ID, Address: string(replica.Addr)})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("join twice: got %v, want AlreadyExists", err)
+	}
+}
+
+func TestReplicaReads(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	replica := c.AddNode(true)
+	if err := cl.Set(ctx, "k", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	c.WaitForApplied()
+
+	bounded := func(maxStaleness time.Duration) (*raftdv1.GetResponse, error) {
+		return replica.Raftd().Get(ctx, &raftdv1.GetRequest{
+			Key:          "k",
+			Consistency:  raftdv1.ReadConsistency_READ_CONSISTENCY_BOUNDED,
+			MaxStaleness: durationpb.New(maxStaleness),
+		})
+	}
+
+	// A replica in touch with the leader serves bounded reads.
+	resp, err := bounded(time.Second)
+	if err != nil || string(resp.Value) != "1" {
+		t.Fatalf("bounded read on the replica = %v, %v, want 1", resp, err)
+	}
+
+	// Once it lags too far behind, it turns them down, though it still
+	// serves stale reads.
+	c.Partition(replica)
+	time.Sleep(300 * time.Millisecond)
+	if _, err := bounded(100 * time.Millisecond); status.Code(err) != codes.Unavailable {
+		t.Fatalf("bounded read on a lagging replica: got %v, want Unavailable", err)
+	}
+	if resp, err := replica.Raftd().Get(ctx, &raftdv1.GetRequest{Key: "k"}); err != nil || string(resp.Value) != "1" {
+		t.Fatalf("stale read on a lagging replica = %v, %v, want 1", resp, err)
+	}
+	c.Heal()
+
+	// Writes and linearizable reads are for the leader alone.
+	_, err = replica.Raftd().Set(ctx, &raftdv1.SetRequest{Key: "k", Value: []byte("2")})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("write to the replica: got %v, want FailedPrecondition", err)
+	}
+	_, err = replica.Raftd().Get(ctx, &raftdv1.GetRequest{Key: "k", Consistency: raftdv1.ReadConsistency_READ_CONSISTENCY_LINEARIZABLE})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("linearizable read on the replica: got %v, want FailedPrecondition", err)
+	}
+	requireValue(t, c, "k", "1")
+}
+
+func TestLeaderFailover(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	if err := cl.Set(ctx, "k", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	old := c.WaitForLeader()
+	c.Kill(old)
+
+	leader := c.WaitForLeader()
+	if leader == old {
+		t.Fatal("killed node is still the leader")
+	}
+
+	if err := cl.Set(ctx, "k", []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Restart(old)
+	c.WaitForApplied()
+	requireValue(t, c, "k", "2")
+}
+
+func TestPartitionedLeader(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	old := c.WaitForLeader()
+	c.Partition(old)
+
+	leader := c.WaitForLeader()
+	if leader == old {
+		t.Fatal("partitioned node is still the leader")
+	}
+
+	if err := cl.Set(ctx, "k", []byte("majority")); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Heal()
+	c.WaitFor("the old leader to step down", func() bool {
+		return old.Raftd().Raft().State() != raft.Leader
+	})
+	c.WaitForApplied()
+	requireValue(t, c, "k", "majority")
+}
+
+func TestSnapshotInstall(t *testing.T) {
+	c := testcluster.New(t, 3, testcluster.WithRaftConfig(func(config *raft.Config) {
+		config.TrailingLogs = 5
+	}))
+	cl := c.Client()
+	ctx := context.Background()
+
+	lagging := c.Followers()[0]
+	c.Kill(lagging)
+
+	for i := 0; i < 50; i++ {
+		if err := cl.Set(ctx, fmt.Sprintf("key%d", i), []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Snapshotting truncates the log behind the lagging node, which then
+	// has to catch up from the snapshot.
+	if err := c.WaitForLeader().Raftd().Raft().Snapshot().Error(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Restart(lagging)
+	c.WaitForApplied()
+	for i := 0; i < 50; i++ {
+		requireValue(t, c, fmt.Sprintf("key%d", i), "v")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	if err := cl.Set(ctx, "a", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	future := c.WaitForLeader().Raftd().Raft().Snapshot()
+	if err := future.Error(); err != nil {
+		t.Fatal(err)
+	}
+	_, rc, err := future.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cl.Set(ctx, "a", []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Set(ctx, "b", []byte("3")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cl.Restore(ctx, bytes.NewReader(backup)); err != nil {
+		t.Fatal(err)
+	}
+
+	c.WaitForApplied()
+	requireValue(t, c, "a", "1")
+	requireMissing(t, c, "b")
+
+	err = cl.Restore(ctx, bytes.NewReader([]byte(`{"format":"other","version":1}`)))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("restore foreign snapshot: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	if err := cl.Set(ctx, "a", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	var backup bytes.Buffer
+	if err := cl.Backup(ctx, &backup); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without anything applied since, the last snapshot is backed up
+	// again.
+	var again bytes.Buffer
+	if err := cl.Backup(ctx, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), backup.Bytes()) {
+		t.Fatal("the second backup differs from the first")
+	}
+
+	if err := cl.Set(ctx, "a", []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Set(ctx, "b", []byte("3")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cl.Restore(ctx, &backup); err != nil {
+		t.Fatal(err)
+	}
+
+	c.WaitForApplied()
+	requireValue(t, c, "a", "1")
+	requireMissing(t, c, "b")
+}
+
+// TestRestoreIndices checks that the revisions, lease IDs, fencing tokens
+// and message IDs issued after a restore are higher than those restored,
+// though the snapshot comes from a cluster further along its log.
+func TestRestoreIndices(t *testing.T) {
+	ctx := context.Background()
+	source := testcluster.New(t, 1)
+	leader := source.WaitForLeader().Raftd()
+
+	for i := 0; i < 20; i++ {
+		if _, err := leader.Set(ctx, &raftdv1.SetRequest{Key: "filler", Value: []byte("x")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lease, err := leader.Grant(ctx, &raftdv1.GrantRequest{Ttl: durationpb.New(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := leader.TryLock(ctx, &raftdv1.TryLockRequest{Name: "l", LeaseId: lease.LeaseId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leader.Enqueue(ctx, &raftdv1.EnqueueRequest{Queue: "q", Body: []byte("m")}); err != nil {
+		t.Fatal(err)
+	}
+	dequeued, err := leader.Dequeue(ctx, &raftdv1.DequeueRequest{Queue: "q", VisibilityTimeout: durationpb.New(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := leader.Set(ctx, &raftdv1.SetRequest{Key: "k", Value: []byte("old")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leader.Compact(ctx, &raftdv1.CompactRequest{Revision: set.Revision - 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	future := leader.Raft().Snapshot()
+	if err := future.Error(); err != nil {
+		t.Fatal(err)
+	}
+	_, rc, err := future.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	if err := cl.Restore(ctx, bytes.NewReader(backup)); err != nil {
+		t.Fatal(err)
+	}
+	leader = c.WaitForLeader().Raftd()
+
+	granted, err := leader.Grant(ctx, &raftdv1.GrantRequest{Ttl: durationpb.New(time.Minute)})
+	if err != nil || granted.LeaseId <= set.Revision {
+		t.Fatalf("grant after restore = %v, %v, want a lease ID above %d", granted, err, set.Revision)
+	}
+	locked, err := leader.TryLock(ctx, &raftdv1.TryLockRequest{Name: "l2", LeaseId: granted.LeaseId})
+	if err != nil || !locked.Acquired || locked.Token <= lock.Token {
+		t.Fatalf("lock after restore = %v, %v, want a token above %d", locked, err, lock.Token)
+	}
+	enqueued, err := leader.Enqueue(ctx, &raftdv1.EnqueueRequest{Queue: "q", Body: []byte("n")})
+	if err != nil || enqueued.Id <= dequeued.Message.GetId() {
+		t.Fatalf("enqueue after restore = %v, %v, want an ID above %d", enqueued, err, dequeued.Message.GetId())
+	}
+	length, err := leader.Length(ctx, &raftdv1.LengthRequest{Queue: "q"})
+	if err != nil || length.Visible != 1 || length.InFlight != 1 {
+		t.Fatalf("length after restore = %v, %v, want 1 visible and 1 in flight", length, err)
+	}
+	if err := cl.Set(ctx, "k", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := cl.Get(ctx, "k", client.Linearizable(), client.AtRevision(set.Revision)); err != nil || string(value) != "old" {
+		t.Fatalf("get k at the restored revision = %q, %v, want old", value, err)
+	}
+	if err := cl.Compact(ctx, 5); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("compact below the restored compaction point: got %v, want FailedPrecondition", err)
+	}
+
+	// A seeded node starts past the restored indices as well.
+	dir := t.TempDir()
+	path := filepath.Join(dir, "backup.snap")
+	if err := os.WriteFile(path, backup, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Seed(filepath.Join(dir, "raft"), "seed", "seed", path); err != nil {
+		t.Fatal(err)
+	}
+	config := raft.DefaultConfig()
+	config.HeartbeatTimeout = 100 * time.Millisecond
+	config.ElectionTimeout = 100 * time.Millisecond
+	config.LeaderLeaseTimeout = 50 * time.Millisecond
+	_, transport := raft.NewInmemTransport("seed")
+	seeded, err := server.NewRaftd(server.Config{
+		RaftDir:    filepath.Join(dir, "raft"),
+		RaftNodeID: "seed",
+		Raft:       config,
+		Transport:  transport,
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer seeded.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for seeded.Raft().State() != raft.Leader && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	resp, err := seeded.Set(ctx, &raftdv1.SetRequest{Key: "k", Value: []byte("seeded")})
+	if err != nil || resp.Revision <= set.Revision {
+		t.Fatalf("set on the seeded node = %v, %v, want a revision above %d", resp, err, set.Revision)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := cl.Increment(ctx, "n", 1); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	n, err := cl.Decrement(ctx, "n", 50, client.WithMin(0))
+	if err != nil || n != 50 {
+		t.Fatalf("decrement = %d, %v, want 50", n, err)
+	}
+	if _, err := cl.Decrement(ctx, "n", 51, client.WithMin(0)); status.Code(err) != codes.OutOfRange {
+		t.Fatalf("decrement below min: got %v, want OutOfRange", err)
+	}
+	if _, err := cl.Increment(ctx, "n", math.MaxInt64); status.Code(err) != codes.OutOfRange {
+		t.Fatalf("overflow: got %v, want OutOfRange", err)
+	}
+
+	if n, err := cl.Increment(ctx, "m", 1, client.WithInitial(41)); err != nil || n != 42 {
+		t.Fatalf("increment from initial = %d, %v, want 42", n, err)
+	}
+
+	if err := cl.Set(ctx, "s", []byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.Increment(ctx, "s", 1); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("increment of a string: got %v, want FailedPrecondition", err)
+	}
+
+	c.WaitForApplied()
+	requireValue(t, c, "n", "50")
+	requireValue(t, c, "s", "abc")
+}
+
+func TestLock(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	l1, err := cl.GrantLease(ctx, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l1.Revoke(ctx)
+
+	other := c.Client()
+	l2, err := other.GrantLease(ctx, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t1, err := cl.Lock(ctx, "l", l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, acquired, err := other.TryLock(ctx, "l", l2); err != nil || acquired {
+		t.Fatalf("try lock of a held lock = %v, %v, want not acquired", acquired, err)
+	}
+
+	locked := make(chan uint64)
+	go func() {
+		token, err := other.Lock(ctx, "l", l2)
+		if err != nil {
+			t.Error(err)
+		}
+		locked <- token
+	}()
+
+	if err := cl.Unlock(ctx, "l", l1); err != nil {
+		t.Fatal(err)
+	}
+	t2 := <-locked
+	if t2 <= t1 {
+		t.Fatalf("token %d after %d, want it to grow", t2, t1)
+	}
+	if err := cl.Unlock(ctx, "l", l1); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("unlock of a lock held by another lease: got %v, want FailedPrecondition", err)
+	}
+
+	if err := l2.Revoke(ctx); err != nil {
+		t.Fatal(err)
+	}
+	<-l2.Done()
+	if !errors.Is(l2.Err(), context.Canceled) {
+		t.Fatalf("lease error = %v, want Canceled", l2.Err())
+	}
+
+	// A lease that is not kept alive expires, and its lock is released.
+	leader := c.WaitForLeader().Raftd()
+	grant, err := leader.Grant(ctx, &raftdv1.GrantRequest{Ttl: durationpb.New(500 * time.Millisecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	try, err := leader.TryLock(ctx, &raftdv1.TryLockRequest{Name: "l", LeaseId: grant.LeaseId})
+	if err != nil || !try.Acquired {
+		t.Fatalf("try lock of a released lock = %v, %v, want acquired", try, err)
+	}
+	t3, err := cl.Lock(ctx, "l", l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if t3 <= try.Token {
+		t.Fatalf("token %d after %d, want it to grow", t3, try.Token)
+	}
+}
+
+func TestElection(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	observed := cl.Observe(ctx, "e")
+	if leader := <-observed; leader != nil {
+		t.Fatalf("leader of a new election = %+v, want none", leader)
+	}
+
+	l1, err := cl.GrantLease(ctx, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l1.Revoke(context.Background())
+	l2, err := cl.GrantLease(ctx, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l2.Revoke(context.Background())
+
+	if _, err := cl.Campaign(ctx, "e", l1, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if leader := <-observed; leader == nil || leader.LeaseID != l1.ID() || string(leader.Value) != "a" {
+		t.Fatalf("leader = %+v, want a", leader)
+	}
+
+	elected := make(chan error)
+	go func() {
+		_, err := cl.Campaign(ctx, "e", l2, []byte("b"))
+		elected <- err
+	}()
+
+	if err := cl.Resign(ctx, "e", l1); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-elected; err != nil {
+		t.Fatal(err)
+	}
+	for leader := range observed {
+		if leader != nil && string(leader.Value) == "b" {
+			return
+		}
+	}
+	t.Fatal("observe ended before b was elected")
+}
+
+func TestQueue(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	for _, body := range []string{"a", "b", "c"} {
+		if _, err := cl.Enqueue(ctx, "q", []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if msg, err := cl.Peek(ctx, "q", client.Linearizable()); err != nil || string(msg.Body) != "a" {
+		t.Fatalf("peek = %v, %v, want a", msg, err)
+	}
+
+	dequeue := func(want string, attempts uint32) *client.Message {
+		t.Helper()
+		msg, err := cl.Dequeue(ctx, "q", 200*time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(msg.Body) != want || msg.Attempts != attempts {
+			t.Fatalf("dequeue = %q after %d attempts, want %q after %d", msg.Body, msg.Attempts, want, attempts)
+		}
+		return msg
+	}
+
+	a := dequeue("a", 1)
+	b := dequeue("b", 1)
+	if err := cl.Ack(ctx, "q", a); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Nack(ctx, "q", b); err != nil {
+		t.Fatal(err)
+	}
+	b = dequeue("b", 2)
+	if err := cl.Ack(ctx, "q", b); err != nil {
+		t.Fatal(err)
+	}
+
+	// A delivery that is not settled in time is redelivered, and the
+	// stale receipt is rejected.
+	first := dequeue("c", 1)
+	if _, err := cl.Dequeue(ctx, "q", 0); !errors.Is(err, client.ErrEmpty) {
+		t.Fatalf("dequeue of an empty queue: got %v, want ErrEmpty", err)
+	}
+	c.WaitFor("redelivery", func() bool {
+		visible, _, err := cl.Length(ctx, "q", client.Linearizable())
+		return err == nil && visible == 1
+	})
+	second := dequeue("c", 2)
+	if err := cl.Ack(ctx, "q", first); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("ack of a timed out delivery: got %v, want FailedPrecondition", err)
+	}
+	if err := cl.Ack(ctx, "q", second); err != nil {
+		t.Fatal(err)
+	}
+
+	if visible, inFlight, err := cl.Length(ctx, "q", client.Linearizable()); err != nil || visible != 0 || inFlight != 0 {
+		t.Fatalf("length = %d, %d, %v, want empty", visible, inFlight, err)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	if err := cl.CreateNamespace(ctx, "a", client.Quota{MaxKeys: 2, MaxValueSize: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.CreateNamespace(ctx, "a", client.Quota{}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("create of an existing namespace: got %v, want AlreadyExists", err)
+	}
+
+	a, err := client.New(client.Config{Endpoints: c.Endpoints(), Namespace: "a", MaxRetries: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if err := cl.Set(ctx, "k", []byte("default")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Set(ctx, "k", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := a.Get(ctx, "k", client.Linearizable()); err != nil || string(value) != "a" {
+		t.Fatalf("get k in a = %q, %v, want a", value, err)
+	}
+	requireValue(t, c, "k", "default")
+
+	if err := a.Set(ctx, "l", []byte("toolong")); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("set of a large value: got %v, want ResourceExhausted", err)
+	}
+	if err := a.Set(ctx, "l", []byte("l")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Set(ctx, "m", []byte("m")); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("set of a third key: got %v, want ResourceExhausted", err)
+	}
+	if _, err := a.Get(ctx, "m", client.Linearizable()); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("get of a rejected key: got %v, want ErrNotFound", err)
+	}
+	if err := a.Set(ctx, "l", []byte("ll")); err != nil {
+		t.Fatalf("overwrite within the quota: %v", err)
+	}
+
+	c.WaitForApplied()
+	ns, err := cl.GetNamespace(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns.Keys != 2 || ns.Bytes != int64(len("k")+len("a")+len("l")+len("ll")) {
+		t.Fatalf("usage = %d keys, %d bytes", ns.Keys, ns.Bytes)
+	}
+
+	if err := cl.DeleteNamespace(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Get(ctx, "k", client.Linearizable()); !errors.Is(err, client.ErrNamespaceNotFound) {
+		t.Fatalf("get in a deleted namespace: got %v, want ErrNamespaceNotFound", err)
+	}
+	if err := a.Set(ctx, "k", []byte("a")); !errors.Is(err, client.ErrNamespaceNotFound) {
+		t.Fatalf("set in a deleted namespace: got %v, want ErrNamespaceNotFound", err)
+	}
+}
+
+func TestStorageLimits(t *testing.T) {
+	c := testcluster.New(t, 3, testcluster.WithConfig(func(cfg *server.Config) {
+		cfg.MaxKeySize = 8
+		cfg.MaxValueSize = 16
+		cfg.StorageQuota = 64
+	}))
+	cl := c.Client()
+	ctx := context.Background()
+
+	if err := cl.Set(ctx, "longerkey", []byte("v")); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("set of a long key: got %v, want InvalidArgument", err)
+	}
+	if err := cl.Set(ctx, "k", bytes.Repeat([]byte("v"), 17)); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("set of a large value: got %v, want InvalidArgument", err)
+	}
+
+	// The write that crosses the quota succeeds and raises the alarm.
+	value := bytes.Repeat([]byte("v"), 16)
+	for _, key := range []string{"k1", "k2", "k3", "k4"} {
+		if err := cl.Set(ctx, key, value); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+	alarm := func() bool {
+		resp, err := c.WaitForLeader().Raftd().Status(ctx, &raftdv1.StatusRequest{})
+		return err == nil && resp.Storage.GetAlarm()
+	}
+	c.WaitFor("the storage alarm", alarm)
+
+	if err := cl.Set(ctx, "k5", []byte("v")); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("set while alarmed: got %v, want ResourceExhausted", err)
+	}
+	if _, err := cl.Increment(ctx, "n", 1); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("increment while alarmed: got %v, want ResourceExhausted", err)
+	}
+
+	// The history of a deleted key still counts until it is compacted.
+	if err := cl.Delete(ctx, "k1"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.WaitForLeader().Raftd().Status(ctx, &raftdv1.StatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Storage.GetAlarm() {
+		t.Fatal("the alarm cleared before the history was compacted")
+	}
+	if err := cl.Compact(ctx, resp.Revision); err != nil {
+		t.Fatal(err)
+	}
+	c.WaitFor("the storage alarm to clear", func() bool { return !alarm() })
+	if err := cl.Set(ctx, "k5", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStorageLimitsQueue(t *testing.T) {
+	c := testcluster.New(t, 3, testcluster.WithConfig(func(cfg *server.Config) {
+		cfg.MaxValueSize = 16
+		cfg.StorageQuota = 64
+	}))
+	cl := c.Client()
+	ctx := context.Background()
+
+	if _, err := cl.Enqueue(ctx, "q", bytes.Repeat([]byte("m"), 17)); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("enqueue of a large body: got %v, want InvalidArgument", err)
+	}
+	lease, err := cl.GrantLease(ctx, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Queued messages count toward the quota like keys and values.
+	for range 4 {
+		if _, err := cl.Enqueue(ctx, "q", bytes.Repeat([]byte("m"), 16)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alarm := func() bool {
+		resp, err := c.WaitForLeader().Raftd().Status(ctx, &raftdv1.StatusRequest{})
+		return err == nil && resp.Storage.GetAlarm()
+	}
+	c.WaitFor("the storage alarm", alarm)
+
+	if _, err := cl.Enqueue(ctx, "q", []byte("m")); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("enqueue while alarmed: got %v, want ResourceExhausted", err)
+	}
+	if _, _, err := cl.TryLock(ctx, "l", lease); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("lock while alarmed: got %v, want ResourceExhausted", err)
+	}
+
+	// Acknowledging a message frees its space and clears the alarm.
+	msg, err := cl.Dequeue(ctx, "q", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Ack(ctx, "q", msg); err != nil {
+		t.Fatal(err)
+	}
+	c.WaitFor("the storage alarm to clear", func() bool { return !alarm() })
+	if _, _, err := cl.TryLock(ctx, "l", lease); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	c := testcluster.New(t, 3, testcluster.WithConfig(func(cfg *server.Config) {
+		cfg.MethodRateLimits = map[string]server.RateLimit{
+			raftdv1.KVService_Set_FullMethodName: {PerClientRate: 2, PerClientBurst: 2},
+		}
+	}))
+	ctx := context.Background()
+
+	conn, err := grpc.NewClient(c.WaitForLeader().GRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	kv := raftdv1.NewKVServiceClient(conn)
+
+	for i := range 2 {
+		if _, err := kv.Set(ctx, &raftdv1.SetRequest{Key: "k", Value: []byte("v")}); err != nil {
+			t.Fatalf("set %d: %v", i, err)
+		}
+	}
+	_, err = kv.Set(ctx, &raftdv1.SetRequest{Key: "k", Value: []byte("v")})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("set over the limit: got %v, want ResourceExhausted", err)
+	}
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = info
+		}
+	}
+	if retryInfo == nil || retryInfo.RetryDelay.AsDuration() <= 0 {
+		t.Fatalf("rejection carries no retry delay: %v", status.Convert(err).Details())
+	}
+
+	// Other RPCs have limits of their own.
+	if _, err := kv.Get(ctx, &raftdv1.GetRequest{Key: "k"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	// Clients are told apart by address, not by the header they choose.
+	spoofed := metadata.AppendToOutgoingContext(ctx, server.ClientIDHeader, "someone-else")
+	if _, err := kv.Set(spoofed, &raftdv1.SetRequest{Key: "k", Value: []byte("v")}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("set under another client ID: got %v, want ResourceExhausted", err)
+	}
+}
+
+func TestApplyDeadlines(t *testing.T) {
+	// A leader lease long enough to keep the leader while its writes wait
+	// for the quorum it lost.
+	c := testcluster.New(t, 3,
+		testcluster.WithRaftConfig(func(config *raft.Config) {
+			config.HeartbeatTimeout = time.Second
+			config.ElectionTimeout = time.Second
+			config.LeaderLeaseTimeout = time.Second
+		}),
+		testcluster.WithConfig(func(cfg *server.Config) {
+			cfg.ApplyTimeout = 200 * time.Millisecond
+		}),
+	)
+
+	leader := c.WaitForLeader()
+	for _, follower := range c.Followers() {
+		c.Kill(follower)
+	}
+	raftd := leader.Raftd()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := raftd.Set(ctx, &raftdv1.SetRequest{Key: "k", Value: []byte("v")})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("set past the deadline of the call: got %v, want DeadlineExceeded", err)
+	}
+
+	_, err = raftd.Set(context.Background(), &raftdv1.SetRequest{Key: "k", Value: []byte("v")})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("set past the apply timeout: got %v, want Unavailable", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = raftd.Set(ctx, &raftdv1.SetRequest{Key: "k", Value: []byte("v")})
+	if status.Code(err) != codes.Canceled {
+		t.Fatalf("set of a cancelled call: got %v, want Canceled", err)
+	}
+}
+
+func TestHistoricalReads(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+	leader := c.WaitForLeader().Raftd()
+
+	var revisions []uint64
+	for _, value := range []string{"v1", "v2"} {
+		resp, err := leader.Set(ctx, &raftdv1.SetRequest{Key: "k", Value: []byte(value)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		revisions = append(revisions, resp.Revision)
+	}
+	resp, err := leader.Delete(ctx, &raftdv1.DeleteRequest{Key: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revisions = append(revisions, resp.Revision)
+
+	for i, want := range []string{"v1", "v2"} {
+		value, err := cl.Get(ctx, "k", client.Linearizable(), client.AtRevision(revisions[i]))
+		if err != nil || string(value) != want {
+			t.Fatalf("get k at %d = %q, %v, want %s", revisions[i], value, err, want)
+		}
+	}
+	if _, err := cl.Get(ctx, "k", client.Linearizable(), client.AtRevision(revisions[2])); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("get k after its deletion: got %v, want ErrNotFound", err)
+	}
+	kvs, err := cl.Range(ctx, "", 0, client.Linearizable(), client.AtRevision(revisions[1]))
+	if err != nil || len(kvs) != 1 || string(kvs[0].Value) != "v2" {
+		t.Fatalf("range at %d = %v, %v", revisions[1], kvs, err)
+	}
+	if _, err := cl.Get(ctx, "k", client.Linearizable(), client.AtRevision(revisions[2]+100)); status.Code(err) != codes.OutOfRange {
+		t.Fatalf("get at a future revision: got %v, want OutOfRange", err)
+	}
+
+	// Reads before the compaction point fail.
+	if err := cl.Compact(ctx, revisions[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.Get(ctx, "k", client.Linearizable(), client.AtRevision(revisions[0])); status.Code(err) != codes.OutOfRange {
+		t.Fatalf("get at a compacted revision: got %v, want OutOfRange", err)
+	}
+	if value, err := cl.Get(ctx, "k", client.Linearizable(), client.AtRevision(revisions[1])); err != nil || string(value) != "v2" {
+		t.Fatalf("get k at the compaction point = %q, %v, want v2", value, err)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	c := testcluster.New(t, 3, testcluster.WithConfig(func(cfg *server.Config) {
+		cfg.AuditReplicated = true
+		cfg.AuditFile = filepath.Join(cfg.RaftDir, "audit.jsonl")
+		cfg.AuditMaxSize = 1024
+	}))
+	cl := c.Client()
+	ctx := context.Background()
+
+	// Followers receive the records applied after the tail started.
+	followCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	followed := make(chan client.AuditRecord, 100)
+	go func() {
+		_ = cl.TailAudit(followCtx, 0, 0, true, func(record client.AuditRecord) error {
+			followed <- record
+			return nil
+		})
+	}()
+
+	for i := 0; i < 20; i++ {
+		if err := cl.Set(ctx, fmt.Sprintf("k%d", i), []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cl.CompareAndSwap(ctx, "k0", []byte("other"), []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The node serving the tail may lag behind.
+	var records []client.AuditRecord
+	c.WaitFor("the cas to be audited", func() bool {
+		records = nil
+		err := cl.TailAudit(ctx, 0, 0, false, func(record client.AuditRecord) error {
+			records = append(records, record)
+			return nil
+		})
+		return err == nil && len(records) > 0 && records[len(records)-1].Op == "cas"
+	})
+	var sets int
+	for _, record := range records {
+		if record.Op != "set" {
+			continue
+		}
+		sets++
+		if record.ClientAddr == "" || record.Term == 0 || record.Time.IsZero() || record.Result != "ok" {
+			t.Fatalf("incomplete record %+v", record)
+		}
+	}
+	if sets != 20 {
+		t.Fatalf("audit log has %d sets, want 20", sets)
+	}
+	if last := records[len(records)-1]; last.Op != "cas" || last.Key != "k0" || last.Result != "condition_failed" {
+		t.Fatalf("last record = %+v, want a failed cas of k0", last)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for key := ""; key != "k19"; {
+		select {
+		case record := <-followed:
+			key = record.Key
+		case <-timeout:
+			t.Fatal("timed out following the audit log")
+		}
+	}
+
+	// Every node records the commands it applies to its own file, which is
+	// rotated as it grows.
+	for _, node := range c.Followers() {
+		path := filepath.Join(node.Dir, "audit.jsonl")
+		c.WaitFor("the audit file to rotate", func() bool {
+			_, err := os.Stat(path + ".1")
+			return err == nil
+		})
+	}
+}
+
+func TestChanges(t *testing.T) {
+	var mu sync.Mutex
+	var delivered []*raftdv1.Change
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch raftdv1.SubscribeChangesResponse
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		delivered = append(delivered, batch.Changes...)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	c := testcluster.New(t, 3, testcluster.WithConfig(func(cfg *server.Config) {
+		file, err := server.NewFileSink(filepath.Join(cfg.RaftDir, "changes.jsonl"), server.ChangeFormatJSON, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.ChangeSinks = map[string]server.ChangeSink{
+			"file":    file,
+			"webhook": server.NewWebhookSink(webhook.URL, nil),
+		}
+	}))
+	cl := c.Client()
+	ctx := context.Background()
+
+	subscribeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	consumed := make(chan client.Change, 100)
+	go func() {
+		_ = cl.SubscribeChanges(subscribeCtx, "test", func(changes []client.Change) error {
+			for _, change := range changes {
+				consumed <- change
+			}
+			return nil
+		})
+	}()
+
+	for i := 0; i < 5; i++ {
+		if err := cl.Set(ctx, fmt.Sprintf("k%d", i), []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cl.Delete(ctx, "k0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delivery resumes from the checkpoint after a leader change.
+	c.WaitFor("the changes to be delivered", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(delivered) >= 6
+	})
+	c.Kill(c.WaitForLeader())
+	c.WaitForLeader()
+	if err := cl.Set(ctx, "after", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes come in revision order, some possibly twice.
+	dedup := func(changes []*raftdv1.Change) []string {
+		var keys []string
+		var last uint64
+		for _, change := range changes {
+			if change.Revision < last {
+				t.Fatalf("change at %d delivered after %d", change.Revision, last)
+			}
+			if change.Revision > last {
+				key := change.Key
+				if change.Deleted {
+					key = "-" + key
+				}
+				keys = append(keys, key)
+			}
+			last = change.Revision
+		}
+		return keys
+	}
+	want := "k0 k1 k2 k3 k4 -k0 after"
+	c.WaitFor("the webhook to get every change", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Join(dedup(delivered), " ") == want
+	})
+
+	var changes []*raftdv1.Change
+	timeout := time.After(5 * time.Second)
+	for strings.Join(dedup(changes), " ") != want {
+		select {
+		case change := <-consumed:
+			changes = append(changes, &raftdv1.Change{Revision: change.Revision, Key: change.Key, Deleted: change.Deleted})
+		case <-timeout:
+			t.Fatalf("consumer got %v, want %s", dedup(changes), want)
+		}
+	}
+}
+
+func TestCompactionKeepsUnacknowledgedChanges(t *testing.T) {
+	c := testcluster.New(t, 3, testcluster.WithConfig(func(cfg *server.Config) {
+		cfg.CompactionRevisions = 1
+		cfg.CompactionInterval = 50 * time.Millisecond
+	}))
+	cl := c.Client()
+	ctx := context.Background()
+
+	leaderStatus := func() *raftdv1.StatusResponse {
+		resp, err := c.WaitForLeader().Raftd().Status(ctx, &raftdv1.StatusRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if err := cl.Set(ctx, "k", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint := leaderStatus().Revision
+	if err := cl.AckChanges(ctx, "test", checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	for i := 2; i <= 5; i++ {
+		if err := cl.Set(ctx, "k", []byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Neither a manual compaction nor the retention policy goes past the
+	// checkpoint of the consumer.
+	last := leaderStatus().Revision
+	if err := cl.Compact(ctx, last); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("compact past the checkpoint: got %v, want FailedPrecondition", err)
+	}
+	c.WaitFor("the history to be compacted to the checkpoint", func() bool {
+		return leaderStatus().CompactRevision == checkpoint
+	})
+	time.Sleep(200 * time.Millisecond)
+	if compacted := leaderStatus().CompactRevision; compacted != checkpoint {
+		t.Fatalf("compacted to %d, past the checkpoint %d", compacted, checkpoint)
+	}
+
+	// Once the consumer acknowledges the changes, they can go.
+	if err := cl.AckChanges(ctx, "test", last); err != nil {
+		t.Fatal(err)
+	}
+	c.WaitFor("the history to be compacted past the checkpoint", func() bool {
+		return leaderStatus().CompactRevision > checkpoint
+	})
+}
+
+func TestCompactionForgetsGoneConsumers(t *testing.T) {
+	c := testcluster.New(t, 3, testcluster.WithConfig(func(cfg *server.Config) {
+		cfg.CompactionRevisions = 1
+		cfg.CompactionInterval = 50 * time.Millisecond
+		cfg.ChangeConsumerRetention = 2 * time.Second
+	}))
+	cl := c.Client()
+	ctx := context.Background()
+
+	compacted := func() uint64 {
+		resp, err := c.WaitForLeader().Raftd().Status(ctx, &raftdv1.StatusRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.CompactRevision
+	}
+	ackAndWrite := func(consumer string) uint64 {
+		if err := cl.Set(ctx, "k", []byte(consumer)); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.WaitForLeader().Raftd().Status(ctx, &raftdv1.StatusRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cl.AckChanges(ctx, consumer, resp.Revision); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			if err := cl.Set(ctx, "k", []byte(fmt.Sprint(i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		c.WaitFor("the history to be compacted to the checkpoint", func() bool {
+			return compacted() == resp.Revision
+		})
+		return resp.Revision
+	}
+
+	// A consumer removed for good no longer holds the history back.
+	checkpoint := ackAndWrite("removed")
+	if err := cl.RemoveChangeConsumer(ctx, "removed"); err != nil {
+		t.Fatal(err)
+	}
+	c.WaitFor("the history to be compacted past the removed consumer", func() bool {
+		return compacted() > checkpoint
+	})
+	if err := cl.RemoveChangeConsumer(ctx, "removed"); status.Code(err) != codes.NotFound {
+		t.Fatalf("remove of a removed consumer: got %v, want NotFound", err)
+	}
+
+	// Neither does one that stopped acknowledging changes for longer than
+	// the retention.
+	checkpoint = ackAndWrite("gone")
+	c.WaitFor("the history to be compacted past the gone consumer", func() bool {
+		return compacted() > checkpoint
+	})
+}
+
+func TestWatchTxn(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	if err := cl.Set(ctx, "job/1", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.WaitForLeader().Raftd().Status(ctx, &raftdv1.StatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watched := make(chan client.Change, 100)
+	go func() {
+		_ = cl.Watch(watchCtx, "job/", func(changes []client.Change) error {
+			for _, change := range changes {
+				watched <- change
+			}
+			return nil
+		}, client.WithPrefix(), client.AfterRevision(resp.Revision-1))
+	}()
+
+	// Claim the job unless someone else did.
+	claim := func(owner string) bool {
+		succeeded, err := cl.Txn(ctx,
+			[]client.Compare{client.ValueIs("job/1", []byte("new")), client.KeyMissing("owner/1")},
+			[]client.TxnOp{client.SetOp("job/1", []byte("claimed")), client.SetOp("owner/1", []byte(owner))},
+			nil,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return succeeded
+	}
+	if !claim("a") {
+		t.Fatal("the first claim failed")
+	}
+	if claim("b") {
+		t.Fatal("the second claim succeeded")
+	}
+	if value, err := cl.Get(ctx, "owner/1", client.Linearizable()); err != nil || string(value) != "a" {
+		t.Fatalf("get owner/1 = %q, %v, want a", value, err)
+	}
+
+	if err := cl.Delete(ctx, "job/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The watch sees the changes under the prefix alone, in order.
+	var got []string
+	timeout := time.After(5 * time.Second)
+	for len(got) < 3 {
+		select {
+		case change := <-watched:
+			if change.Deleted {
+				got = append(got, "-"+change.Key)
+			} else {
+				got = append(got, change.Key+"="+string(change.Value))
+			}
+		case <-timeout:
+			t.Fatalf("watch got %v", got)
+		}
+	}
+	if want := "job/1=new job/1=claimed -job/1"; strings.Join(got, " ") != want {
+		t.Fatalf("watch got %q, want %q", strings.Join(got, " "), want)
+	}
+
+	_, err = cl.Txn(ctx, nil, []client.TxnOp{client.SetOp("k", nil), client.DeleteOp("k")}, nil)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("txn writing a key twice: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestWatchIdleNamespace(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	if err := cl.CreateNamespace(ctx, "a", client.Quota{}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := client.New(client.Config{Endpoints: c.Endpoints(), Namespace: "a", MaxRetries: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watched := make(chan client.Change, 100)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- a.Watch(watchCtx, "k", func(changes []client.Change) error {
+			for _, change := range changes {
+				watched <- change
+			}
+			return nil
+		})
+	}()
+
+	// The log moves on in the default namespace and is compacted past the
+	// revision the watch started at, while nothing changes in a.
+	for i := 0; i < 5; i++ {
+		if err := cl.Set(ctx, "k", []byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := c.WaitForLeader().Raftd().Status(ctx, &raftdv1.StatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.WaitForApplied()
+	time.Sleep(200 * time.Millisecond)
+	if err := cl.Compact(ctx, resp.Revision); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Set(ctx, "k", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case change := <-watched:
+		if change.Key != "k" || string(change.Value) != "a" {
+			t.Fatalf("watch got %+v, want k=a", change)
+		}
+	case err := <-watchErr:
+		t.Fatalf("watch of an idle namespace failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch got no change")
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	c := testcluster.New(t, 3)
+	cl := c.Client()
+	ctx := context.Background()
+
+	if err := cl.CreateNamespace(ctx, "a", client.Quota{MaxKeys: 4}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := client.New(client.Config{Endpoints: c.Endpoints(), Namespace: "a", MaxRetries: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	pairs := func(kvs ...client.KeyValue) func() (client.KeyValue, error) {
+		return func() (client.KeyValue, error) {
+			if len(kvs) == 0 {
+				return client.KeyValue{}, io.EOF
+			}
+			kv := kvs[0]
+			kvs = kvs[1:]
+			return kv, nil
+		}
+	}
+	kvs := []client.KeyValue{
+		{Key: "x/1", Value: []byte("1")},
+		{Key: "x/2", Value: []byte("2")},
+		{Key: "y/1", Value: []byte("3")},
+	}
+
+	// A dry run validates the pairs without writing them.
+	result, err := a.Import(ctx, pairs(kvs...), true, nil)
+	if err != nil || result.Keys != 3 || result.Bytes != 12 {
+		t.Fatalf("dry run = %+v, %v, want 3 keys of 12 bytes", result, err)
+	}
+	if got, err := a.Range(ctx, "", 0, client.Linearizable()); err != nil || len(got) != 0 {
+		t.Fatalf("range after a dry run = %v, %v, want empty", got, err)
+	}
+
+	var sent uint64
+	result, err = a.Import(ctx, pairs(kvs...), false, func(keys uint64, _ int64) { sent = keys })
+	if err != nil || result.Keys != 3 || sent != 3 {
+		t.Fatalf("import = %+v, %v, progress %d, want 3 keys", result, err, sent)
+	}
+
+	var exported []client.KeyValue
+	revision, err := a.Export(ctx, "x/", func(batch []client.KeyValue) error {
+		exported = append(exported, batch...)
+		return nil
+	}, client.Linearizable())
+	if err != nil || revision < result.Revision {
+		t.Fatalf("export = %d, %v, want a revision of at least %d", revision, err, result.Revision)
+	}
+	if len(exported) != 2 || exported[0].Key != "x/1" || string(exported[1].Value) != "2" {
+		t.Fatalf("exported %v, want x/1 and x/2", exported)
+	}
+
+	// A batch going over the quota is rejected as a whole.
+	_, err = a.Import(ctx, pairs(client.KeyValue{Key: "z/1", Value: []byte("z")}, client.KeyValue{Key: "z/2", Value: []byte("z")}), false, nil)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("import over the quota: got %v, want ResourceExhausted", err)
+	}
+	if _, err := a.Get(ctx, "z/1", client.Linearizable()); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("get of a rejected key: got %v, want ErrNotFound", err)
+	}
+	if err := a.Set(ctx, "z/1", []byte("z")); err != nil {
+		t.Fatalf("set within the quota after a rejected import: %v", err)
+	}
+}
+
+func TestLogStoreMigration(t *testing.T) {
+	var mu sync.Mutex
+	backends := make(map[string]string)
+	c := testcluster.New(t, 3, testcluster.WithConfig(func(cfg *server.Config) {
+		mu.Lock()
+		defer mu.Unlock()
+		cfg.LogStore = backends[cfg.RaftDir]
+	}))
+	cl := c.Client()
+	ctx := context.Background()
+	c.WaitForLeader()
+
+	if err := cl.Set(ctx, "k", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	c.WaitForApplied()
+
+	follower := c.Followers()[0]
+	c.Kill(follower)
+	if from, err := server.MigrateLogStore(follower.Dir, server.LogStoreWAL); err != nil || from != server.LogStoreBolt {
+		t.Fatalf("migrate = %s, %v, want from bolt", from, err)
+	}
+	if _, err := server.MigrateLogStore(follower.Dir, server.LogStoreWAL); err == nil {
+		t.Fatal("second migration succeeded")
+	}
+
+	// The node refuses to start without the log it has.
+	_, transport := raft.NewInmemTransport("")
+	defer transport.Close()
+	if _, err := server.NewRaftd(server.Config{RaftDir: follower.Dir, RaftNodeID: follower.ID, Transport: transport}); err == nil {
+		t.Fatal("start with the bolt log store succeeded after migrating to wal")
+	}
+
+	mu.Lock()
+	backends[follower.Dir] = server.LogStoreWAL
+	mu.Unlock()
+	c.Restart(follower)
+	if err := cl.Set(ctx, "k", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	c.WaitForApplied()
+	requireValue(t, c, "k", "v2")
+
+	// The entries survive a restart with the wal.
+	c.Kill(follower)
+	c.Restart(follower)
+	c.WaitForApplied()
+	requireValue(t, c, "k", "v2")
+}
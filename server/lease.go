@@ -0,0 +1,191 @@
+package server
+
+import (
+	"sync"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// leases is the lease and lock table of the FSM. Deadlines are in the
+// leader's clock as recorded in the log, so every node agrees on them; the
+// leader alone decides when to expire a lease, see Raftd.expireLeases.
+//
+// Unlike the dedup table it is read by RPC handlers, so it is guarded by a
+// mutex, and it signals every change of the locks to the calls waiting on
+// them. Entries are replaced rather than modified, so they can be shared.
+type leases struct {
+	mu      sync.Mutex
+	leases  map[uint64]*raftdv1.Lease
+	locks   map[string]*raftdv1.LockHolder
+	changed chan struct{}
+}
+
+func newLeases() *leases {
+	return &leases{
+		leases:  make(map[uint64]*raftdv1.Lease),
+		locks:   make(map[string]*raftdv1.LockHolder),
+		changed: make(chan struct{}),
+	}
+}
+
+// notify wakes up the waiters of the locks. It must be called with mu
+// held.
+func (l *leases) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+func (l *leases) get(id uint64) *raftdv1.Lease {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.leases[id]
+}
+
+func (l *leases) grant(id uint64, ttl, now int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.leases[id] = &raftdv1.Lease{Id: id, Ttl: ttl, Deadline: deadline(now, ttl)}
+}
+
+// keepAlive renews the lease and reports whether it exists.
+func (l *leases) keepAlive(id uint64, now int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lease, ok := l.leases[id]
+	if !ok {
+		return false
+	}
+	l.leases[id] = &raftdv1.Lease{Id: id, Ttl: lease.Ttl, Deadline: deadline(now, lease.Ttl)}
+	return true
+}
+
+// revoke removes the lease and releases its locks. It reports whether the
+// lease existed.
+func (l *leases) revoke(id uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.revokeLocked(id)
+}
+
+// expire revokes the lease if its deadline has passed at now.
+func (l *leases) expire(id uint64, now int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lease, ok := l.leases[id]
+	if !ok || lease.Deadline == 0 || lease.Deadline > now {
+		return false
+	}
+	return l.revokeLocked(id)
+}
+
+func (l *leases) revokeLocked(id uint64) bool {
+	if _, ok := l.leases[id]; !ok {
+		return false
+	}
+	delete(l.leases, id)
+
+	released := false
+	for name, holder := range l.locks {
+		if holder.LeaseId == id {
+			delete(l.locks, name)
+			released = true
+		}
+	}
+	if released {
+		l.notify()
+	}
+	return true
+}
+
+// expired returns the leases whose deadline has passed at now.
+func (l *leases) expired(now int64) []*raftdv1.Lease {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var expired []*raftdv1.Lease
+	for _, lease := range l.leases {
+		if lease.Deadline != 0 && lease.Deadline <= now {
+			expired = append(expired, lease)
+		}
+	}
+	return expired
+}
+
+// acquire takes the lock for the lease unless another lease holds it, and
+// returns the holder. Acquiring a lock the lease already holds returns the
+// existing holder. It reports false if the lease does not exist.
+func (l *leases) acquire(name string, leaseID, token uint64, value []byte) (*raftdv1.LockHolder, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.leases[leaseID]; !ok {
+		return nil, false
+	}
+	if holder, ok := l.locks[name]; ok {
+		return holder, true
+	}
+
+	holder := &raftdv1.LockHolder{Name: name, LeaseId: leaseID, Token: token, Value: value}
+	l.locks[name] = holder
+	l.notify()
+	return holder, true
+}
+
+// release frees the lock if the lease holds it, and reports whether it
+// did.
+func (l *leases) release(name string, leaseID uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	holder, ok := l.locks[name]
+	if !ok || holder.LeaseId != leaseID {
+		return false
+	}
+	delete(l.locks, name)
+	l.notify()
+	return true
+}
+
+// holder returns the holder of the lock, or nil, and a channel closed on
+// the next change of any lock.
+func (l *leases) holder(name string) (*raftdv1.LockHolder, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.locks[name], l.changed
+}
+
+// list returns every lease and lock, for snapshots.
+func (l *leases) list() ([]*raftdv1.Lease, []*raftdv1.LockHolder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	leases := make([]*raftdv1.Lease, 0, len(l.leases))
+	for _, lease := range l.leases {
+		leases = append(leases, lease)
+	}
+	locks := make([]*raftdv1.LockHolder, 0, len(l.locks))
+	for _, holder := range l.locks {
+		locks = append(locks, holder)
+	}
+	return leases, locks
+}
+
+// replace swaps in the tables restored from a snapshot.
+func (l *leases) replace(leases map[uint64]*raftdv1.Lease, locks map[string]*raftdv1.LockHolder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.leases = leases
+	l.locks = locks
+	l.notify()
+}
+
+// deadline returns when a lease renewed at now expires, or zero if the
+// time is unknown.
+func deadline(now, ttl int64) int64 {
+	if now == 0 {
+		return 0
+	}
+	return now + ttl
+}
@@ -0,0 +1,352 @@
+package server_test
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"testing"
+)
+
+// This file is a linearizability checker for histories of Get, Set and
+// CompareAndSwap calls, in the style of Porcupine. Keys are independent
+// registers, so the history is checked one key at a time with the
+// Wing & Gong search and the state caching described by Lowe in "Testing
+// for linearizability".
+
+type opKind int
+
+const (
+	opGet opKind = iota
+	opSet
+	opCAS
+)
+
+// pending is the return time of a write whose outcome is unknown. The
+// write may take effect at any point after its call, or never.
+const pending = math.MaxInt64
+
+type operation struct {
+	clientID int
+	key      string
+	kind     opKind
+	call     int64
+	ret      int64
+
+	// value is the value written by Set and CompareAndSwap, or the value
+	// read by Get.
+	value string
+	// expected is the value CompareAndSwap compares with. Nil means the
+	// key must not exist.
+	expected *string
+
+	// found is whether Get saw the key.
+	found bool
+	// swapped is whether CompareAndSwap swapped.
+	swapped bool
+}
+
+// register is the model state of a single key.
+type register struct {
+	value  string
+	exists bool
+}
+
+// step applies op to s and reports whether its output is possible from s.
+func step(s register, op *operation) (bool, register) {
+	switch op.kind {
+	case opGet:
+		return op.found == s.exists && op.value == s.value, s
+	case opSet:
+		return true, register{value: op.value, exists: true}
+	case opCAS:
+		match := s.exists && op.expected != nil && *op.expected == s.value ||
+			!s.exists && op.expected == nil
+		if op.ret != pending && match != op.swapped {
+			return false, s
+		}
+		if match {
+			return true, register{value: op.value, exists: true}
+		}
+		return true, s
+	default:
+		return false, s
+	}
+}
+
+// checkLinearizable reports whether history is linearizable, and if not,
+// the key whose operations are not.
+func checkLinearizable(history []operation) (bool, string) {
+	byKey := make(map[string][]*operation)
+	for i := range history {
+		op := &history[i]
+		byKey[op.key] = append(byKey[op.key], op)
+	}
+
+	for key, ops := range byKey {
+		if !checkRegister(ops) {
+			return false, key
+		}
+	}
+	return true, ""
+}
+
+// entry is a call or return event in the doubly linked history list.
+type entry struct {
+	op    *operation
+	id    int
+	match *entry // the return entry of a call, nil for returns
+	prev  *entry
+	next  *entry
+}
+
+func checkRegister(ops []*operation) bool {
+	type event struct {
+		op     *operation
+		id     int
+		time   int64
+		isCall bool
+	}
+
+	events := make([]event, 0, 2*len(ops))
+	for id, op := range ops {
+		events = append(events,
+			event{op: op, id: id, time: op.call, isCall: true},
+			event{op: op, id: id, time: op.ret},
+		)
+	}
+	// Calls go first on ties, which only allows more orderings.
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].time != events[j].time {
+			return events[i].time < events[j].time
+		}
+		return events[i].isCall && !events[j].isCall
+	})
+
+	head := &entry{id: -1}
+	tail := head
+	calls := make(map[int]*entry, len(ops))
+	for _, ev := range events {
+		e := &entry{op: ev.op, id: ev.id, prev: tail}
+		tail.next = e
+		tail = e
+		if ev.isCall {
+			calls[ev.id] = e
+		} else {
+			calls[ev.id].match = e
+		}
+	}
+
+	type frame struct {
+		entry *entry
+		state register
+	}
+	type cached struct {
+		linearized bitset
+		state      register
+	}
+
+	var (
+		state      register
+		linearized = newBitset(len(ops))
+		stack      []frame
+		cache      = make(map[uint64][]cached)
+	)
+
+	seen := func(c cached) bool {
+		for _, other := range cache[c.linearized.hash()] {
+			if other.state == c.state && other.linearized.equal(c.linearized) {
+				return true
+			}
+		}
+		return false
+	}
+
+	e := head.next
+	for head.next != nil {
+		if e == nil {
+			// Every remaining call was tried at this point; backtrack.
+			if len(stack) == 0 {
+				return false
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			e, state = top.entry, top.state
+			linearized.clear(e.id)
+			unlift(e)
+			e = e.next
+			continue
+		}
+
+		if e.match == nil {
+			// A return before its call was linearized: this branch is dead.
+			e = nil
+			continue
+		}
+
+		ok, next := step(state, e.op)
+		if ok {
+			c := cached{linearized: linearized.clone(), state: next}
+			c.linearized.set(e.id)
+			if !seen(c) {
+				h := c.linearized.hash()
+				cache[h] = append(cache[h], c)
+				stack = append(stack, frame{entry: e, state: state})
+				state = next
+				linearized.set(e.id)
+				lift(e)
+				e = head.next
+				continue
+			}
+		}
+		e = e.next
+	}
+	return true
+}
+
+// lift removes a call and its return from the list.
+func lift(e *entry) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	m := e.match
+	m.prev.next = m.next
+	if m.next != nil {
+		m.next.prev = m.prev
+	}
+}
+
+// unlift undoes lift.
+func unlift(e *entry) {
+	m := e.match
+	m.prev.next = m
+	if m.next != nil {
+		m.next.prev = m
+	}
+	e.prev.next = e
+	e.next.prev = e
+}
+
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) set(i int)   { b[i/64] |= 1 << (i % 64) }
+func (b bitset) clear(i int) { b[i/64] &^= 1 << (i % 64) }
+
+func (b bitset) clone() bitset {
+	return append(bitset(nil), b...)
+}
+
+func (b bitset) equal(other bitset) bool {
+	for i := range b {
+		if b[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b bitset) hash() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, w := range b {
+		for i := range buf {
+			buf[i] = byte(w >> (8 * i))
+		}
+		_, _ = h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+func TestCheckLinearizable(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		history []operation
+		want    bool
+	}{
+		{
+			name: "sequential",
+			history: []operation{
+				{key: "k", kind: opSet, value: "1", call: 0, ret: 1},
+				{key: "k", kind: opGet, value: "1", found: true, call: 2, ret: 3},
+				{key: "k", kind: opCAS, expected: str("1"), value: "2", swapped: true, call: 4, ret: 5},
+				{key: "k", kind: opGet, value: "2", found: true, call: 6, ret: 7},
+			},
+			want: true,
+		},
+		{
+			name: "concurrent reads may see either value",
+			history: []operation{
+				{key: "k", kind: opSet, value: "1", call: 0, ret: 1},
+				{key: "k", kind: opSet, value: "2", call: 2, ret: 5},
+				{key: "k", kind: opGet, value: "2", found: true, call: 3, ret: 4},
+				{key: "k", kind: opGet, value: "1", found: true, call: 3, ret: 6},
+			},
+			want: true,
+		},
+		{
+			name: "stale read",
+			history: []operation{
+				{key: "k", kind: opSet, value: "1", call: 0, ret: 1},
+				{key: "k", kind: opSet, value: "2", call: 2, ret: 3},
+				{key: "k", kind: opGet, value: "1", found: true, call: 4, ret: 5},
+			},
+			want: false,
+		},
+		{
+			name: "read of missing key",
+			history: []operation{
+				{key: "k", kind: opGet, call: 0, ret: 1},
+				{key: "k", kind: opCAS, value: "1", swapped: true, call: 2, ret: 3},
+				{key: "k", kind: opCAS, value: "2", swapped: false, call: 4, ret: 5},
+			},
+			want: true,
+		},
+		{
+			name: "lost cas",
+			history: []operation{
+				{key: "k", kind: opSet, value: "1", call: 0, ret: 1},
+				{key: "k", kind: opCAS, expected: str("1"), value: "2", swapped: true, call: 2, ret: 3},
+				{key: "k", kind: opCAS, expected: str("1"), value: "3", swapped: true, call: 4, ret: 5},
+			},
+			want: false,
+		},
+		{
+			name: "pending write may apply late",
+			history: []operation{
+				{key: "k", kind: opSet, value: "1", call: 0, ret: pending},
+				{key: "k", kind: opGet, call: 1, ret: 2},
+				{key: "k", kind: opGet, value: "1", found: true, call: 3, ret: 4},
+			},
+			want: true,
+		},
+		{
+			name: "pending write may never apply",
+			history: []operation{
+				{key: "k", kind: opSet, value: "1", call: 0, ret: pending},
+				{key: "k", kind: opGet, call: 1, ret: 2},
+			},
+			want: true,
+		},
+		{
+			name: "keys are independent",
+			history: []operation{
+				{key: "a", kind: opSet, value: "1", call: 0, ret: 1},
+				{key: "b", kind: opGet, call: 2, ret: 3},
+				{key: "a", kind: opGet, value: "1", found: true, call: 2, ret: 3},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := checkLinearizable(tt.history); got != tt.want {
+				t.Fatalf("checkLinearizable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
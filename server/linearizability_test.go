@@ -0,0 +1,198 @@
+package server_test
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	"github.com/amjadjibon/raftd/server/testcluster"
+)
+
+var linDuration = flag.Duration("lincheck.duration", 5*time.Second, "how long TestLinearizability injects faults")
+
+const (
+	linClients = 5
+	linKeys    = 3
+)
+
+// recorder collects the operations of concurrent clients.
+type recorder struct {
+	start time.Time
+
+	mu  sync.Mutex
+	ops []operation
+}
+
+func (r *recorder) now() int64 {
+	return time.Since(r.start).Nanoseconds()
+}
+
+func (r *recorder) add(op operation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = append(r.ops, op)
+}
+
+// TestLinearizability runs clients doing linearizable reads, writes and
+// compare-and-swaps against the current leader while nodes are partitioned,
+// killed and restarted, then checks the recorded history.
+func TestLinearizability(t *testing.T) {
+	if testing.Short() {
+		t.Skip("fault injection test")
+	}
+
+	c := testcluster.New(t, 3)
+	c.WaitForApplied()
+
+	rec := &recorder{start: time.Now()}
+	ctx, cancel := context.WithTimeout(context.Background(), *linDuration)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < linClients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runClient(ctx, c, rec, i)
+		}()
+	}
+
+	runNemesis(ctx, t, c)
+	wg.Wait()
+
+	ok, key := checkLinearizable(rec.ops)
+	if !ok {
+		for _, op := range rec.ops {
+			if op.key == key {
+				t.Logf("%+v", op)
+			}
+		}
+		t.Fatalf("history of key %s is not linearizable", key)
+	}
+	t.Logf("checked %d operations", len(rec.ops))
+}
+
+func runClient(ctx context.Context, c *testcluster.Cluster, rec *recorder, id int) {
+	rng := rand.New(rand.NewPCG(uint64(id), uint64(time.Now().UnixNano())))
+
+	for seq := 0; ctx.Err() == nil; seq++ {
+		leader := c.Leader()
+		if leader == nil {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		raftd := leader.Raftd()
+		if raftd == nil {
+			continue
+		}
+
+		op := operation{
+			clientID: id,
+			key:      fmt.Sprintf("key%d", rng.IntN(linKeys)),
+			value:    fmt.Sprintf("c%d-%d", id, seq),
+		}
+
+		var err error
+		op.call = rec.now()
+		switch rng.IntN(3) {
+		case 0:
+			op.kind = opGet
+			var resp *raftdv1.GetResponse
+			resp, err = raftd.Get(ctx, &raftdv1.GetRequest{
+				Key:         op.key,
+				Consistency: raftdv1.ReadConsistency_READ_CONSISTENCY_LINEARIZABLE,
+			})
+			op.value = ""
+			if err == nil {
+				op.found, op.value = true, string(resp.Value)
+			} else if status.Code(err) == codes.NotFound {
+				err = nil
+			}
+		case 1:
+			op.kind = opSet
+			_, err = raftd.Set(ctx, &raftdv1.SetRequest{Key: op.key, Value: []byte(op.value)})
+		case 2:
+			op.kind = opCAS
+			req := &raftdv1.CompareAndSwapRequest{Key: op.key, Value: []byte(op.value)}
+			if rng.IntN(4) > 0 {
+				expected := fmt.Sprintf("c%d-%d", rng.IntN(linClients), max(seq-rng.IntN(5), 0))
+				op.expected = &expected
+				req.Expected = []byte(expected)
+			}
+			var resp *raftdv1.CompareAndSwapResponse
+			resp, err = raftd.CompareAndSwap(ctx, req)
+			if err == nil {
+				op.swapped = resp.Swapped
+			}
+		}
+		op.ret = rec.now()
+
+		switch {
+		case err == nil:
+			rec.add(op)
+		case op.kind == opGet, status.Code(err) == codes.FailedPrecondition:
+			// Failed reads and rejected writes have no effect.
+		default:
+			// The write may or may not have been committed.
+			op.ret = pending
+			rec.add(op)
+		}
+	}
+}
+
+// runNemesis keeps at most one node partitioned or down at a time, so that
+// a majority can always make progress.
+func runNemesis(ctx context.Context, t *testing.T, c *testcluster.Cluster) {
+	rng := rand.New(rand.NewPCG(0, uint64(time.Now().UnixNano())))
+	ticker := time.NewTicker(300 * time.Millisecond)
+	defer ticker.Stop()
+
+	var faulty *testcluster.Node
+	heal := func() {
+		if faulty == nil {
+			return
+		}
+		if faulty.Running() {
+			c.Heal()
+		} else {
+			c.Restart(faulty)
+		}
+		faulty = nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			heal()
+			return
+		case <-ticker.C:
+		}
+
+		if faulty != nil {
+			heal()
+			continue
+		}
+
+		nodes := c.Nodes()
+		faulty = nodes[rng.IntN(len(nodes))]
+		if leader := c.Leader(); leader != nil && rng.IntN(2) == 0 {
+			faulty = leader
+		}
+
+		if rng.IntN(2) == 0 {
+			t.Logf("partition %s", faulty.ID)
+			c.Partition(faulty)
+		} else {
+			t.Logf("kill %s", faulty.ID)
+			c.Kill(faulty)
+		}
+	}
+}
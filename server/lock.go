@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+const (
+	// leaseCheckInterval is how often the leader looks for expired
+	// leases.
+	leaseCheckInterval = 100 * time.Millisecond
+
+	// lockRetryInterval bounds how long Lock and Campaign wait between
+	// attempts, so that they notice a lost lease or leadership.
+	lockRetryInterval = time.Second
+)
+
+// Locks and elections share the lock table under distinct prefixes.
+const (
+	lockPrefix     = "lock/"
+	electionPrefix = "election/"
+)
+
+// Grant implements raftdv1.LockServiceServer. The lease ID is the raft
+// index of the grant.
+func (s *Raftd) Grant(ctx context.Context, req *raftdv1.GrantRequest) (*raftdv1.GrantResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+
+	ttl := req.Ttl.AsDuration()
+	if ttl <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "ttl must be positive")
+	}
+
+	result, err := s.apply(ctx, &raftdv1.Command{
+		Op:       "lease_grant",
+		Ttl:      int64(ttl),
+		ClientId: req.Session.GetClientId(),
+		Sequence: req.Session.GetSequence(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.GrantResponse{LeaseId: result.Revision, Ttl: req.Ttl}, nil
+}
+
+// KeepAlive implements raftdv1.LockServiceServer.
+func (s *Raftd) KeepAlive(ctx context.Context, req *raftdv1.KeepAliveRequest) (*raftdv1.KeepAliveResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+
+	if _, err := s.apply(ctx, &raftdv1.Command{Op: "lease_keepalive", LeaseId: req.LeaseId}); err != nil {
+		return nil, err
+	}
+
+	// The lease may expire right after the keepalive is applied.
+	lease := s.fsm.leases.get(req.LeaseId)
+	if lease == nil {
+		return nil, status.Errorf(codes.NotFound, "lease not found")
+	}
+
+	return &raftdv1.KeepAliveResponse{Ttl: durationpb.New(time.Duration(lease.Ttl))}, nil
+}
+
+// Revoke implements raftdv1.LockServiceServer.
+func (s *Raftd) Revoke(ctx context.Context, req *raftdv1.RevokeRequest) (*raftdv1.RevokeResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+
+	if _, err := s.apply(ctx, &raftdv1.Command{Op: "lease_revoke", LeaseId: req.LeaseId}); err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.RevokeResponse{}, nil
+}
+
+// Lock implements raftdv1.LockServiceServer.
+func (s *Raftd) Lock(ctx context.Context, req *raftdv1.LockRequest) (*raftdv1.LockResponse, error) {
+	if req.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required")
+	}
+
+	token, _, err := s.acquire(ctx, lockPrefix+req.Name, req.LeaseId, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.LockResponse{Token: token}, nil
+}
+
+// TryLock implements raftdv1.LockServiceServer.
+func (s *Raftd) TryLock(ctx context.Context, req *raftdv1.TryLockRequest) (*raftdv1.TryLockResponse, error) {
+	if req.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required")
+	}
+
+	token, acquired, err := s.acquire(ctx, lockPrefix+req.Name, req.LeaseId, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.TryLockResponse{Acquired: acquired, Token: token}, nil
+}
+
+// Unlock implements raftdv1.LockServiceServer.
+func (s *Raftd) Unlock(ctx context.Context, req *raftdv1.UnlockRequest) (*raftdv1.UnlockResponse, error) {
+	if req.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required")
+	}
+
+	if err := s.release(ctx, lockPrefix+req.Name, req.LeaseId); err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.UnlockResponse{}, nil
+}
+
+// Campaign implements raftdv1.LockServiceServer.
+func (s *Raftd) Campaign(ctx context.Context, req *raftdv1.CampaignRequest) (*raftdv1.CampaignResponse, error) {
+	if req.Election == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "election is required")
+	}
+
+	token, _, err := s.acquire(ctx, electionPrefix+req.Election, req.LeaseId, req.Value, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.CampaignResponse{Token: token}, nil
+}
+
+// Observe implements raftdv1.LockServiceServer. It reads the local lock
+// table, so followers serve it as well, lagging behind the leader by their
+// replication delay.
+func (s *Raftd) Observe(req *raftdv1.ObserveRequest, stream grpc.ServerStreamingServer[raftdv1.ObserveResponse]) error {
+	if req.Election == "" {
+		return status.Errorf(codes.InvalidArgument, "election is required")
+	}
+
+	ctx := stream.Context()
+	first := true
+	var token uint64
+	for {
+		holder, changed := s.fsm.leases.holder(electionPrefix + req.Election)
+		if first || holder.GetToken() != token {
+			resp := &raftdv1.ObserveResponse{}
+			if holder != nil {
+				resp.Leader = &raftdv1.Leader{
+					LeaseId: holder.LeaseId,
+					Token:   holder.Token,
+					Value:   holder.Value,
+				}
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			first, token = false, holder.GetToken()
+		}
+
+		select {
+		case <-changed:
+		case <-s.shutdown:
+			return status.Errorf(codes.Unavailable, "server is shutting down")
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// Resign implements raftdv1.LockServiceServer.
+func (s *Raftd) Resign(ctx context.Context, req *raftdv1.ResignRequest) (*raftdv1.ResignResponse, error) {
+	if req.Election == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "election is required")
+	}
+
+	if err := s.release(ctx, electionPrefix+req.Election, req.LeaseId); err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.ResignResponse{}, nil
+}
+
+// acquire takes the lock for the lease and returns its fencing token. If
+// another lease holds the lock, it either reports false or, with wait,
+// tries again whenever the lock may have been released.
+func (s *Raftd) acquire(ctx context.Context, name string, leaseID uint64, value []byte, wait bool) (uint64, bool, error) {
+	if err := s.checkWrite(name, value); err != nil {
+		return 0, false, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if s.raftEngine.State() != raft.Leader {
+			return 0, false, status.Errorf(codes.FailedPrecondition, "not the leader")
+		}
+
+		// Take the channel before the attempt, so that a release applied
+		// in between is not missed.
+		holder, changed := s.fsm.leases.holder(name)
+		if attempt == 0 || holder == nil || holder.LeaseId == leaseID {
+			result, err := s.apply(ctx, &raftdv1.Command{
+				Op:      "lock_acquire",
+				Key:     name,
+				LeaseId: leaseID,
+				Value:   value,
+			})
+			if err != nil {
+				return 0, false, err
+			}
+			if result.Code == raftdv1.ApplyCode_APPLY_CODE_OK {
+				return result.Token, true, nil
+			}
+		} else if s.fsm.leases.get(leaseID) == nil {
+			return 0, false, status.Errorf(codes.NotFound, "lease not found")
+		}
+
+		if !wait {
+			return 0, false, nil
+		}
+
+		timer := time.NewTimer(lockRetryInterval)
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, false, status.FromContextError(ctx.Err()).Err()
+		}
+		timer.Stop()
+	}
+}
+
+// release frees the lock if the lease holds it.
+func (s *Raftd) release(ctx context.Context, name string, leaseID uint64) error {
+	if s.raftEngine.State() != raft.Leader {
+		return status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+
+	result, err := s.apply(ctx, &raftdv1.Command{Op: "lock_release", Key: name, LeaseId: leaseID})
+	if err != nil {
+		return err
+	}
+	if result.Code == raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED {
+		return status.Errorf(codes.FailedPrecondition, "lock is not held by this lease")
+	}
+
+	return nil
+}
+
+// expireLeases proposes the expiry of the leases whose deadline has
+// passed. A new leader first gives every lease a full TTL, so that clients
+// have time to find it and keep their leases alive.
+func (s *Raftd) expireLeases(leaderSince, now time.Time) {
+	for _, lease := range s.fsm.leases.expired(now.UnixNano()) {
+		if now.Sub(leaderSince) < time.Duration(lease.Ttl) {
+			continue
+		}
+
+		result, err := s.apply(context.Background(), &raftdv1.Command{Op: "lease_expire", LeaseId: lease.Id})
+		if err != nil {
+			s.logger.Warn("failed to expire lease", "lease_id", lease.Id, "error", err)
+			return
+		}
+		if result.Code == raftdv1.ApplyCode_APPLY_CODE_OK {
+			s.logger.Debug("expired lease", "lease_id", lease.Id)
+		}
+	}
+}
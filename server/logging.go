@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader is the gRPC metadata key carrying the request ID. A
+// client may set it to correlate its calls with the server logs; otherwise
+// the server generates one. It is sent back in the response header.
+const RequestIDHeader = "x-request-id"
+
+// levelTrace is the slog level raft trace messages are logged at.
+const levelTrace = slog.LevelDebug - 4
+
+// hclogAdapter lets raft log through a slog.Logger.
+type hclogAdapter struct {
+	base    *slog.Logger
+	logger  *slog.Logger
+	name    string
+	implied []any
+}
+
+var _ hclog.Logger = (*hclogAdapter)(nil)
+
+func newHCLogger(base *slog.Logger, name string, implied []any) *hclogAdapter {
+	logger := base
+	if name != "" {
+		logger = logger.With("logger", name)
+	}
+	return &hclogAdapter{
+		base:    base,
+		logger:  logger.With(implied...),
+		name:    name,
+		implied: implied,
+	}
+}
+
+func (a *hclogAdapter) Log(level hclog.Level, msg string, args ...any) {
+	switch level {
+	case hclog.Trace:
+		a.Trace(msg, args...)
+	case hclog.Debug:
+		a.Debug(msg, args...)
+	case hclog.Warn:
+		a.Warn(msg, args...)
+	case hclog.Error:
+		a.Error(msg, args...)
+	case hclog.Off:
+	default:
+		a.Info(msg, args...)
+	}
+}
+
+func (a *hclogAdapter) log(level slog.Level, msg string, args []any) {
+	if !a.enabled(level) {
+		return
+	}
+
+	// raft formats some values lazily with hclog.Format.
+	for i, arg := range args {
+		if f, ok := arg.(hclog.Format); ok && len(f) > 0 {
+			if format, ok := f[0].(string); ok {
+				args[i] = fmt.Sprintf(format, f[1:]...)
+			}
+		}
+	}
+	a.logger.Log(context.Background(), level, msg, args...)
+}
+
+func (a *hclogAdapter) Trace(msg string, args ...any) { a.log(levelTrace, msg, args) }
+func (a *hclogAdapter) Debug(msg string, args ...any) { a.log(slog.LevelDebug, msg, args) }
+func (a *hclogAdapter) Info(msg string, args ...any)  { a.log(slog.LevelInfo, msg, args) }
+func (a *hclogAdapter) Warn(msg string, args ...any)  { a.log(slog.LevelWarn, msg, args) }
+func (a *hclogAdapter) Error(msg string, args ...any) { a.log(slog.LevelError, msg, args) }
+
+func (a *hclogAdapter) enabled(level slog.Level) bool {
+	return a.logger.Enabled(context.Background(), level)
+}
+
+func (a *hclogAdapter) IsTrace() bool { return a.enabled(levelTrace) }
+func (a *hclogAdapter) IsDebug() bool { return a.enabled(slog.LevelDebug) }
+func (a *hclogAdapter) IsInfo() bool  { return a.enabled(slog.LevelInfo) }
+func (a *hclogAdapter) IsWarn() bool  { return a.enabled(slog.LevelWarn) }
+func (a *hclogAdapter) IsError() bool { return a.enabled(slog.LevelError) }
+
+func (a *hclogAdapter) ImpliedArgs() []any {
+	return a.implied
+}
+
+func (a *hclogAdapter) With(args ...any) hclog.Logger {
+	return newHCLogger(a.base, a.name, append(append([]any(nil), a.implied...), args...))
+}
+
+func (a *hclogAdapter) Name() string {
+	return a.name
+}
+
+func (a *hclogAdapter) Named(name string) hclog.Logger {
+	if a.name != "" {
+		name = a.name + "." + name
+	}
+	return a.ResetNamed(name)
+}
+
+func (a *hclogAdapter) ResetNamed(name string) hclog.Logger {
+	return newHCLogger(a.base, name, a.implied)
+}
+
+// SetLevel is a no-op: the level is owned by the slog handler.
+func (a *hclogAdapter) SetLevel(hclog.Level) {}
+
+func (a *hclogAdapter) GetLevel() hclog.Level {
+	switch {
+	case a.IsTrace():
+		return hclog.Trace
+	case a.IsDebug():
+		return hclog.Debug
+	case a.IsInfo():
+		return hclog.Info
+	case a.IsWarn():
+		return hclog.Warn
+	case a.IsError():
+		return hclog.Error
+	default:
+		return hclog.Off
+	}
+}
+
+func (a *hclogAdapter) StandardLogger(opts *hclog.StandardLoggerOptions) *log.Logger {
+	return slog.NewLogLogger(a.logger.Handler(), slog.LevelInfo)
+}
+
+func (a *hclogAdapter) StandardWriter(opts *hclog.StandardLoggerOptions) io.Writer {
+	return a.StandardLogger(opts).Writer()
+}
+
+// unaryLoggingInterceptor logs every unary call once it completes.
+func unaryLoggingInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		id := requestID(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+
+		resp, err := handler(ctx, req)
+		logCall(ctx, logger, info.FullMethod, id, start, err)
+		return resp, err
+	}
+}
+
+// streamLoggingInterceptor logs every streaming call once it completes.
+func streamLoggingInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		id := requestID(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(RequestIDHeader, id))
+
+		err := handler(srv, ss)
+		logCall(ss.Context(), logger, info.FullMethod, id, start, err)
+		return err
+	}
+}
+
+// requestID returns the request ID sent by the client, or a new one.
+func requestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(RequestIDHeader); len(ids) > 0 && ids[0] != "" {
+			return ids[0]
+		}
+	}
+
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func logCall(ctx context.Context, logger *slog.Logger, method, id string, start time.Time, err error) {
+	code := status.Code(err)
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unimplemented:
+		level = slog.LevelError
+	}
+
+	addr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+
+	attrs := []any{
+		"method", method,
+		"request_id", id,
+		"peer", addr,
+		"code", code.String(),
+		"duration", time.Since(start),
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		attrs = append(attrs, "trace_id", spanContext.TraceID().String())
+	}
+	if err != nil {
+		attrs = append(attrs, "error", status.Convert(err).Message())
+	}
+	logger.Log(ctx, level, "rpc", attrs...)
+}
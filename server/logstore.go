@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+	wal "github.com/hashicorp/raft-wal"
+	"github.com/hashicorp/raft-wal/metadb"
+	"github.com/hashicorp/raft-wal/migrate"
+)
+
+// Backends of Config.LogStore.
+const (
+	// LogStoreBolt keeps the raft log and stable store in the bolt
+	// database raft.db. It is the default.
+	LogStoreBolt = "bolt"
+
+	// LogStoreWAL keeps them in the segment files of the wal directory,
+	// which sustain more writes than bolt and give the space of truncated
+	// entries back to the filesystem.
+	LogStoreWAL = "wal"
+
+	// LogStoreMemory keeps them, and the snapshots, in memory, so the node
+	// starts empty every time. It is meant for tests.
+	LogStoreMemory = "memory"
+)
+
+// LogStores lists the backends of Config.LogStore.
+var LogStores = []string{LogStoreBolt, LogStoreWAL, LogStoreMemory}
+
+const (
+	boltFile = "raft.db"
+	walDir   = "wal"
+
+	// logCacheSize is the number of recent entries raft.LogCache keeps, so
+	// that replicating them to followers does not read the log store.
+	logCacheSize = 512
+
+	// migrateBatchSize is the size of the entries MigrateLogStore appends
+	// at once.
+	migrateBatchSize = 1 << 20
+
+	// migratedSuffix is added to the log store MigrateLogStore copied from.
+	migratedSuffix = ".migrated"
+)
+
+// logStore is a raft log store along with the stable store kept by the same
+// backend.
+type logStore interface {
+	raft.LogStore
+	raft.StableStore
+	io.Closer
+}
+
+// memoryStore is the logStore of LogStoreMemory.
+type memoryStore struct {
+	*raft.InmemStore
+}
+
+func (memoryStore) Close() error {
+	return nil
+}
+
+// walStore is the logStore of LogStoreWAL. It closes the meta database of
+// the wal along with it, which WAL.Close leaves open and locked.
+type walStore struct {
+	*wal.WAL
+	meta *metadb.BoltMetaDB
+}
+
+func (w walStore) Close() error {
+	return errors.Join(w.WAL.Close(), w.meta.Close())
+}
+
+// openLogStore opens the log store of backend under raftDir. It refuses to
+// open one when raftDir holds the log of another backend, which the node
+// would otherwise start without.
+func openLogStore(raftDir, backend string, logger hclog.Logger) (logStore, error) {
+	if backend == "" {
+		backend = LogStoreBolt
+	}
+	if backend != LogStoreMemory {
+		found, err := detectLogStore(raftDir)
+		if err != nil {
+			return nil, err
+		}
+		if found != "" && found != backend {
+			return nil, fmt.Errorf("raft directory %s has a %s log store, migrate it to %s first", raftDir, found, backend)
+		}
+	}
+	return newLogStore(raftDir, backend, logger)
+}
+
+func newLogStore(raftDir, backend string, logger hclog.Logger) (logStore, error) {
+	switch backend {
+	case LogStoreBolt:
+		store, err := raftboltdb.NewBoltStore(filepath.Join(raftDir, boltFile))
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case LogStoreWAL:
+		dir := filepath.Join(raftDir, walDir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		meta := &metadb.BoltMetaDB{}
+		store, err := wal.Open(dir, wal.WithMetaStore(meta), wal.WithLogger(logger))
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to open wal: %w", err), meta.Close())
+		}
+		return walStore{WAL: store, meta: meta}, nil
+	case LogStoreMemory:
+		return memoryStore{raft.NewInmemStore()}, nil
+	default:
+		return nil, fmt.Errorf("invalid log store %q, want one of %s", backend, strings.Join(LogStores, ", "))
+	}
+}
+
+// detectLogStore returns the backend of the log store under raftDir, or an
+// empty string if there is none.
+func detectLogStore(raftDir string) (string, error) {
+	for _, backend := range []string{LogStoreBolt, LogStoreWAL} {
+		_, err := os.Stat(logStorePath(raftDir, backend))
+		if err == nil {
+			return backend, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// logStorePath returns the file or directory of a backend under raftDir.
+func logStorePath(raftDir, backend string) string {
+	if backend == LogStoreWAL {
+		return filepath.Join(raftDir, walDir)
+	}
+	return filepath.Join(raftDir, boltFile)
+}
+
+// MigrateLogStore copies the raft log and stable store under raftDir to the
+// backend to, from the one the directory has. The node must be stopped, and
+// started with the new backend afterwards. The old store is renamed with a
+// ".migrated" suffix rather than deleted, and can be removed once the node
+// runs with the new one.
+func MigrateLogStore(raftDir, to string) (from string, err error) {
+	if to != LogStoreBolt && to != LogStoreWAL {
+		return "", fmt.Errorf("invalid log store %q, want %s or %s", to, LogStoreBolt, LogStoreWAL)
+	}
+
+	from, err = detectLogStore(raftDir)
+	if err != nil {
+		return "", err
+	}
+	switch from {
+	case "":
+		return "", fmt.Errorf("raft directory %s has no log store", raftDir)
+	case to:
+		return "", fmt.Errorf("raft directory %s already has a %s log store", raftDir, to)
+	}
+	old := logStorePath(raftDir, from)
+	if _, err := os.Stat(old + migratedSuffix); err == nil {
+		return "", fmt.Errorf("%s is in the way, remove it first", old+migratedSuffix)
+	}
+
+	if err := copyLogStore(raftDir, from, to); err != nil {
+		// Leave no partial store behind, so that the migration can be
+		// run again.
+		return "", errors.Join(err, os.RemoveAll(logStorePath(raftDir, to)))
+	}
+	return from, os.Rename(old, old+migratedSuffix)
+}
+
+func copyLogStore(raftDir, from, to string) error {
+	src, err := newLogStore(raftDir, from, hclog.NewNullLogger())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	dst, err := newLogStore(raftDir, to, hclog.NewNullLogger())
+	if err != nil {
+		return err
+	}
+
+	err = copyStable(dst, src)
+	if err == nil {
+		err = copyLogs(dst, src)
+	}
+	return errors.Join(err, dst.Close())
+}
+
+// copyLogs copies the entries of src to the empty dst.
+func copyLogs(dst, src raft.LogStore) error {
+	last, err := src.LastIndex()
+	if err != nil {
+		return err
+	}
+	if last == 0 {
+		return nil
+	}
+	return migrate.CopyLogs(context.Background(), dst, src, migrateBatchSize, nil)
+}
+
+// copyStable copies the keys raft keeps in the stable store. The stores
+// differ on keys that were never set: bolt fails to read them while the wal
+// returns nothing, so both are skipped.
+func copyStable(dst, src raft.StableStore) error {
+	// These are the keys of raft.go in hashicorp/raft.
+	for _, key := range []string{"CurrentTerm", "LastVoteTerm"} {
+		value, err := src.GetUint64([]byte(key))
+		if errors.Is(err, raftboltdb.ErrKeyNotFound) || err == nil && value == 0 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		if err := dst.SetUint64([]byte(key), value); err != nil {
+			return err
+		}
+	}
+
+	value, err := src.Get([]byte("LastVoteCand"))
+	if errors.Is(err, raftboltdb.ErrKeyNotFound) || err == nil && len(value) == 0 {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read LastVoteCand: %w", err)
+	}
+	return dst.Set([]byte("LastVoteCand"), value)
+}
@@ -0,0 +1,364 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	"github.com/amjadjibon/raftd/store"
+)
+
+// defaultNamespace holds the keys of requests that name no namespace.
+const defaultNamespace = "default"
+
+// namespaces is the namespace table of the FSM: the store of every
+// namespace along with its quota and usage. The table itself is read by
+// RPC handlers, so it is guarded by a mutex; the stores guard themselves.
+// Quotas are replaced rather than modified, so they can be shared.
+type namespaces struct {
+	mu      sync.Mutex
+	entries map[string]*namespace
+	// compacted is the revision the history of every namespace was
+	// compacted to. Namespaces created later have no earlier history.
+	compacted uint64
+	// retired holds the history of deleted namespaces, which ends with the
+	// deletion of every key, until compaction drops it, so that the
+	// consumers of changes see the deletions.
+	retired map[string]*store.Store
+}
+
+type namespace struct {
+	store *store.Store
+	quota *raftdv1.Quota
+	keys  int64
+	bytes int64
+}
+
+func newNamespaces(defaultStore *store.Store) *namespaces {
+	return &namespaces{
+		entries: map[string]*namespace{
+			defaultNamespace: {store: defaultStore, quota: &raftdv1.Quota{}},
+		},
+		retired: make(map[string]*store.Store),
+	}
+}
+
+// namespaceName maps the empty name of requests to the default namespace.
+func namespaceName(name string) string {
+	if name == "" {
+		return defaultNamespace
+	}
+	return name
+}
+
+// store returns the store of the namespace, or nil if it does not exist.
+func (n *namespaces) store(name string) *store.Store {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	entry, ok := n.entries[namespaceName(name)]
+	if !ok {
+		return nil
+	}
+	return entry.store
+}
+
+// create adds an empty namespace and reports false if it exists.
+func (n *namespaces) create(name string, quota *raftdv1.Quota) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.entries[name]; ok {
+		return false
+	}
+	n.entries[name] = &namespace{store: n.newStore(), quota: quotaOrNone(quota)}
+	return true
+}
+
+// newStore returns an empty store compacted like the others. It must be
+// called with mu held.
+func (n *namespaces) newStore() *store.Store {
+	s := store.New()
+	s.Restore(nil, n.compacted)
+	return s
+}
+
+// update replaces the quota of the namespace and reports whether it
+// exists.
+func (n *namespaces) update(name string, quota *raftdv1.Quota) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	entry, ok := n.entries[namespaceName(name)]
+	if !ok {
+		return false
+	}
+	entry.quota = quotaOrNone(quota)
+	return true
+}
+
+// delete removes the namespace, deleting its keys at rev, and reports
+// whether it existed. The default namespace cannot be deleted.
+func (n *namespaces) delete(name string, rev uint64) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	entry, ok := n.entries[name]
+	if !ok || name == defaultNamespace {
+		return false
+	}
+	delete(n.entries, name)
+
+	for _, key := range entry.store.Keys() {
+		entry.store.Delete(key, rev)
+	}
+	history := entry.store.History()
+	if len(history) == 0 {
+		return true
+	}
+	// An earlier namespace of the same name ended before this one began,
+	// so their histories follow each other.
+	if earlier, ok := n.retired[name]; ok {
+		entry.store.Restore(append(earlier.History(), history...), n.compacted)
+	}
+	n.retired[name] = entry.store
+	return true
+}
+
+// charge accounts for key changing from prev, if it existed, to value, if
+// it exists now. It leaves the usage unchanged and returns an error if the
+// change would take the namespace over a quota; changes that do not grow
+// the usage are always allowed.
+func (n *namespaces) charge(name, key string, prev []byte, hadPrev bool, value []byte, has bool) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	name = namespaceName(name)
+	entry := n.entries[name]
+	keys, bytes := entry.keys, entry.bytes
+	if hadPrev {
+		keys--
+		bytes -= int64(len(key) + len(prev))
+	}
+	if has {
+		keys++
+		bytes += int64(len(key) + len(value))
+	}
+
+	quota := entry.quota
+	switch {
+	case has && quota.MaxValueSize > 0 && int64(len(value)) > quota.MaxValueSize:
+		return fmt.Errorf("value of %d bytes exceeds the limit of %d of namespace %q", len(value), quota.MaxValueSize, name)
+	case keys > entry.keys && quota.MaxKeys > 0 && keys > quota.MaxKeys:
+		return fmt.Errorf("namespace %q is limited to %d keys", name, quota.MaxKeys)
+	case bytes > entry.bytes && quota.MaxBytes > 0 && bytes > quota.MaxBytes:
+		return fmt.Errorf("namespace %q is limited to %d bytes", name, quota.MaxBytes)
+	}
+
+	entry.keys, entry.bytes = keys, bytes
+	return nil
+}
+
+// usage returns the key count and size a namespace is charged for.
+func (n *namespaces) usage(name string) (keys, bytes int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	entry := n.entries[namespaceName(name)]
+	return entry.keys, entry.bytes
+}
+
+// setUsage reverts the usage of a namespace to what usage returned, for
+// writes that are undone.
+func (n *namespaces) setUsage(name string, keys, bytes int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	entry := n.entries[namespaceName(name)]
+	entry.keys, entry.bytes = keys, bytes
+}
+
+// size returns the total size of the keys and values of every namespace,
+// with the history kept for them.
+func (n *namespaces) size() int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var size int64
+	for _, entry := range n.entries {
+		size += entry.store.HistorySize()
+	}
+	for _, retired := range n.retired {
+		size += retired.HistorySize()
+	}
+	return size
+}
+
+// compact drops the history of every namespace superseded at rev, and
+// returns how many versions it dropped.
+func (n *namespaces) compact(rev uint64) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.compacted = max(n.compacted, rev)
+	dropped := 0
+	for _, entry := range n.entries {
+		dropped += entry.store.Compact(rev)
+	}
+	for name, retired := range n.retired {
+		dropped += retired.Compact(rev)
+		if len(retired.History()) == 0 {
+			delete(n.retired, name)
+		}
+	}
+	return dropped
+}
+
+// changes returns up to limit changes of every namespace after rev, in
+// revision order, deleted namespaces included. The changes of a revision
+// are never split, so there may be more than limit.
+func (n *namespaces) changes(rev uint64, limit int) []*raftdv1.Change {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var changes []*raftdv1.Change
+	add := func(name string, kv *store.Store) {
+		for _, v := range kv.Changes(rev, limit) {
+			changes = append(changes, &raftdv1.Change{
+				Revision:  v.Revision,
+				Namespace: name,
+				Key:       v.Key,
+				Value:     v.Value,
+				Deleted:   v.Deleted,
+			})
+		}
+	}
+	for name, entry := range n.entries {
+		add(name, entry.store)
+	}
+	for name, retired := range n.retired {
+		add(name, retired)
+	}
+	slices.SortStableFunc(changes, func(a, b *raftdv1.Change) int {
+		return cmp.Compare(a.Revision, b.Revision)
+	})
+	if limit > 0 && len(changes) > limit {
+		for limit < len(changes) && changes[limit].Revision == changes[limit-1].Revision {
+			limit++
+		}
+		changes = changes[:limit]
+	}
+	return changes
+}
+
+// compactRevision returns the revision the history was compacted to.
+func (n *namespaces) compactRevision() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.compacted
+}
+
+// get returns the namespace with its usage, or nil.
+func (n *namespaces) get(name string) *raftdv1.Namespace {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	entry, ok := n.entries[namespaceName(name)]
+	if !ok {
+		return nil
+	}
+	return entry.describe(namespaceName(name))
+}
+
+// list returns every namespace with its usage, in name order.
+func (n *namespaces) list() []*raftdv1.Namespace {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	list := make([]*raftdv1.Namespace, 0, len(n.entries))
+	for name, entry := range n.entries {
+		list = append(list, entry.describe(name))
+	}
+	slices.SortFunc(list, func(a, b *raftdv1.Namespace) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return list
+}
+
+func (e *namespace) describe(name string) *raftdv1.Namespace {
+	return &raftdv1.Namespace{
+		Name:  name,
+		Quota: e.quota,
+		Usage: &raftdv1.Usage{Keys: e.keys, Bytes: e.bytes},
+	}
+}
+
+// snapshot returns the quota and the history of every namespace, the
+// history of the deleted ones, and the compaction point, for snapshots.
+func (n *namespaces) snapshot() (map[string]*raftdv1.Quota, map[string][]store.Version, map[string][]store.Version, uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	quotas := make(map[string]*raftdv1.Quota, len(n.entries))
+	history := make(map[string][]store.Version, len(n.entries))
+	for name, entry := range n.entries {
+		quotas[name] = entry.quota
+		history[name] = entry.store.History()
+	}
+	retired := make(map[string][]store.Version, len(n.retired))
+	for name, kv := range n.retired {
+		retired[name] = kv.History()
+	}
+	return quotas, history, retired, n.compacted
+}
+
+// replace swaps in the namespaces restored from a snapshot. Namespaces
+// with history but no quota are created without limits. The store of the
+// default namespace is kept and refilled.
+func (n *namespaces) replace(quotas map[string]*raftdv1.Quota, history, retired map[string][]store.Version, compacted uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	defaultStore := n.entries[defaultNamespace].store
+	n.entries = map[string]*namespace{
+		defaultNamespace: {store: defaultStore, quota: &raftdv1.Quota{}},
+	}
+	n.compacted = compacted
+	for name, quota := range quotas {
+		n.entry(name).quota = quotaOrNone(quota)
+	}
+	for name := range history {
+		n.entry(name)
+	}
+
+	for name, entry := range n.entries {
+		entry.store.Restore(history[name], compacted)
+		entry.keys = int64(len(entry.store.Keys()))
+		entry.bytes = entry.store.Size()
+	}
+
+	n.retired = make(map[string]*store.Store, len(retired))
+	for name, versions := range retired {
+		n.retired[name] = store.New()
+		n.retired[name].Restore(versions, compacted)
+	}
+}
+
+// entry returns the namespace, creating it without limits if needed. It
+// must be called with mu held.
+func (n *namespaces) entry(name string) *namespace {
+	entry, ok := n.entries[name]
+	if !ok {
+		entry = &namespace{store: n.newStore(), quota: &raftdv1.Quota{}}
+		n.entries[name] = entry
+	}
+	return entry
+}
+
+func quotaOrNone(quota *raftdv1.Quota) *raftdv1.Quota {
+	if quota == nil {
+		return &raftdv1.Quota{}
+	}
+	return quota
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+var namespaceNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,63}$`)
+
+// CreateNamespace implements raftdv1.NamespaceServiceServer.
+func (s *Raftd) CreateNamespace(ctx context.Context, req *raftdv1.CreateNamespaceRequest) (*raftdv1.CreateNamespaceResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+
+	name := req.Namespace.GetName()
+	if !namespaceNamePattern.MatchString(name) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid namespace name %q", name)
+	}
+	if err := checkQuota(req.Namespace.GetQuota()); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.apply(ctx, &raftdv1.Command{Op: "ns_create", Namespace: name, Quota: req.Namespace.GetQuota()}); err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.CreateNamespaceResponse{Namespace: s.fsm.namespaces.get(name)}, nil
+}
+
+// GetNamespace implements raftdv1.NamespaceServiceServer.
+func (s *Raftd) GetNamespace(ctx context.Context, req *raftdv1.GetNamespaceRequest) (*raftdv1.GetNamespaceResponse, error) {
+	namespace := s.fsm.namespaces.get(req.Name)
+	if namespace == nil {
+		return nil, status.Errorf(codes.NotFound, "namespace not found")
+	}
+
+	return &raftdv1.GetNamespaceResponse{Namespace: namespace}, nil
+}
+
+// ListNamespaces implements raftdv1.NamespaceServiceServer.
+func (s *Raftd) ListNamespaces(ctx context.Context, req *raftdv1.ListNamespacesRequest) (*raftdv1.ListNamespacesResponse, error) {
+	return &raftdv1.ListNamespacesResponse{Namespaces: s.fsm.namespaces.list()}, nil
+}
+
+// UpdateNamespace implements raftdv1.NamespaceServiceServer.
+func (s *Raftd) UpdateNamespace(ctx context.Context, req *raftdv1.UpdateNamespaceRequest) (*raftdv1.UpdateNamespaceResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	if err := checkQuota(req.Quota); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.apply(ctx, &raftdv1.Command{Op: "ns_update", Namespace: namespaceName(req.Name), Quota: req.Quota}); err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.UpdateNamespaceResponse{Namespace: s.fsm.namespaces.get(req.Name)}, nil
+}
+
+// DeleteNamespace implements raftdv1.NamespaceServiceServer.
+func (s *Raftd) DeleteNamespace(ctx context.Context, req *raftdv1.DeleteNamespaceRequest) (*raftdv1.DeleteNamespaceResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	if namespaceName(req.Name) == defaultNamespace {
+		return nil, status.Errorf(codes.InvalidArgument, "the default namespace cannot be deleted")
+	}
+
+	if _, err := s.apply(ctx, &raftdv1.Command{Op: "ns_delete", Namespace: req.Name}); err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.DeleteNamespaceResponse{}, nil
+}
+
+func checkQuota(quota *raftdv1.Quota) error {
+	if quota.GetMaxKeys() < 0 || quota.GetMaxBytes() < 0 || quota.GetMaxValueSize() < 0 {
+		return status.Errorf(codes.InvalidArgument, "quotas must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// queues is the queue table of the FSM. Visibility deadlines are in the
+// leader's clock as recorded in the log; the leader alone decides when a
+// delivery times out, see Raftd.redeliverMessages.
+//
+// It is read by RPC handlers, so it is guarded by a mutex. Entries are
+// replaced rather than modified, so they can be shared.
+type queues struct {
+	mu     sync.Mutex
+	queues map[string]*queue
+	// bytes is the size of the queue names and bodies of every message,
+	// which counts toward the storage quota.
+	bytes int64
+}
+
+type queue struct {
+	// visible holds the messages waiting for delivery, by ID.
+	visible []*raftdv1.QueueMessage
+	// inFlight holds the delivered messages by ID.
+	inFlight map[uint64]*raftdv1.QueueMessage
+}
+
+func newQueues() *queues {
+	return &queues{queues: make(map[string]*queue)}
+}
+
+// get returns the queue name, creating it if needed. It must be called
+// with mu held.
+func (q *queues) get(name string) *queue {
+	entry, ok := q.queues[name]
+	if !ok {
+		entry = &queue{inFlight: make(map[uint64]*raftdv1.QueueMessage)}
+		q.queues[name] = entry
+	}
+	return entry
+}
+
+// drop removes the queue name if it holds no message. It must be called
+// with mu held.
+func (q *queues) drop(name string) {
+	if entry, ok := q.queues[name]; ok && len(entry.visible) == 0 && len(entry.inFlight) == 0 {
+		delete(q.queues, name)
+	}
+}
+
+func (q *queue) show(msg *raftdv1.QueueMessage) {
+	i, _ := slices.BinarySearchFunc(q.visible, msg.Id, func(m *raftdv1.QueueMessage, id uint64) int {
+		return cmp.Compare(m.Id, id)
+	})
+	q.visible = slices.Insert(q.visible, i, msg)
+}
+
+func (q *queues) enqueue(name string, id uint64, body []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.get(name).show(&raftdv1.QueueMessage{Queue: name, Id: id, Body: body})
+	q.bytes += messageSize(name, body)
+}
+
+// messageSize is the size a message counts toward the storage quota.
+func messageSize(name string, body []byte) int64 {
+	return int64(len(name) + len(body))
+}
+
+// size returns the size of every message.
+func (q *queues) size() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.bytes
+}
+
+// dequeue delivers the oldest visible message under receipt, hiding it
+// until deadline, and returns it. It returns nil if no message is
+// visible.
+func (q *queues) dequeue(name string, receipt uint64, deadline int64) *raftdv1.QueueMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.queues[name]
+	if !ok || len(entry.visible) == 0 {
+		return nil
+	}
+
+	next := entry.visible[0]
+	entry.visible[0] = nil
+	entry.visible = entry.visible[1:]
+
+	msg := &raftdv1.QueueMessage{
+		Queue:    name,
+		Id:       next.Id,
+		Body:     next.Body,
+		Attempts: next.Attempts + 1,
+		Receipt:  receipt,
+		Deadline: deadline,
+	}
+	entry.inFlight[msg.Id] = msg
+	return msg
+}
+
+// inFlight returns the message if it is delivered under receipt. It must
+// be called with mu held.
+func (q *queues) inFlight(name string, id, receipt uint64) (*queue, *raftdv1.QueueMessage) {
+	entry, ok := q.queues[name]
+	if !ok {
+		return nil, nil
+	}
+	msg, ok := entry.inFlight[id]
+	if !ok || msg.Receipt != receipt {
+		return nil, nil
+	}
+	return entry, msg
+}
+
+// ack deletes the message delivered under receipt, and reports whether it
+// did.
+func (q *queues) ack(name string, id, receipt uint64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, msg := q.inFlight(name, id, receipt)
+	if msg == nil {
+		return false
+	}
+	delete(entry.inFlight, id)
+	q.bytes -= messageSize(name, msg.Body)
+	q.drop(name)
+	return true
+}
+
+// nack makes the message delivered under receipt visible again, and
+// reports whether it did.
+func (q *queues) nack(name string, id, receipt uint64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, msg := q.inFlight(name, id, receipt)
+	if msg == nil {
+		return false
+	}
+	q.redeliver(entry, msg)
+	return true
+}
+
+// timeout makes the message delivered under receipt visible again if its
+// deadline has passed at now, and reports whether it did.
+func (q *queues) timeout(name string, id, receipt uint64, now int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, msg := q.inFlight(name, id, receipt)
+	if msg == nil || msg.Deadline == 0 || msg.Deadline > now {
+		return false
+	}
+	q.redeliver(entry, msg)
+	return true
+}
+
+func (q *queues) redeliver(entry *queue, msg *raftdv1.QueueMessage) {
+	delete(entry.inFlight, msg.Id)
+	entry.show(&raftdv1.QueueMessage{
+		Queue:    msg.Queue,
+		Id:       msg.Id,
+		Body:     msg.Body,
+		Attempts: msg.Attempts,
+	})
+}
+
+// timedOut returns the deliveries whose deadline has passed at now.
+func (q *queues) timedOut(now int64) []*raftdv1.QueueMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var timedOut []*raftdv1.QueueMessage
+	for _, entry := range q.queues {
+		for _, msg := range entry.inFlight {
+			if msg.Deadline != 0 && msg.Deadline <= now {
+				timedOut = append(timedOut, msg)
+			}
+		}
+	}
+	return timedOut
+}
+
+// peek returns the oldest visible message, or nil.
+func (q *queues) peek(name string) *raftdv1.QueueMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.queues[name]
+	if !ok || len(entry.visible) == 0 {
+		return nil
+	}
+	return entry.visible[0]
+}
+
+func (q *queues) length(name string) (visible, inFlight int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.queues[name]
+	if !ok {
+		return 0, 0
+	}
+	return len(entry.visible), len(entry.inFlight)
+}
+
+// list returns every message, for snapshots.
+func (q *queues) list() []*raftdv1.QueueMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var list []*raftdv1.QueueMessage
+	for _, entry := range q.queues {
+		list = append(list, entry.visible...)
+		for _, msg := range entry.inFlight {
+			list = append(list, msg)
+		}
+	}
+	return list
+}
+
+// replace swaps in the messages restored from a snapshot.
+func (q *queues) replace(messages []*raftdv1.QueueMessage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.queues = make(map[string]*queue)
+	q.bytes = 0
+	for _, msg := range messages {
+		q.bytes += messageSize(msg.Queue, msg.Body)
+		entry := q.get(msg.Queue)
+		if msg.Receipt != 0 {
+			entry.inFlight[msg.Id] = msg
+		} else {
+			entry.show(msg)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+const (
+	// defaultVisibilityTimeout is how long a delivered message stays
+	// hidden when Dequeue does not say.
+	defaultVisibilityTimeout = 30 * time.Second
+
+	// queueCheckInterval is how often the leader looks for timed out
+	// deliveries.
+	queueCheckInterval = 100 * time.Millisecond
+)
+
+// Enqueue implements raftdv1.QueueServiceServer. The message ID is the
+// raft index of the enqueue.
+func (s *Raftd) Enqueue(ctx context.Context, req *raftdv1.EnqueueRequest) (*raftdv1.EnqueueResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	if req.Queue == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "queue is required")
+	}
+	if err := s.checkWrite(req.Queue, req.Body); err != nil {
+		return nil, err
+	}
+
+	result, err := s.apply(ctx, &raftdv1.Command{
+		Op:       "queue_enqueue",
+		Key:      req.Queue,
+		Value:    req.Body,
+		ClientId: req.Session.GetClientId(),
+		Sequence: req.Session.GetSequence(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.EnqueueResponse{Id: result.Revision}, nil
+}
+
+// Dequeue implements raftdv1.QueueServiceServer. The receipt is the raft
+// index of the dequeue.
+func (s *Raftd) Dequeue(ctx context.Context, req *raftdv1.DequeueRequest) (*raftdv1.DequeueResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	if req.Queue == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "queue is required")
+	}
+
+	timeout := defaultVisibilityTimeout
+	if req.VisibilityTimeout != nil {
+		timeout = req.VisibilityTimeout.AsDuration()
+		if timeout <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "visibility timeout must be positive")
+		}
+	}
+
+	result, err := s.apply(ctx, &raftdv1.Command{
+		Op:       "queue_dequeue",
+		Key:      req.Queue,
+		Ttl:      int64(timeout),
+		ClientId: req.Session.GetClientId(),
+		Sequence: req.Session.GetSequence(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.DequeueResponse{Message: message(result.QueueMessage)}, nil
+}
+
+// Ack implements raftdv1.QueueServiceServer.
+func (s *Raftd) Ack(ctx context.Context, req *raftdv1.AckRequest) (*raftdv1.AckResponse, error) {
+	if err := s.settle(ctx, "queue_ack", req.Queue, req.Id, req.Receipt); err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.AckResponse{}, nil
+}
+
+// Nack implements raftdv1.QueueServiceServer.
+func (s *Raftd) Nack(ctx context.Context, req *raftdv1.NackRequest) (*raftdv1.NackResponse, error) {
+	if err := s.settle(ctx, "queue_nack", req.Queue, req.Id, req.Receipt); err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.NackResponse{}, nil
+}
+
+// Peek implements raftdv1.QueueServiceServer.
+func (s *Raftd) Peek(ctx context.Context, req *raftdv1.PeekRequest) (*raftdv1.PeekResponse, error) {
+	if err := s.checkConsistency(ctx, req.Consistency, req.MaxStaleness.AsDuration()); err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.PeekResponse{Message: message(s.fsm.queues.peek(req.Queue))}, nil
+}
+
+// Length implements raftdv1.QueueServiceServer.
+func (s *Raftd) Length(ctx context.Context, req *raftdv1.LengthRequest) (*raftdv1.LengthResponse, error) {
+	if err := s.checkConsistency(ctx, req.Consistency, req.MaxStaleness.AsDuration()); err != nil {
+		return nil, err
+	}
+
+	visible, inFlight := s.fsm.queues.length(req.Queue)
+	return &raftdv1.LengthResponse{Visible: int64(visible), InFlight: int64(inFlight)}, nil
+}
+
+// settle applies an ack or nack of the delivery receipt of message id.
+func (s *Raftd) settle(ctx context.Context, op, queue string, id, receipt uint64) error {
+	if s.raftEngine.State() != raft.Leader {
+		return status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	if queue == "" {
+		return status.Errorf(codes.InvalidArgument, "queue is required")
+	}
+
+	result, err := s.apply(ctx, &raftdv1.Command{
+		Op:        op,
+		Key:       queue,
+		MessageId: id,
+		Receipt:   receipt,
+	})
+	if err != nil {
+		return err
+	}
+	if result.Code == raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED {
+		return status.Errorf(codes.FailedPrecondition, "message is not in flight with this receipt")
+	}
+
+	return nil
+}
+
+// redeliverMessages proposes making visible again the messages whose
+// delivery timed out.
+func (s *Raftd) redeliverMessages(_, now time.Time) {
+	for _, msg := range s.fsm.queues.timedOut(now.UnixNano()) {
+		result, err := s.apply(context.Background(), &raftdv1.Command{
+			Op:        "queue_timeout",
+			Key:       msg.Queue,
+			MessageId: msg.Id,
+			Receipt:   msg.Receipt,
+		})
+		if err != nil {
+			s.logger.Warn("failed to redeliver message", "queue", msg.Queue, "id", msg.Id, "error", err)
+			return
+		}
+		if result.Code == raftdv1.ApplyCode_APPLY_CODE_OK {
+			s.logger.Debug("redelivering message", "queue", msg.Queue, "id", msg.Id, "attempts", msg.Attempts)
+		}
+	}
+}
+
+// message converts a queue table entry to its API form, or returns nil.
+func message(msg *raftdv1.QueueMessage) *raftdv1.Message {
+	if msg == nil {
+		return nil
+	}
+	return &raftdv1.Message{
+		Id:       msg.Id,
+		Body:     msg.Body,
+		Attempts: msg.Attempts,
+		Receipt:  msg.Receipt,
+	}
+}
@@ -3,13 +3,21 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
 	"net"
 	"os"
-	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/raft"
-	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -21,92 +29,376 @@ const (
 	transportMaxPool    = 5
 	transportTimeout    = 10 * time.Second
 	snapshotRetainCount = 3
+	restoreTimeout      = time.Minute
+	backupChunkSize     = 64 * 1024
 )
 
+// Defaults of the apply timeouts of Config.
+const (
+	DefaultApplyTimeout    = 5 * time.Second
+	DefaultMaxApplyTimeout = 30 * time.Second
+)
+
+// Config configures a Raftd node.
+type Config struct {
+	// RaftDir holds the raft log and snapshots.
+	RaftDir string
+
+	// LogStore is the backend of the raft log and stable store, one of
+	// LogStores. LogStoreBolt is used when empty. A node refuses to start
+	// with a backend other than the one RaftDir has; MigrateLogStore
+	// converts it.
+	LogStore string
+
+	// RaftBind is the address raft traffic is served on.
+	RaftBind string
+
+	// RaftNodeID identifies the node in the cluster.
+	RaftNodeID string
+
+	// GRPCAddr is the address the gRPC API is served on by Run.
+	GRPCAddr string
+
+	// Bootstrap makes a new node form a single-node cluster on first start.
+	// Nodes that will be added to an existing cluster with Join leave it
+	// unset. It is ignored for non-voters.
+	Bootstrap bool
+
+	// NonVoter starts the node as a read replica.
+	NonVoter bool
+
+	// Raft is the base raft configuration. raft.DefaultConfig is used
+	// when nil. LocalID is always set from RaftNodeID.
+	Raft *raft.Config
+
+	// Transport replaces the TCP transport bound to RaftBind, so that
+	// tests can run nodes over raft.InmemTransport.
+	Transport raft.Transport
+
+	// Logger receives the server logs, and the raft logs unless Raft sets
+	// its own Logger. slog.Default is used when nil.
+	Logger *slog.Logger
+
+	// MaxKeySize and MaxValueSize bound the keys and values of writes.
+	// DefaultMaxKeySize and DefaultMaxValueSize are used when zero.
+	MaxKeySize   int
+	MaxValueSize int
+
+	// MaxMsgSize bounds the gRPC messages the server receives.
+	// DefaultMaxMsgSize is used when zero.
+	MaxMsgSize int
+
+	// StorageQuota bounds the total size of the keys and values of every
+	// namespace, with their history until it is compacted, and of the
+	// queued messages. Once the leader finds it exceeded, it raises an
+	// alarm that rejects the writes adding data until enough is deleted
+	// and compacted. Zero means no quota.
+	StorageQuota int64
+
+	// RateLimit bounds the calls to the RPCs without a limit of their own
+	// in MethodRateLimits, which is keyed by full method name such as
+	// raftdv1.KVService_Set_FullMethodName. Calls over a limit fail with
+	// ResourceExhausted and a RetryInfo detail.
+	RateLimit        RateLimit
+	MethodRateLimits map[string]RateLimit
+
+	// MaxInFlightWrites bounds the writes being applied at once, whatever
+	// their RPC. Zero means no limit.
+	MaxInFlightWrites int
+
+	// ApplyTimeout bounds how long a write or linearizable read waits for
+	// raft when its call has no deadline, and MaxApplyTimeout bounds it
+	// when the call has one. DefaultApplyTimeout and
+	// DefaultMaxApplyTimeout are used when zero.
+	ApplyTimeout    time.Duration
+	MaxApplyTimeout time.Duration
+
+	// CompactionRevisions keeps the history of the last revisions, and
+	// CompactionAge the history written within the age. The leader
+	// compacts the rest every CompactionInterval, or every
+	// DefaultCompactionInterval when zero. With both set, history is kept
+	// as long as either asks for it. Zero keeps everything.
+	CompactionRevisions uint64
+	CompactionAge       time.Duration
+	CompactionInterval  time.Duration
+
+	// AuditFile, when set, is the JSONL file the node records every
+	// command it applies to. It is rotated once it reaches AuditMaxSize
+	// bytes, keeping AuditMaxFiles rotated files; DefaultAuditMaxSize and
+	// DefaultAuditMaxFiles are used when zero.
+	AuditFile     string
+	AuditMaxSize  int64
+	AuditMaxFiles int
+
+	// AuditReplicated makes the node, while it is the leader, record the
+	// commands it proposes in the replicated audit log served by
+	// AuditService.
+	AuditReplicated bool
+
+	// GRPCOptions are added to the options of NewGRPCServer, such as the
+	// TLS credentials whose client certificates name the principal of
+	// audit records.
+	GRPCOptions []grpc.ServerOption
+
+	// ChangeSinks receive the changes of every key from the leader, in
+	// revision order and at least once, keyed by a name under which their
+	// checkpoint is kept in raft. Every node should have the same sinks,
+	// so that delivery resumes after a leader change. The history is not
+	// compacted past the checkpoints, nor past those of the consumers of
+	// ChangeService. Sinks are closed with the server.
+	ChangeSinks map[string]ChangeSink
+
+	// ChangeConsumerRetention is how long the checkpoint of a consumer of
+	// ChangeService, or of a sink the leader does not have, holds back
+	// compaction after its last ack. DefaultChangeConsumerRetention is
+	// used when zero.
+	ChangeConsumerRetention time.Duration
+}
+
 type Raftd struct {
-	store      *store.Store
+	nodeID     string
+	logger     *slog.Logger
 	fsm        *FSM
 	raftEngine *raft.Raft
-	raftBoltDB *raftboltdb.BoltStore
+	logStore   logStore
+	snapshots  raft.SnapshotStore
+	limits     limits
+	admission  *admission
+
+	applyTimeout    time.Duration
+	maxApplyTimeout time.Duration
+	compaction      compactionPolicy
+	auditReplicated bool
+	grpcOptions     []grpc.ServerOption
+	changeSinks     map[string]ChangeSink
+	changeRetention time.Duration
+
+	shutdown chan struct{}
+	wg       sync.WaitGroup
 }
 
 var _ raftdv1.RaftServiceServer = (*Raftd)(nil)
 var _ raftdv1.KVServiceServer = (*Raftd)(nil)
-
-func NewRaftd(
-	raftDir string,
-	raftBind string,
-	raftNodeID string,
-) (*Raftd, error) {
+var _ raftdv1.LockServiceServer = (*Raftd)(nil)
+var _ raftdv1.QueueServiceServer = (*Raftd)(nil)
+var _ raftdv1.NamespaceServiceServer = (*Raftd)(nil)
+var _ raftdv1.AuditServiceServer = (*Raftd)(nil)
+var _ raftdv1.ChangeServiceServer = (*Raftd)(nil)
+
+// NewRaftd starts a raft node.
+func NewRaftd(cfg Config) (*Raftd, error) {
 	config := raft.DefaultConfig()
-	config.LocalID = raft.ServerID(raftNodeID)
+	if cfg.Raft != nil {
+		c := *cfg.Raft
+		config = &c
+	}
+	config.LocalID = raft.ServerID(cfg.RaftNodeID)
 
-	addr, err := net.ResolveTCPAddr("tcp", raftBind)
-	if err != nil {
-		return nil, err
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With("node", cfg.RaftNodeID)
+	raftLogger := newHCLogger(logger, "raft", nil)
+	if config.Logger == nil {
+		config.Logger = raftLogger
 	}
 
-	transport, err := raft.NewTCPTransport(
-		raftBind,
-		addr,
-		transportMaxPool,
-		transportTimeout,
-		os.Stderr,
-	)
-	if err != nil {
-		return nil, err
+	transport := cfg.Transport
+	if transport == nil {
+		addr, err := net.ResolveTCPAddr("tcp", cfg.RaftBind)
+		if err != nil {
+			return nil, err
+		}
+
+		transport, err = raft.NewTCPTransportWithLogger(
+			cfg.RaftBind,
+			addr,
+			transportMaxPool,
+			transportTimeout,
+			raftLogger.Named("transport"),
+		)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	snapshotStore, err := raft.NewFileSnapshotStore(
-		raftDir,
-		snapshotRetainCount,
-		os.Stderr,
-	)
+	var snapshotStore raft.SnapshotStore = raft.NewInmemSnapshotStore()
+	if cfg.LogStore != LogStoreMemory {
+		var err error
+		snapshotStore, err = raft.NewFileSnapshotStoreWithLogger(
+			cfg.RaftDir,
+			snapshotRetainCount,
+			raftLogger.Named("snapshot"),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	backend, err := openLogStore(cfg.RaftDir, cfg.LogStore, raftLogger.Named("wal"))
 	if err != nil {
 		return nil, err
 	}
-
-	boltStore, err := raftboltdb.NewBoltStore(filepath.Join(raftDir, "raft.db"))
+	logCache, err := raft.NewLogCache(logCacheSize, backend)
 	if err != nil {
+		_ = backend.Close()
 		return nil, err
 	}
 
-	memStore := store.New()
-	fsm := NewFSM(memStore)
+	fsm := NewFSM(store.New())
+	if cfg.AuditFile != "" {
+		maxSize, maxFiles := cfg.AuditMaxSize, cfg.AuditMaxFiles
+		if maxSize <= 0 {
+			maxSize = DefaultAuditMaxSize
+		}
+		if maxFiles <= 0 {
+			maxFiles = DefaultAuditMaxFiles
+		}
+		fsm.auditFile, err = openAuditFile(cfg.AuditFile, maxSize, maxFiles, logger)
+		if err != nil {
+			_ = backend.Close()
+			return nil, err
+		}
+	}
 
 	raftEngine, err := raft.NewRaft(
 		config,
 		fsm,
-		boltStore,
-		boltStore,
+		logCache,
+		backend,
 		snapshotStore,
 		transport,
 	)
 	if err != nil {
+		_ = backend.Close()
+		_ = fsm.auditFile.Close()
 		return nil, err
 	}
 
-	configuration := raft.Configuration{
-		Servers: []raft.Server{
-			{
-				ID:      raft.ServerID(raftNodeID),
-				Address: transport.LocalAddr(),
+	if cfg.Bootstrap && !cfg.NonVoter {
+		configuration := raft.Configuration{
+			Servers: []raft.Server{
+				{
+					ID:      config.LocalID,
+					Address: transport.LocalAddr(),
+				},
 			},
-		},
-	}
+		}
 
-	raftEngine.BootstrapCluster(configuration)
+		raftEngine.BootstrapCluster(configuration)
+	}
 
-	return &Raftd{
-		store:      memStore,
+	s := &Raftd{
+		nodeID:     cfg.RaftNodeID,
+		logger:     logger,
 		fsm:        fsm,
 		raftEngine: raftEngine,
-		raftBoltDB: boltStore,
-	}, nil
+		logStore:   backend,
+		snapshots:  snapshotStore,
+		limits:     newLimits(cfg),
+		admission:  newAdmission(cfg),
+		shutdown:   make(chan struct{}),
+
+		applyTimeout:    cfg.ApplyTimeout,
+		maxApplyTimeout: cfg.MaxApplyTimeout,
+		compaction: compactionPolicy{
+			revisions: cfg.CompactionRevisions,
+			age:       cfg.CompactionAge,
+		},
+		auditReplicated: cfg.AuditReplicated,
+		grpcOptions:     cfg.GRPCOptions,
+		changeSinks:     cfg.ChangeSinks,
+		changeRetention: cfg.ChangeConsumerRetention,
+	}
+	if s.applyTimeout <= 0 {
+		s.applyTimeout = DefaultApplyTimeout
+	}
+	if s.maxApplyTimeout <= 0 {
+		s.maxApplyTimeout = DefaultMaxApplyTimeout
+	}
+	if s.changeRetention <= 0 {
+		s.changeRetention = DefaultChangeConsumerRetention
+	}
+
+	s.wg.Add(3)
+	go s.whileLeader(leaseCheckInterval, s.expireLeases)
+	go s.whileLeader(queueCheckInterval, s.redeliverMessages)
+	go s.whileLeader(storageCheckInterval, s.checkStorage)
+	if cfg.CompactionRevisions > 0 || cfg.CompactionAge > 0 {
+		interval := cfg.CompactionInterval
+		if interval <= 0 {
+			interval = DefaultCompactionInterval
+		}
+		s.wg.Add(1)
+		go s.whileLeader(interval, s.autoCompact)
+	}
+	for name, sink := range cfg.ChangeSinks {
+		s.wg.Add(1)
+		go s.whileLeader(changeDeliveryInterval, s.deliverChanges(name, sink))
+	}
+
+	return s, nil
+}
+
+// Raft returns the underlying raft engine, for tests and tooling.
+func (s *Raftd) Raft() *raft.Raft {
+	return s.raftEngine
+}
+
+// Close shuts raft down and closes the log store, audit file and change
+// sinks. The transport is left to its owner.
+func (s *Raftd) Close() error {
+	close(s.shutdown)
+	s.wg.Wait()
+
+	if err := s.raftEngine.Shutdown().Error(); err != nil {
+		return err
+	}
+
+	errs := []error{s.logStore.Close(), s.fsm.auditFile.Close()}
+	for _, sink := range s.changeSinks {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// whileLeader calls task every interval while the node is the leader,
+// with the time it became leader, until the node is closed. Tasks
+// propose what only the leader decides, such as timeouts.
+func (s *Raftd) whileLeader(interval time.Duration, task func(leaderSince, now time.Time)) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var leaderSince time.Time
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case <-ticker.C:
+		}
+
+		if s.raftEngine.State() != raft.Leader {
+			leaderSince = time.Time{}
+			continue
+		}
+
+		now := time.Now()
+		if leaderSince.IsZero() {
+			leaderSince = now
+		}
+		task(leaderSince, now)
+	}
 }
 
 // Join implements raftdv1.RaftServiceServer.
 func (s *Raftd) Join(ctx context.Context, req *raftdv1.JoinRequest) (*raftdv1.JoinResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+
 	config := s.raftEngine.GetConfiguration()
 	if config.Error() != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get configuration: %v", config.Error())
@@ -116,11 +408,16 @@ func (s *Raftd) Join(ctx context.Context, req *raftdv1.JoinRequest) (*raftdv1.Jo
 		if server.ID == raft.ServerID(req.Id) || server.Address == raft.ServerAddress(req.Address) {
 			return nil, status.Errorf(codes.AlreadyExists, "server already joined")
 		}
+	}
 
-		future := s.raftEngine.AddVoter(raft.ServerID(req.Id), raft.ServerAddress(req.Address), 0, time.Second)
+	if req.NonVoter {
+		future := s.raftEngine.AddNonvoter(raft.ServerID(req.Id), raft.ServerAddress(req.Address), 0, time.Second)
 		if err := future.Error(); err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to add voter: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to add non-voter: %v", err)
 		}
+
+		s.logger.Info("added non-voter", "id", req.Id, "address", req.Address)
+		return &raftdv1.JoinResponse{}, nil
 	}
 
 	future := s.raftEngine.AddVoter(raft.ServerID(req.Id), raft.ServerAddress(req.Address), 0, time.Second)
@@ -128,81 +425,555 @@ func (s *Raftd) Join(ctx context.Context, req *raftdv1.JoinRequest) (*raftdv1.Jo
 		return nil, status.Errorf(codes.Internal, "failed to add voter: %v", err)
 	}
 
+	s.logger.Info("added voter", "id", req.Id, "address", req.Address)
 	return &raftdv1.JoinResponse{}, nil
 }
 
 // Leave implements raftdv1.RaftServiceServer.
-func (s *Raftd) Leave(context.Context, *raftdv1.LeaveRequest) (*raftdv1.LeaveResponse, error) {
-	panic("unimplemented")
+func (s *Raftd) Leave(ctx context.Context, req *raftdv1.LeaveRequest) (*raftdv1.LeaveResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+
+	config := s.raftEngine.GetConfiguration()
+	if config.Error() != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get configuration: %v", config.Error())
+	}
+
+	found := false
+	for _, server := range config.Configuration().Servers {
+		if server.ID == raft.ServerID(req.Id) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "server not found")
+	}
+
+	future := s.raftEngine.RemoveServer(raft.ServerID(req.Id), 0, time.Second)
+	if err := future.Error(); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to remove server: %v", err)
+	}
+
+	s.logger.Info("removed server", "id", req.Id)
+	return &raftdv1.LeaveResponse{}, nil
 }
 
 // Status implements raftdv1.RaftServiceServer.
 func (s *Raftd) Status(context.Context, *raftdv1.StatusRequest) (*raftdv1.StatusResponse, error) {
 	peers := make([]*raftdv1.Peer, 0, len(s.raftEngine.GetConfiguration().Configuration().Servers))
 	for _, peer := range s.raftEngine.GetConfiguration().Configuration().Servers {
-		peers = append(peers, &raftdv1.Peer{Id: string(peer.ID)})
+		peers = append(peers, &raftdv1.Peer{
+			Id:      string(peer.ID),
+			Address: string(peer.Address),
+			Replica: peer.Suffrage == raft.Nonvoter,
+		})
 	}
+	leaderAddr, leaderID := s.raftEngine.LeaderWithID()
 	return &raftdv1.StatusResponse{
-		Leader: string(s.raftEngine.Leader()),
-		Peers:  peers,
+		Leader:     string(leaderAddr),
+		Peers:      peers,
+		Id:         s.nodeID,
+		LeaderId:   string(leaderID),
+		Namespaces: s.fsm.namespaces.list(),
+		Storage: &raftdv1.Storage{
+			UsedBytes:  s.storageUsed(),
+			QuotaBytes: s.limits.storageQuota,
+			Alarm:      s.fsm.alarm.Load(),
+		},
+		Revision:        s.fsm.revision.Load(),
+		CompactRevision: s.fsm.namespaces.compactRevision(),
 	}, nil
 }
 
-// Set implements raftdv1.KVServiceServer.
-func (s *Raftd) Set(ctx context.Context, req *raftdv1.SetRequest) (*raftdv1.SetResponse, error) {
+// Restore implements raftdv1.RaftServiceServer.
+func (s *Raftd) Restore(stream raftdv1.RaftService_RestoreServer) error {
 	if s.raftEngine.State() != raft.Leader {
-		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+		return status.Errorf(codes.FailedPrecondition, "not the leader")
 	}
 
-	cmd := &raftdv1.Command{
-		Op:    "set",
-		Key:   req.Key,
-		Value: req.Value,
+	f, err := os.CreateTemp("", "raftd-restore-*.snap")
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to create temp file: %v", err)
 	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
 
-	data, err := json.Marshal(cmd)
+		if _, err := f.Write(req.Chunk); err != nil {
+			return status.Errorf(codes.Internal, "failed to buffer snapshot: %v", err)
+		}
+	}
+
+	// Decode the whole snapshot into a scratch FSM first so that a corrupt
+	// or foreign file is rejected before it replaces the cluster state.
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return status.Errorf(codes.Internal, "failed to rewind snapshot: %v", err)
+	}
+	scratch := NewFSM(store.New())
+	if err := scratch.Restore(io.NopCloser(f)); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid snapshot: %v", err)
+	}
+
+	size, err := f.Seek(0, io.SeekEnd)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to marshal command: %v", err)
+		return status.Errorf(codes.Internal, "failed to size snapshot: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return status.Errorf(codes.Internal, "failed to rewind snapshot: %v", err)
+	}
+
+	// Raft installs the snapshot past both its index and the last index
+	// of the cluster, so that the revisions, lease IDs, fencing tokens
+	// and message IDs issued from then on are higher than those restored.
+	meta := &raft.SnapshotMeta{
+		Version: raft.SnapshotVersionMax,
+		Index:   scratch.revision.Load(),
+		Size:    size,
+	}
+	if err := s.raftEngine.Restore(meta, f, restoreTimeout); err != nil {
+		return status.Errorf(codes.Internal, "failed to restore snapshot: %v", err)
+	}
+
+	s.logger.Info("restored snapshot", "size", size)
+	return stream.SendAndClose(&raftdv1.RestoreResponse{})
+}
+
+// Backup implements raftdv1.RaftServiceServer. It takes a snapshot, so that
+// the backup holds every entry applied so far, unless nothing was applied
+// since the last one.
+func (s *Raftd) Backup(_ *raftdv1.BackupRequest, stream grpc.ServerStreamingServer[raftdv1.BackupResponse]) error {
+	err := s.raftEngine.Snapshot().Error()
+	if err != nil && !errors.Is(err, raft.ErrNothingNewToSnapshot) {
+		return status.Errorf(codes.Internal, "failed to take snapshot: %v", err)
+	}
+
+	snapshots, err := s.snapshots.List()
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to list snapshots: %v", err)
+	}
+	if len(snapshots) == 0 {
+		return status.Errorf(codes.FailedPrecondition, "nothing applied to back up yet")
+	}
+	_, rc, err := s.snapshots.Open(snapshots[0].ID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to open snapshot: %v", err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	buf := make([]byte, backupChunkSize)
+	for {
+		n, err := rc.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&raftdv1.BackupResponse{Chunk: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to read snapshot: %v", err)
+		}
+	}
+
+	s.logger.Info("backed up snapshot", "index", snapshots[0].Index, "size", snapshots[0].Size)
+	return nil
+}
+
+// Set implements raftdv1.KVServiceServer.
+func (s *Raftd) Set(ctx context.Context, req *raftdv1.SetRequest) (*raftdv1.SetResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	if err := s.checkWrite(req.Key, req.Value); err != nil {
+		return nil, err
 	}
 
-	resp := s.raftEngine.Apply(data, time.Second)
-	if resp.Error() != nil {
-		return nil, status.Errorf(codes.Internal, "failed to apply command: %v", resp.Error())
+	result, err := s.apply(ctx, &raftdv1.Command{
+		Op:        "set",
+		Namespace: req.Namespace,
+		Key:       req.Key,
+		Value:     req.Value,
+		ClientId:  req.Session.GetClientId(),
+		Sequence:  req.Session.GetSequence(),
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &raftdv1.SetResponse{}, nil
+	return &raftdv1.SetResponse{Revision: result.Revision}, nil
 }
 
 // Get implements raftdv1.KVServiceServer.
 func (s *Raftd) Get(ctx context.Context, req *raftdv1.GetRequest) (*raftdv1.GetResponse, error) {
-	value, err := s.store.Get(req.Key)
+	if err := s.checkConsistency(ctx, req.Consistency, req.MaxStaleness.AsDuration()); err != nil {
+		return nil, err
+	}
+
+	kv, err := s.namespaceStore(req.Namespace)
 	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRevision(kv, req.Revision); err != nil {
+		return nil, err
+	}
+
+	var value []byte
+	if req.Revision == 0 {
+		value, err = kv.Get(req.Key)
+	} else {
+		value, err = kv.GetAt(req.Key, req.Revision)
+	}
+	switch {
+	case errors.Is(err, store.ErrCompacted):
+		return nil, compactedError(kv, req.Revision)
+	case err != nil:
 		return nil, status.Errorf(codes.NotFound, "key not found: %v", err)
 	}
 
 	return &raftdv1.GetResponse{Value: value}, nil
 }
 
+// Range implements raftdv1.KVServiceServer.
+func (s *Raftd) Range(ctx context.Context, req *raftdv1.RangeRequest) (*raftdv1.RangeResponse, error) {
+	if err := s.checkConsistency(ctx, req.Consistency, req.MaxStaleness.AsDuration()); err != nil {
+		return nil, err
+	}
+
+	kv, err := s.namespaceStore(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRevision(kv, req.Revision); err != nil {
+		return nil, err
+	}
+
+	var kvs []store.KeyValue
+	if req.Revision == 0 {
+		kvs = kv.Range(req.Prefix, int(req.Limit))
+	} else if kvs, err = kv.RangeAt(req.Prefix, int(req.Limit), req.Revision); err != nil {
+		return nil, compactedError(kv, req.Revision)
+	}
+	resp := &raftdv1.RangeResponse{Kvs: make([]*raftdv1.KeyValue, 0, len(kvs))}
+	for _, kv := range kvs {
+		resp.Kvs = append(resp.Kvs, &raftdv1.KeyValue{Key: kv.Key, Value: kv.Value})
+	}
+
+	return resp, nil
+}
+
+// namespaceStore returns the store of the namespace, or NotFound.
+func (s *Raftd) namespaceStore(name string) (*store.Store, error) {
+	kv := s.fsm.namespaces.store(name)
+	if kv == nil {
+		return nil, status.Errorf(codes.NotFound, "namespace not found")
+	}
+	return kv, nil
+}
+
+// checkConsistency rejects a local read that would not meet the requested
+// consistency. Staleness is measured as the time since this node last heard
+// from the leader, so the leader itself always qualifies. Linearizable reads
+// go through a raft barrier, which commits only with a quorum behind the
+// leader and returns once every earlier entry is applied.
+func (s *Raftd) checkConsistency(ctx context.Context, consistency raftdv1.ReadConsistency, maxStaleness time.Duration) error {
+	switch consistency {
+	case raftdv1.ReadConsistency_READ_CONSISTENCY_LINEARIZABLE:
+		if s.raftEngine.State() != raft.Leader {
+			return status.Errorf(codes.FailedPrecondition, "not the leader")
+		}
+		timeout, fromDeadline := s.raftTimeout(ctx)
+		return s.await(ctx, s.raftEngine.Barrier(timeout), timeout, fromDeadline, "failed to confirm leadership")
+	case raftdv1.ReadConsistency_READ_CONSISTENCY_BOUNDED:
+	default:
+		return nil
+	}
+
+	if maxStaleness <= 0 {
+		return status.Errorf(codes.InvalidArgument, "max staleness is required for bounded reads")
+	}
+
+	if s.raftEngine.State() == raft.Leader {
+		return nil
+	}
+
+	lastContact := s.raftEngine.LastContact()
+	if lastContact.IsZero() {
+		return status.Errorf(codes.Unavailable, "no contact with the leader")
+	}
+
+	if staleness := time.Since(lastContact); staleness > maxStaleness {
+		return status.Errorf(codes.Unavailable, "last contact with the leader was %s ago", staleness)
+	}
+
+	return nil
+}
+
+// checkRevision rejects reads at a revision this node has not applied yet
+// or whose history is compacted. Zero reads the current values.
+func (s *Raftd) checkRevision(kv *store.Store, revision uint64) error {
+	if revision == 0 {
+		return nil
+	}
+	if current := s.fsm.revision.Load(); revision > current {
+		return status.Errorf(codes.OutOfRange, "revision %d is not applied yet, the current revision is %d", revision, current)
+	}
+	if revision < kv.Compacted() {
+		return compactedError(kv, revision)
+	}
+	return nil
+}
+
+func compactedError(kv *store.Store, revision uint64) error {
+	return status.Errorf(codes.OutOfRange, "revision %d is compacted, the oldest readable revision is %d", revision, kv.Compacted())
+}
+
+// CompareAndSwap implements raftdv1.KVServiceServer.
+func (s *Raftd) CompareAndSwap(ctx context.Context, req *raftdv1.CompareAndSwapRequest) (*raftdv1.CompareAndSwapResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	if err := s.checkWrite(req.Key, req.Value); err != nil {
+		return nil, err
+	}
+
+	result, err := s.apply(ctx, &raftdv1.Command{
+		Op:            "cas",
+		Namespace:     req.Namespace,
+		Key:           req.Key,
+		Value:         req.Value,
+		Expected:      req.Expected,
+		ExpectMissing: req.Expected == nil,
+		ClientId:      req.Session.GetClientId(),
+		Sequence:      req.Session.GetSequence(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.CompareAndSwapResponse{
+		Swapped:  result.Code == raftdv1.ApplyCode_APPLY_CODE_OK,
+		Revision: result.Revision,
+	}, nil
+}
+
 // Delete implements raftdv1.KVServiceServer.
 func (s *Raftd) Delete(ctx context.Context, req *raftdv1.DeleteRequest) (*raftdv1.DeleteResponse, error) {
 	if s.raftEngine.State() != raft.Leader {
 		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
 	}
 
-	cmd := &raftdv1.Command{
-		Op:  "del",
-		Key: req.Key,
+	result, err := s.apply(ctx, &raftdv1.Command{
+		Op:        "del",
+		Namespace: req.Namespace,
+		Key:       req.Key,
+		ClientId:  req.Session.GetClientId(),
+		Sequence:  req.Session.GetSequence(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.DeleteResponse{
+		Revision: result.Revision,
+		Deleted:  result.PrevExists,
+	}, nil
+}
+
+// Increment implements raftdv1.KVServiceServer.
+func (s *Raftd) Increment(ctx context.Context, req *raftdv1.IncrementRequest) (*raftdv1.IncrementResponse, error) {
+	delta := req.Delta
+	if delta == 0 {
+		delta = 1
+	}
+
+	value, revision, err := s.add(ctx, req.Namespace, req.Key, delta, req.Initial, req.Min, req.Max, req.Session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.IncrementResponse{Value: value, Revision: revision}, nil
+}
+
+// Decrement implements raftdv1.KVServiceServer.
+func (s *Raftd) Decrement(ctx context.Context, req *raftdv1.DecrementRequest) (*raftdv1.DecrementResponse, error) {
+	delta := req.Delta
+	switch delta {
+	case 0:
+		delta = 1
+	case math.MinInt64:
+		return nil, status.Errorf(codes.InvalidArgument, "delta out of range")
+	}
+
+	value, revision, err := s.add(ctx, req.Namespace, req.Key, -delta, req.Initial, req.Min, req.Max, req.Session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.DecrementResponse{Value: value, Revision: revision}, nil
+}
+
+// add applies an "incr" command and returns the new value and revision.
+func (s *Raftd) add(ctx context.Context, namespace, key string, delta, initial int64, min, max *int64, session *raftdv1.WriteSession) (int64, uint64, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return 0, 0, status.Errorf(codes.FailedPrecondition, "not the leader")
 	}
+	if min != nil && max != nil && *min > *max {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "min is greater than max")
+	}
+	if err := s.checkWrite(key, nil); err != nil {
+		return 0, 0, err
+	}
+
+	result, err := s.apply(ctx, &raftdv1.Command{
+		Op:        "incr",
+		Namespace: namespace,
+		Key:       key,
+		Delta:     delta,
+		Initial:   initial,
+		Min:       min,
+		Max:       max,
+		ClientId:  session.GetClientId(),
+		Sequence:  session.GetSequence(),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	value, err := strconv.ParseInt(string(result.Value), 10, 64)
+	if err != nil {
+		return 0, 0, status.Errorf(codes.Internal, "invalid counter value %q", result.Value)
+	}
+
+	return value, result.Revision, nil
+}
+
+// apply commits cmd through raft and returns the result of FSM.Apply,
+// mapping failed results to gRPC errors. A failed condition is not an
+// error. The trace context of ctx travels with the log entry. apply gives
+// up when ctx is done or the timeout derived from it passes, in which case
+// cmd may still be applied later.
+func (s *Raftd) apply(ctx context.Context, cmd *raftdv1.Command) (*raftdv1.ApplyResult, error) {
+	cmd.Principal, cmd.ClientAddr = caller(ctx)
+	cmd.Audit = s.auditReplicated
 
 	data, err := json.Marshal(cmd)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to marshal command: %v", err)
 	}
 
-	resp := s.raftEngine.Apply(data, time.Second)
-	if resp.Error() != nil {
-		return nil, status.Errorf(codes.Internal, "failed to apply command: %v", resp.Error())
+	ctx, span := tracer.Start(ctx, "raft.Apply", trace.WithAttributes(attribute.String("raftd.op", cmd.Op)))
+	defer span.End()
+
+	timeout, fromDeadline := s.raftTimeout(ctx)
+	future := s.raftEngine.ApplyLog(raft.Log{
+		Data:       data,
+		Extensions: traceExtensions(ctx),
+	}, timeout)
+	if err := s.await(ctx, future, timeout, fromDeadline, "failed to apply command"); err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int64("raft.index", int64(future.Index())))
+
+	result, ok := future.Response().(*raftdv1.ApplyResult)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "unexpected apply result %T", future.Response())
+	}
+
+	switch result.Code {
+	case raftdv1.ApplyCode_APPLY_CODE_OK, raftdv1.ApplyCode_APPLY_CODE_CONDITION_FAILED:
+		return result, nil
+	case raftdv1.ApplyCode_APPLY_CODE_INVALID_COMMAND:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid command: %s", result.Message)
+	case raftdv1.ApplyCode_APPLY_CODE_UNKNOWN_OP:
+		return nil, status.Errorf(codes.Unimplemented, "%s", result.Message)
+	case raftdv1.ApplyCode_APPLY_CODE_INVALID_VALUE:
+		return nil, status.Errorf(codes.FailedPrecondition, "%s", result.Message)
+	case raftdv1.ApplyCode_APPLY_CODE_OUT_OF_RANGE:
+		return nil, status.Errorf(codes.OutOfRange, "%s", result.Message)
+	case raftdv1.ApplyCode_APPLY_CODE_NOT_FOUND:
+		return nil, status.Errorf(codes.NotFound, "%s", result.Message)
+	case raftdv1.ApplyCode_APPLY_CODE_ALREADY_EXISTS:
+		return nil, status.Errorf(codes.AlreadyExists, "%s", result.Message)
+	case raftdv1.ApplyCode_APPLY_CODE_QUOTA_EXCEEDED:
+		return nil, status.Errorf(codes.ResourceExhausted, "%s", result.Message)
+	default:
+		return nil, status.Errorf(codes.Internal, "apply failed with %s: %s", result.Code, result.Message)
+	}
+}
+
+// raftTimeout returns how long a raft operation for ctx may take: until
+// the deadline of ctx, up to the maximum, or the default without one. It
+// reports whether the deadline of ctx set the timeout.
+func (s *Raftd) raftTimeout(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return s.applyTimeout, false
 	}
+	timeout := time.Until(deadline)
+	if timeout > s.maxApplyTimeout {
+		return s.maxApplyTimeout, false
+	}
+	return timeout, true
+}
 
-	return &raftdv1.DeleteResponse{}, nil
+// await waits for future until ctx is done or timeout passes, and maps
+// its error to a gRPC error prefixed with what. A timeout set by the
+// deadline of the caller is reported as DeadlineExceeded, and one set by
+// the server as Unavailable, so that the call may be retried. So are the
+// errors raft returns when the leader changes or shuts down.
+func (s *Raftd) await(ctx context.Context, future raft.Future, timeout time.Duration, fromDeadline bool, what string) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- future.Error()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-timer.C:
+		err = raft.ErrEnqueueTimeout
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, raft.ErrEnqueueTimeout) && fromDeadline:
+		return status.Errorf(codes.DeadlineExceeded, "%s: timed out after %s", what, timeout)
+	case errors.Is(err, raft.ErrEnqueueTimeout):
+		return status.Errorf(codes.Unavailable, "%s: timed out after %s", what, timeout)
+	case errors.Is(err, raft.ErrNotLeader):
+		return status.Errorf(codes.FailedPrecondition, "not the leader")
+	case errors.Is(err, raft.ErrLeadershipLost),
+		errors.Is(err, raft.ErrLeadershipTransferInProgress),
+		errors.Is(err, raft.ErrAbortedByRestore),
+		errors.Is(err, raft.ErrRaftShutdown):
+		return status.Errorf(codes.Unavailable, "%s: %v", what, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", what, err)
+	}
 }
@@ -0,0 +1,353 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// ClientIDHeader is the gRPC metadata key identifying a client for per
+// client rate limits when the call has neither a verified TLS certificate
+// nor a peer address. Any client can set it, so it is never trusted over
+// them.
+const ClientIDHeader = "x-client-id"
+
+const (
+	// inFlightRetryDelay is the retry hint of calls rejected for too many
+	// calls in flight, which may end at any moment.
+	inFlightRetryDelay = 50 * time.Millisecond
+
+	// clientBucketIdleTimeout is how long the bucket of a silent client is
+	// kept. A client that comes back later starts with a full bucket.
+	clientBucketIdleTimeout = time.Minute
+)
+
+// meter uses the global meter provider, which does nothing unless the
+// binary installs one.
+var meter = otel.Meter("github.com/amjadjibon/raftd/server")
+
+// RateLimit is the admission control of a group of RPCs. The zero value
+// admits everything.
+type RateLimit struct {
+	// Rate is how many calls per second are admitted from all clients
+	// together, with bursts of up to Burst calls. Zero means no limit.
+	// Burst defaults to Rate, rounded up.
+	Rate  float64
+	Burst int
+
+	// PerClientRate and PerClientBurst limit every client alike.
+	PerClientRate  float64
+	PerClientBurst int
+
+	// MaxInFlight bounds the calls served at once. Zero means no limit.
+	MaxInFlight int
+}
+
+// writeMethods are the RPCs bounded by Config.MaxInFlightWrites: those
+// proposing a raft entry and returning once it is applied. Lock and
+// Campaign are left out since they wait for the lock for as long as the
+// client asks.
+var writeMethods = map[string]bool{
+	raftdv1.KVService_Set_FullMethodName:                      true,
+	raftdv1.KVService_Delete_FullMethodName:                   true,
+	raftdv1.KVService_CompareAndSwap_FullMethodName:           true,
+	raftdv1.KVService_Increment_FullMethodName:                true,
+	raftdv1.KVService_Decrement_FullMethodName:                true,
+	raftdv1.KVService_Compact_FullMethodName:                  true,
+	raftdv1.KVService_Import_FullMethodName:                   true,
+	raftdv1.KVService_Txn_FullMethodName:                      true,
+	raftdv1.LockService_Grant_FullMethodName:                  true,
+	raftdv1.LockService_KeepAlive_FullMethodName:              true,
+	raftdv1.LockService_Revoke_FullMethodName:                 true,
+	raftdv1.LockService_TryLock_FullMethodName:                true,
+	raftdv1.LockService_Unlock_FullMethodName:                 true,
+	raftdv1.LockService_Resign_FullMethodName:                 true,
+	raftdv1.QueueService_Enqueue_FullMethodName:               true,
+	raftdv1.QueueService_Dequeue_FullMethodName:               true,
+	raftdv1.QueueService_Ack_FullMethodName:                   true,
+	raftdv1.QueueService_Nack_FullMethodName:                  true,
+	raftdv1.NamespaceService_CreateNamespace_FullMethodName:   true,
+	raftdv1.NamespaceService_UpdateNamespace_FullMethodName:   true,
+	raftdv1.NamespaceService_DeleteNamespace_FullMethodName:   true,
+	raftdv1.ChangeService_AckChanges_FullMethodName:           true,
+	raftdv1.ChangeService_RemoveChangeConsumer_FullMethodName: true,
+}
+
+// admission enforces the rate limits of a node. The RPCs without a limit
+// of their own share the buckets of the default limit.
+type admission struct {
+	defaultLimit *limiter
+	methods      map[string]*limiter
+	writes       *semaphore
+	rejected     metric.Int64Counter
+}
+
+func newAdmission(cfg Config) *admission {
+	a := &admission{
+		defaultLimit: newLimiter(cfg.RateLimit),
+		methods:      make(map[string]*limiter, len(cfg.MethodRateLimits)),
+		writes:       newSemaphore(cfg.MaxInFlightWrites),
+	}
+	for method, limit := range cfg.MethodRateLimits {
+		a.methods[method] = newLimiter(limit)
+	}
+
+	// The counter is a no-op should the meter provider fail to create it.
+	a.rejected, _ = meter.Int64Counter("raftd.server.rejected_calls",
+		metric.WithDescription("Calls rejected by rate limits and in-flight limits."),
+		metric.WithUnit("{call}"),
+	)
+	return a
+}
+
+// admit reserves room for a call to method, and returns the function
+// releasing it once the call completes, or a ResourceExhausted error
+// carrying a retry hint.
+func (a *admission) admit(ctx context.Context, method string) (func(), error) {
+	l, ok := a.methods[method]
+	if !ok {
+		l = a.defaultLimit
+	}
+
+	// The bucket of the client comes first, so that a client over its own
+	// limit does not use up the tokens of the others, and gets its token
+	// back if the global limit rejects the call.
+	now := time.Now()
+	client := l.client(clientKey(ctx), now)
+	if wait := client.take(now); wait > 0 {
+		return nil, a.reject(ctx, method, "client_rate", wait)
+	}
+	if wait := l.global.take(now); wait > 0 {
+		client.refund()
+		return nil, a.reject(ctx, method, "rate", wait)
+	}
+
+	if !l.inFlight.acquire() {
+		return nil, a.reject(ctx, method, "in_flight", inFlightRetryDelay)
+	}
+	if writeMethods[method] && !a.writes.acquire() {
+		l.inFlight.release()
+		return nil, a.reject(ctx, method, "in_flight_writes", inFlightRetryDelay)
+	}
+
+	return func() {
+		if writeMethods[method] {
+			a.writes.release()
+		}
+		l.inFlight.release()
+	}, nil
+}
+
+func (a *admission) reject(ctx context.Context, method, reason string, retryDelay time.Duration) error {
+	if a.rejected != nil {
+		a.rejected.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("rpc.method", method),
+			attribute.String("raftd.reason", reason),
+		))
+	}
+
+	st := status.Newf(codes.ResourceExhausted, "rate limited (%s), retry in %s", reason, retryDelay)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryDelay)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// unaryAdmissionInterceptor rejects the unary calls over the limits.
+func unaryAdmissionInterceptor(a *admission) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		done, err := a.admit(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer done()
+
+		return handler(ctx, req)
+	}
+}
+
+// streamAdmissionInterceptor rejects the streams over the limits. A stream
+// counts as in flight until it ends.
+func streamAdmissionInterceptor(a *admission) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		done, err := a.admit(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer done()
+
+		return handler(srv, ss)
+	}
+}
+
+// clientKey identifies the client of a call like the audit log does: by
+// the subject of its verified TLS certificate, or else by its peer host.
+// Its ClientIDHeader is used only for calls with neither.
+func clientKey(ctx context.Context) string {
+	principal, addr := caller(ctx)
+	if principal != "" {
+		return "principal:" + principal
+	}
+	if addr != "" {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			return "addr:" + host
+		}
+		return "addr:" + addr
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(ClientIDHeader); len(ids) > 0 {
+			return "id:" + ids[0]
+		}
+	}
+	return ""
+}
+
+// limiter holds the buckets and in-flight count of a RateLimit.
+type limiter struct {
+	limit    RateLimit
+	global   *bucket
+	inFlight *semaphore
+
+	mu        sync.Mutex
+	clients   map[string]*bucket
+	lastSweep time.Time
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	return &limiter{
+		limit:    limit,
+		global:   newBucket(limit.Rate, limit.Burst),
+		inFlight: newSemaphore(limit.MaxInFlight),
+		clients:  make(map[string]*bucket),
+	}
+}
+
+// client returns the bucket of the client, or nil without a per client
+// limit.
+func (l *limiter) client(key string, now time.Time) *bucket {
+	if l.limit.PerClientRate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) >= clientBucketIdleTimeout {
+		for k, b := range l.clients {
+			if b.idle(now, clientBucketIdleTimeout) {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.clients[key]
+	if !ok {
+		b = newBucket(l.limit.PerClientRate, l.limit.PerClientBurst)
+		l.clients[key] = b
+	}
+	l.mu.Unlock()
+
+	return b
+}
+
+// bucket is a token bucket. A nil bucket admits everything.
+type bucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// take takes a token and returns zero, or returns how long until a token
+// is available if there is none.
+func (b *bucket) take(now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// refund returns a token taken for a call that was rejected after all.
+func (b *bucket) refund() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+func (b *bucket) idle(now time.Time, timeout time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return now.Sub(b.last) >= timeout
+}
+
+// semaphore bounds the calls in flight. A nil semaphore admits everything.
+type semaphore struct {
+	slots chan struct{}
+}
+
+func newSemaphore(n int) *semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return &semaphore{slots: make(chan struct{}, n)}
+}
+
+func (s *semaphore) acquire() bool {
+	if s == nil {
+		return true
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *semaphore) release() {
+	if s != nil {
+		<-s.slots
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"os"
+
+	"github.com/hashicorp/raft"
+
+	"github.com/amjadjibon/raftd/store"
+)
+
+// recoverNodeID is the local ID handed to raft for offline operations. It
+// only has to pass config validation and is never written anywhere.
+const recoverNodeID = "raftd-recover"
+
+// Configuration returns the raft configuration persisted under raftDir.
+// The node must be stopped.
+func Configuration(raftDir string) (raft.Configuration, error) {
+	if _, err := os.Stat(raftDir); err != nil {
+		return raft.Configuration{}, err
+	}
+
+	logs, snapshotStore, err := openStores(raftDir)
+	if err != nil {
+		return raft.Configuration{}, err
+	}
+	defer func() {
+		_ = logs.Close()
+	}()
+
+	_, trans := raft.NewInmemTransport("")
+	defer func() {
+		_ = trans.Close()
+	}()
+
+	return raft.GetConfiguration(
+		recoverConfig(),
+		NewFSM(store.New()),
+		logs,
+		logs,
+		snapshotStore,
+		trans,
+	)
+}
+
+// Recover rewrites the raft state under raftDir so that the node starts
+// with configuration as its membership, as described for peers.json in the
+// raft documentation. It is meant for clusters that have lost quorum and
+// must be run with the node stopped, on every surviving node, with the same
+// configuration.
+func Recover(raftDir string, configuration raft.Configuration) error {
+	if _, err := os.Stat(raftDir); err != nil {
+		return err
+	}
+
+	logs, snapshotStore, err := openStores(raftDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = logs.Close()
+	}()
+
+	_, trans := raft.NewInmemTransport("")
+	defer func() {
+		_ = trans.Close()
+	}()
+
+	return raft.RecoverCluster(
+		recoverConfig(),
+		NewFSM(store.New()),
+		logs,
+		logs,
+		snapshotStore,
+		trans,
+		configuration,
+	)
+}
+
+func recoverConfig() *raft.Config {
+	config := raft.DefaultConfig()
+	config.LocalID = recoverNodeID
+	return config
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+
+	"github.com/amjadjibon/raftd/store"
+)
+
+// Seed prepares an empty raft directory so that the next start brings up a
+// single-node cluster whose state is the snapshot at snapshotPath. Other
+// nodes can then be joined to it as usual.
+func Seed(
+	raftDir string,
+	raftBind string,
+	raftNodeID string,
+	snapshotPath string,
+) error {
+	if err := os.MkdirAll(raftDir, 0o755); err != nil {
+		return err
+	}
+
+	logs, snapshotStore, err := openStores(raftDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = logs.Close()
+	}()
+
+	hasState, err := raft.HasExistingState(logs, logs, snapshotStore)
+	if err != nil {
+		return err
+	}
+	if hasState {
+		return errors.New("raft directory already has state, refusing to overwrite it")
+	}
+
+	f, err := os.Open(snapshotPath)
+	if err != nil {
+		return err
+	}
+
+	fsm := NewFSM(store.New())
+	if err := fsm.Restore(f); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	addr, trans := raft.NewInmemTransport(raft.ServerAddress(raftBind))
+	defer func() {
+		_ = trans.Close()
+	}()
+
+	configuration := raft.Configuration{
+		Servers: []raft.Server{
+			{
+				ID:      raft.ServerID(raftNodeID),
+				Address: addr,
+			},
+		},
+	}
+
+	// Place the configuration at index 1, the same position
+	// BootstrapCluster would use, and the snapshot at the highest index
+	// it refers to, so that the revisions, lease IDs, fencing tokens and
+	// message IDs issued after the start are higher than those restored.
+	index := max(fsm.revision.Load(), 1)
+	sink, err := snapshotStore.Create(raft.SnapshotVersionMax, index, 1, configuration, 1, trans)
+	if err != nil {
+		return err
+	}
+
+	return snap.Persist(sink)
+}
+
+// openStores opens the log, stable and snapshot stores kept under raftDir
+// for offline maintenance while the node is stopped. The log store is the
+// one the directory has, or an empty one in memory when it has none, so
+// that a directory seeded for any backend is left without one.
+func openStores(raftDir string) (logStore, *raft.FileSnapshotStore, error) {
+	snapshotStore, err := raft.NewFileSnapshotStore(
+		raftDir,
+		snapshotRetainCount,
+		os.Stderr,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	backend, err := detectLogStore(raftDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if backend == "" {
+		backend = LogStoreMemory
+	}
+	logs, err := newLogStore(raftDir, backend, hclog.NewNullLogger())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return logs, snapshotStore, nil
+}
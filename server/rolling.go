@@ -0,0 +1,97 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// rollingFile is an append-only file rotated by size. Once a write would
+// take it over maxSize, it is renamed path.1, the previous path.1 renamed
+// path.2 and so on, keeping maxFiles rotated files.
+type rollingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	size int64
+}
+
+func openRollingFile(path string, maxSize int64, maxFiles int) (*rollingFile, error) {
+	r := &rollingFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rollingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+// write appends p, rotating the file first if p would take it over its
+// maximum size. A write is never split across files.
+func (r *rollingFile) write(p []byte) error {
+	if r.file != nil && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate %s: %w", r.path, err)
+		}
+	}
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return err
+}
+
+func (r *rollingFile) sync() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// rotate shifts the rotated files by one, dropping the oldest, and starts
+// a new file.
+func (r *rollingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	if err := os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxFiles)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := r.maxFiles - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *rollingFile) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
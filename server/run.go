@@ -2,42 +2,63 @@ package server
 
 import (
 	"context"
+	"errors"
 	"net"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 
 	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
 )
 
-func Run(
-	ctx context.Context,
-	raftDir string,
-	raftBind string,
-	raftNodeID string,
-	grpcAddr string,
-) error {
-	lis, err := net.Listen("tcp", grpcAddr)
+func Run(ctx context.Context, cfg Config) error {
+	lis, err := net.Listen("tcp", cfg.GRPCAddr)
 	if err != nil {
 		return err
 	}
 
-	grpcServer := grpc.NewServer()
-	raftd, err := NewRaftd(raftDir, raftBind, raftNodeID)
+	raftd, err := NewRaftd(cfg)
 	if err != nil {
 		return err
 	}
 
-	raftdv1.RegisterRaftServiceServer(grpcServer, raftd)
-	raftdv1.RegisterKVServiceServer(grpcServer, raftd)
+	grpcServer := NewGRPCServer(raftd)
+	raftd.logger.Info("serving gRPC", "addr", lis.Addr().String())
 
 	go func() {
 		<-ctx.Done()
 		grpcServer.GracefulStop()
 	}()
 
-	if err = grpcServer.Serve(lis); err != nil {
-		return err
-	}
+	err = grpcServer.Serve(lis)
+	return errors.Join(err, raftd.Close())
+}
 
-	return nil
+// NewGRPCServer returns a gRPC server with the raftd services registered.
+// Calls are traced with the global OpenTelemetry provider and logged
+// through the logger of raftd, then admitted by its rate limits. Received
+// messages are bounded by the MaxMsgSize of raftd. The GRPCOptions of raftd
+// come last.
+func NewGRPCServer(raftd *Raftd) *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(raftd.limits.maxMsgSize),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			unaryLoggingInterceptor(raftd.logger),
+			unaryAdmissionInterceptor(raftd.admission),
+		),
+		grpc.ChainStreamInterceptor(
+			streamLoggingInterceptor(raftd.logger),
+			streamAdmissionInterceptor(raftd.admission),
+		),
+	}
+	grpcServer := grpc.NewServer(append(opts, raftd.grpcOptions...)...)
+	raftdv1.RegisterRaftServiceServer(grpcServer, raftd)
+	raftdv1.RegisterKVServiceServer(grpcServer, raftd)
+	raftdv1.RegisterLockServiceServer(grpcServer, raftd)
+	raftdv1.RegisterQueueServiceServer(grpcServer, raftd)
+	raftdv1.RegisterNamespaceServiceServer(grpcServer, raftd)
+	raftdv1.RegisterAuditServiceServer(grpcServer, raftd)
+	raftdv1.RegisterChangeServiceServer(grpcServer, raftd)
+	return grpcServer
 }
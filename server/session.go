@@ -0,0 +1,83 @@
+package server
+
+import (
+	"time"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+const (
+	// sessionTTL is how long the result of a write is kept for retries.
+	// A retry that arrives later is applied again.
+	sessionTTL = 10 * time.Minute
+
+	// sessionPruneEntries is how many log entries apart expired results
+	// are dropped. Counting entries rather than time keeps every node,
+	// including those restored from a snapshot, on the same schedule.
+	sessionPruneEntries = 1024
+)
+
+type sessionKey struct {
+	clientID string
+	sequence uint64
+}
+
+// sessions is the dedup table of the FSM. Time is taken from the leader's
+// clock as recorded in the log, so that every node expires the same
+// entries. It is only used from the FSM goroutine.
+type sessions struct {
+	entries map[sessionKey]*raftdv1.Session
+}
+
+func newSessions() *sessions {
+	return &sessions{entries: make(map[sessionKey]*raftdv1.Session)}
+}
+
+// get returns the result of an earlier write, or nil if there is none or
+// it is older than sessionTTL at now. Expiry does not depend on whether
+// the entry was pruned yet.
+func (s *sessions) get(clientID string, sequence uint64, now int64) *raftdv1.ApplyResult {
+	entry, ok := s.entries[sessionKey{clientID, sequence}]
+	if !ok || expired(entry, now) {
+		return nil
+	}
+	return entry.Result
+}
+
+func (s *sessions) put(clientID string, sequence uint64, result *raftdv1.ApplyResult, appliedAt int64) {
+	s.entries[sessionKey{clientID, sequence}] = &raftdv1.Session{
+		ClientId:  clientID,
+		Sequence:  sequence,
+		Result:    result,
+		AppliedAt: appliedAt,
+	}
+}
+
+// prune drops the results older than sessionTTL at now, once every
+// sessionPruneEntries log entries.
+func (s *sessions) prune(index uint64, now int64) {
+	if index%sessionPruneEntries != 0 {
+		return
+	}
+	for key, entry := range s.entries {
+		if expired(entry, now) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// expired reports whether entry is older than sessionTTL at now. Nothing
+// expires in entries without a time.
+func expired(entry *raftdv1.Session, now int64) bool {
+	return now != 0 && now-entry.AppliedAt > int64(sessionTTL)
+}
+
+// list returns every entry, for snapshots. Entries are never modified, so
+// they can be shared.
+func (s *sessions) list() []*raftdv1.Session {
+	list := make([]*raftdv1.Session, 0, len(s.entries))
+	for _, entry := range s.entries {
+		list = append(list, entry)
+	}
+	return list
+}
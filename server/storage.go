@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// Defaults of the size limits of Config.
+const (
+	DefaultMaxKeySize   = 4 << 10
+	DefaultMaxValueSize = 1 << 20
+	DefaultMaxMsgSize   = 4 << 20
+)
+
+// storageCheckInterval is how often the leader compares the storage used
+// with the quota.
+const storageCheckInterval = 100 * time.Millisecond
+
+// limits are the size limits of a node, with defaults applied.
+type limits struct {
+	maxKeySize   int
+	maxValueSize int
+	maxMsgSize   int
+	storageQuota int64
+}
+
+func newLimits(cfg Config) limits {
+	l := limits{
+		maxKeySize:   cfg.MaxKeySize,
+		maxValueSize: cfg.MaxValueSize,
+		maxMsgSize:   cfg.MaxMsgSize,
+		storageQuota: cfg.StorageQuota,
+	}
+	if l.maxKeySize <= 0 {
+		l.maxKeySize = DefaultMaxKeySize
+	}
+	if l.maxValueSize <= 0 {
+		l.maxValueSize = DefaultMaxValueSize
+	}
+	if l.maxMsgSize <= 0 {
+		l.maxMsgSize = DefaultMaxMsgSize
+	}
+	return l
+}
+
+// checkWrite rejects a write of value to key that exceeds the size limits,
+// or any such write while the storage alarm is raised. Writes that only
+// remove data are not checked, so that space can be freed.
+func (s *Raftd) checkWrite(key string, value []byte) error {
+	if len(key) > s.limits.maxKeySize {
+		return status.Errorf(codes.InvalidArgument, "key of %d bytes exceeds the limit of %d", len(key), s.limits.maxKeySize)
+	}
+	if len(value) > s.limits.maxValueSize {
+		return status.Errorf(codes.InvalidArgument, "value of %d bytes exceeds the limit of %d", len(value), s.limits.maxValueSize)
+	}
+	if s.fsm.alarm.Load() {
+		return status.Errorf(codes.ResourceExhausted, "storage quota exceeded, writes are rejected until space is freed")
+	}
+	return nil
+}
+
+// storageUsed returns the size of the keys and values of every namespace,
+// with their history, and of the queued messages, which the storage quota
+// bounds.
+func (s *Raftd) storageUsed() int64 {
+	return s.fsm.namespaces.size() + s.fsm.queues.size()
+}
+
+// checkStorage raises the storage alarm once the keys, values and queued
+// messages exceed the quota, and clears it once they fit again.
+func (s *Raftd) checkStorage(_, _ time.Time) {
+	if s.limits.storageQuota <= 0 && !s.fsm.alarm.Load() {
+		return
+	}
+
+	used := s.storageUsed()
+	exceeded := s.limits.storageQuota > 0 && used > s.limits.storageQuota
+	if exceeded == s.fsm.alarm.Load() {
+		return
+	}
+
+	op := "alarm_clear"
+	if exceeded {
+		op = "alarm_raise"
+	}
+	if _, err := s.apply(context.Background(), &raftdv1.Command{Op: op}); err != nil {
+		s.logger.Warn("failed to update storage alarm", "op", op, "error", err)
+		return
+	}
+	if exceeded {
+		s.logger.Warn("storage quota exceeded, rejecting writes", "used", used, "quota", s.limits.storageQuota)
+	} else {
+		s.logger.Info("storage back under quota, accepting writes", "used", used, "quota", s.limits.storageQuota)
+	}
+}
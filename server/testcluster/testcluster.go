@@ -0,0 +1,448 @@
+// Package testcluster runs raftd clusters inside a single test process.
+//
+// Nodes talk raft over raft.InmemTransport, keep their data in temporary
+// directories and serve the gRPC API on loopback ports, so tests can drive
+// them either directly or through the client package. Nodes can be
+// partitioned, killed and restarted to exercise failure handling.
+package testcluster
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/amjadjibon/raftd/client"
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+	"github.com/amjadjibon/raftd/server"
+)
+
+// logger only shows errors, which keeps the output of failing tests
+// readable.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+const (
+	// DefaultTimeout bounds the Wait helpers.
+	DefaultTimeout = 10 * time.Second
+
+	pollInterval = 10 * time.Millisecond
+)
+
+// Node is a member of a Cluster.
+type Node struct {
+	ID       string
+	Addr     raft.ServerAddress
+	Dir      string
+	GRPCAddr string
+	NonVoter bool
+
+	cluster    *Cluster
+	transport  *raft.InmemTransport
+	raftd      *server.Raftd
+	grpcServer *grpc.Server
+}
+
+// Raftd returns the running server of the node, or nil if it is killed.
+func (n *Node) Raftd() *server.Raftd {
+	n.cluster.mu.Lock()
+	defer n.cluster.mu.Unlock()
+	return n.raftd
+}
+
+// Running reports whether the node is up.
+func (n *Node) Running() bool {
+	return n.Raftd() != nil
+}
+
+// Cluster is a set of nodes running in the test process.
+type Cluster struct {
+	t testing.TB
+
+	mu          sync.Mutex
+	nodes       []*Node
+	partitioned map[*Node]bool
+	raftConfig  func(*raft.Config)
+	config      func(*server.Config)
+}
+
+// Option configures a Cluster.
+type Option func(*Cluster)
+
+// WithRaftConfig lets a test adjust the raft configuration of every node,
+// for example to snapshot more often.
+func WithRaftConfig(fn func(*raft.Config)) Option {
+	return func(c *Cluster) {
+		c.raftConfig = fn
+	}
+}
+
+// WithConfig lets a test adjust the server configuration of every node,
+// for example to lower its size limits.
+func WithConfig(fn func(*server.Config)) Option {
+	return func(c *Cluster) {
+		c.config = fn
+	}
+}
+
+// New starts a cluster of n voters and waits until all of them have joined.
+// The cluster is shut down when the test ends.
+func New(t testing.TB, n int, opts ...Option) *Cluster {
+	t.Helper()
+
+	c := &Cluster{
+		t:           t,
+		partitioned: make(map[*Node]bool),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	t.Cleanup(c.Close)
+
+	c.start(c.newNode(false), true)
+	c.WaitForLeader()
+
+	for i := 1; i < n; i++ {
+		c.AddNode(false)
+	}
+
+	return c
+}
+
+// AddNode starts a new node and joins it through the leader, as a read
+// replica if nonVoter is set.
+func (c *Cluster) AddNode(nonVoter bool) *Node {
+	c.t.Helper()
+
+	node := c.newNode(nonVoter)
+	c.start(node, false)
+
+	_, err := c.WaitForLeader().Raftd().Join(context.Background(), &raftdv1.JoinRequest{
+		Id:       node.ID,
+		Address:  string(node.Addr),
+		NonVoter: nonVoter,
+	})
+	if err != nil {
+		c.t.Fatalf("join %s: %v", node.ID, err)
+	}
+
+	return node
+}
+
+func (c *Cluster) newNode(nonVoter bool) *Node {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := fmt.Sprintf("node%d", len(c.nodes))
+	node := &Node{
+		ID:       id,
+		Addr:     raft.ServerAddress(id),
+		Dir:      c.t.TempDir(),
+		NonVoter: nonVoter,
+		cluster:  c,
+	}
+	c.nodes = append(c.nodes, node)
+	return node
+}
+
+// start brings node up with a fresh transport connected to every running,
+// unpartitioned node.
+func (c *Cluster) start(node *Node, bootstrap bool) {
+	c.t.Helper()
+
+	config := raft.DefaultConfig()
+	config.HeartbeatTimeout = 100 * time.Millisecond
+	config.ElectionTimeout = 100 * time.Millisecond
+	config.LeaderLeaseTimeout = 50 * time.Millisecond
+	config.CommitTimeout = 5 * time.Millisecond
+	if c.raftConfig != nil {
+		c.raftConfig(config)
+	}
+
+	_, transport := raft.NewInmemTransport(node.Addr)
+
+	cfg := server.Config{
+		RaftDir:    node.Dir,
+		RaftBind:   string(node.Addr),
+		RaftNodeID: node.ID,
+		Bootstrap:  bootstrap,
+		NonVoter:   node.NonVoter,
+		Raft:       config,
+		Transport:  transport,
+		Logger:     logger,
+	}
+	if c.config != nil {
+		c.config(&cfg)
+	}
+
+	raftd, err := server.NewRaftd(cfg)
+	if err != nil {
+		c.t.Fatalf("start %s: %v", node.ID, err)
+	}
+
+	// Reuse the previous port on restart so clients keep working.
+	addr := node.GRPCAddr
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		c.t.Fatalf("listen %s: %v", node.ID, err)
+	}
+
+	grpcServer := server.NewGRPCServer(raftd)
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+
+	c.mu.Lock()
+	node.GRPCAddr = lis.Addr().String()
+	node.transport = transport
+	node.raftd = raftd
+	node.grpcServer = grpcServer
+	c.mu.Unlock()
+
+	c.connect()
+}
+
+// connect wires the transports of all running nodes, skipping links that
+// cross a partition.
+func (c *Cluster) connect() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, a := range c.nodes {
+		if a.raftd == nil {
+			continue
+		}
+		for _, b := range c.nodes {
+			if a == b || b.raftd == nil {
+				continue
+			}
+			if c.partitioned[a] != c.partitioned[b] {
+				a.transport.Disconnect(b.Addr)
+				continue
+			}
+			a.transport.Connect(b.Addr, b.transport)
+		}
+	}
+}
+
+// Nodes returns every node, running or not.
+func (c *Cluster) Nodes() []*Node {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Node(nil), c.nodes...)
+}
+
+// Node returns the i-th node.
+func (c *Cluster) Node(i int) *Node {
+	return c.Nodes()[i]
+}
+
+// Endpoints returns the gRPC addresses of all nodes.
+func (c *Cluster) Endpoints() []string {
+	nodes := c.Nodes()
+	endpoints := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		endpoints = append(endpoints, node.GRPCAddr)
+	}
+	return endpoints
+}
+
+// Client returns a client for every node, closed when the test ends.
+func (c *Cluster) Client() *client.Client {
+	c.t.Helper()
+
+	cl, err := client.New(client.Config{
+		Endpoints:  c.Endpoints(),
+		MaxRetries: 20,
+	})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.t.Cleanup(func() {
+		_ = cl.Close()
+	})
+	return cl
+}
+
+// Leader returns the running node that currently considers itself the
+// leader, if any.
+func (c *Cluster) Leader() *Node {
+	for _, node := range c.Nodes() {
+		raftd := node.Raftd()
+		if raftd != nil && raftd.Raft().State() == raft.Leader {
+			return node
+		}
+	}
+	return nil
+}
+
+// Followers returns the running nodes that are not the leader.
+func (c *Cluster) Followers() []*Node {
+	var followers []*Node
+	for _, node := range c.Nodes() {
+		raftd := node.Raftd()
+		if raftd != nil && raftd.Raft().State() != raft.Leader {
+			followers = append(followers, node)
+		}
+	}
+	return followers
+}
+
+// WaitForLeader waits until a single node outside of any partition is the
+// leader and returns it.
+func (c *Cluster) WaitForLeader() *Node {
+	c.t.Helper()
+
+	var leader *Node
+	c.WaitFor("a leader", func() bool {
+		leader = nil
+		for _, node := range c.Nodes() {
+			raftd := node.Raftd()
+			if raftd == nil || raftd.Raft().State() != raft.Leader {
+				continue
+			}
+			c.mu.Lock()
+			partitioned := c.partitioned[node]
+			c.mu.Unlock()
+			if partitioned {
+				continue
+			}
+			if leader != nil {
+				return false
+			}
+			leader = node
+		}
+		return leader != nil
+	})
+	return leader
+}
+
+// WaitForApplied waits until every running node has applied the leader's
+// log and holds the same data as the leader.
+func (c *Cluster) WaitForApplied() {
+	c.t.Helper()
+
+	leader := c.WaitForLeader()
+	if err := leader.Raftd().Raft().Barrier(DefaultTimeout).Error(); err != nil {
+		c.t.Fatalf("barrier: %v", err)
+	}
+	index := leader.Raftd().Raft().AppliedIndex()
+	want := c.dump(leader)
+
+	// raft reports an entry as applied once it is handed to the FSM, so the
+	// data is compared as well to be sure the FSM has caught up.
+	c.WaitFor("replication", func() bool {
+		for _, node := range c.Nodes() {
+			raftd := node.Raftd()
+			if raftd == nil {
+				continue
+			}
+			if raftd.Raft().AppliedIndex() < index || !proto.Equal(c.dump(node), want) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// dump returns every key/value pair held by node.
+func (c *Cluster) dump(node *Node) *raftdv1.RangeResponse {
+	c.t.Helper()
+
+	raftd := node.Raftd()
+	if raftd == nil {
+		return nil
+	}
+
+	resp, err := raftd.Range(context.Background(), &raftdv1.RangeRequest{})
+	if err != nil {
+		c.t.Fatalf("range %s: %v", node.ID, err)
+	}
+	return resp
+}
+
+// WaitFor polls cond until it holds, failing the test after DefaultTimeout.
+func (c *Cluster) WaitFor(what string, cond func() bool) {
+	c.t.Helper()
+
+	deadline := time.Now().Add(DefaultTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			c.t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Partition cuts the given nodes off from the others. They can still talk
+// among themselves.
+func (c *Cluster) Partition(nodes ...*Node) {
+	c.mu.Lock()
+	for _, node := range nodes {
+		c.partitioned[node] = true
+	}
+	c.mu.Unlock()
+
+	c.connect()
+}
+
+// Heal removes every partition.
+func (c *Cluster) Heal() {
+	c.mu.Lock()
+	c.partitioned = make(map[*Node]bool)
+	c.mu.Unlock()
+
+	c.connect()
+}
+
+// Kill stops node, leaving its data directory in place.
+func (c *Cluster) Kill(node *Node) {
+	c.t.Helper()
+
+	c.mu.Lock()
+	raftd, grpcServer, transport := node.raftd, node.grpcServer, node.transport
+	node.raftd, node.grpcServer = nil, nil
+	for _, other := range c.nodes {
+		if other.transport != nil && other != node {
+			other.transport.Disconnect(node.Addr)
+		}
+	}
+	c.mu.Unlock()
+
+	if raftd == nil {
+		return
+	}
+
+	grpcServer.Stop()
+	transport.DisconnectAll()
+	if err := raftd.Close(); err != nil {
+		c.t.Errorf("close %s: %v", node.ID, err)
+	}
+}
+
+// Restart starts a killed node again from its data directory.
+func (c *Cluster) Restart(node *Node) {
+	c.t.Helper()
+
+	if node.Running() {
+		c.t.Fatalf("restart %s: node is running", node.ID)
+	}
+	c.start(node, false)
+}
+
+// Close kills every node.
+func (c *Cluster) Close() {
+	for _, node := range c.Nodes() {
+		c.Kill(node)
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// tracer uses the global tracer provider, which does nothing unless the
+// binary installs one.
+var tracer = otel.Tracer("github.com/amjadjibon/raftd/server")
+
+// traceExtensions encodes the trace context of ctx for raft.Log.Extensions,
+// so that FSM.Apply continues the trace on every node.
+func traceExtensions(ctx context.Context) []byte {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(carrier)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// traceContext decodes the trace context written by traceExtensions.
+func traceContext(extensions []byte) context.Context {
+	ctx := context.Background()
+
+	var carrier propagation.MapCarrier
+	if err := json.Unmarshal(extensions, &carrier); err != nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"errors"
+	"io"
+
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+const (
+	// exportBatchSize bounds the size of the pairs of an ExportResponse.
+	exportBatchSize = 1 << 20
+
+	// importBatchSize is the size of the pairs Import gathers into a raft
+	// entry before applying them. A batch goes over it by one pair at most.
+	importBatchSize = 1 << 20
+)
+
+// Export implements raftdv1.KVServiceServer. The pairs are read at once, so
+// they reflect a single revision.
+func (s *Raftd) Export(req *raftdv1.ExportRequest, stream grpc.ServerStreamingServer[raftdv1.ExportResponse]) error {
+	ctx := stream.Context()
+	if err := s.checkConsistency(ctx, req.Consistency, req.MaxStaleness.AsDuration()); err != nil {
+		return err
+	}
+
+	kv, err := s.namespaceStore(req.Namespace)
+	if err != nil {
+		return err
+	}
+	if err := s.checkRevision(kv, req.Revision); err != nil {
+		return err
+	}
+
+	// The pairs are read at the revision reported, rather than as they are
+	// now, which a write applied since would move past it.
+	revision := req.Revision
+	if revision == 0 {
+		revision = s.fsm.revision.Load()
+	}
+	kvs, err := kv.RangeAt(req.Prefix, 0, revision)
+	if err != nil {
+		return compactedError(kv, revision)
+	}
+
+	resp := &raftdv1.ExportResponse{Revision: revision}
+	size := 0
+	for _, pair := range kvs {
+		resp.Kvs = append(resp.Kvs, &raftdv1.KeyValue{Key: pair.Key, Value: pair.Value})
+		size += len(pair.Key) + len(pair.Value)
+		if size < exportBatchSize {
+			continue
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+		resp, size = &raftdv1.ExportResponse{}, 0
+	}
+	if len(resp.Kvs) > 0 || resp.Revision != 0 {
+		return stream.Send(resp)
+	}
+	return nil
+}
+
+// Import implements raftdv1.KVServiceServer.
+func (s *Raftd) Import(stream grpc.ClientStreamingServer[raftdv1.ImportRequest, raftdv1.ImportResponse]) error {
+	if s.raftEngine.State() != raft.Leader {
+		return status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+
+	ctx := stream.Context()
+	resp := &raftdv1.ImportResponse{}
+	var namespace string
+	var dryRun bool
+	var batch []*raftdv1.KeyValue
+	size := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if !dryRun {
+			result, err := s.apply(ctx, &raftdv1.Command{Op: "import", Namespace: namespace, Kvs: batch})
+			if err != nil {
+				st := status.Convert(err)
+				return status.Errorf(st.Code(), "import failed after %d keys: %s", resp.Keys, st.Message())
+			}
+			resp.Revision = result.Revision
+		}
+		resp.Keys += uint64(len(batch))
+		resp.Bytes += int64(size)
+		batch, size = nil, 0
+		return nil
+	}
+
+	for first := true; ; first = false {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if first {
+			namespace, dryRun = req.Namespace, req.DryRun
+			if _, err := s.namespaceStore(namespace); err != nil {
+				return err
+			}
+		}
+
+		for _, pair := range req.Kvs {
+			if err := s.checkWrite(pair.Key, pair.Value); err != nil {
+				st := status.Convert(err)
+				return status.Errorf(st.Code(), "key %q: %s", pair.Key, st.Message())
+			}
+			batch = append(batch, pair)
+			size += len(pair.Key) + len(pair.Value)
+			if size >= importBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	s.logger.Info("imported keys", "namespace", namespaceName(namespace), "keys", resp.Keys, "bytes", resp.Bytes, "dry_run", dryRun)
+	return stream.SendAndClose(resp)
+}
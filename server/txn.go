@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// Txn implements raftdv1.KVServiceServer.
+func (s *Raftd) Txn(ctx context.Context, req *raftdv1.TxnRequest) (*raftdv1.TxnResponse, error) {
+	if s.raftEngine.State() != raft.Leader {
+		return nil, status.Errorf(codes.FailedPrecondition, "not the leader")
+	}
+	for _, ops := range [][]*raftdv1.TxnOp{req.Success, req.Failure} {
+		if err := s.checkTxnOps(ops); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := s.apply(ctx, &raftdv1.Command{
+		Op:        "txn",
+		Namespace: req.Namespace,
+		Compares:  req.Compares,
+		Success:   req.Success,
+		Failure:   req.Failure,
+		ClientId:  req.Session.GetClientId(),
+		Sequence:  req.Session.GetSequence(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &raftdv1.TxnResponse{Succeeded: result.Succeeded, Revision: result.Revision}, nil
+}
+
+// checkTxnOps rejects a branch of a transaction that writes a key twice,
+// or whose sets checkWrite rejects.
+func (s *Raftd) checkTxnOps(ops []*raftdv1.TxnOp) error {
+	seen := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		if seen[op.Key] {
+			return status.Errorf(codes.InvalidArgument, "key %q is written twice", op.Key)
+		}
+		seen[op.Key] = true
+		if op.Delete {
+			continue
+		}
+		if err := s.checkWrite(op.Key, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
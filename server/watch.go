@@ -0,0 +1,93 @@
+package server
+
+import (
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	raftdv1 "github.com/amjadjibon/raftd/gen/raftd/v1"
+)
+
+// Watch implements raftdv1.KVServiceServer. It follows the store of the
+// namespace it started with, so the deletions of a deleted namespace are
+// sent before the call ends.
+func (s *Raftd) Watch(req *raftdv1.WatchRequest, stream grpc.ServerStreamingServer[raftdv1.WatchResponse]) error {
+	kv, err := s.namespaceStore(req.Namespace)
+	if err != nil {
+		return err
+	}
+	// A revision this node has not applied yet is waited for, so that a
+	// watch can resume on a node lagging behind the previous one.
+	after := req.AfterRevision
+	if after == 0 {
+		after = s.fsm.revision.Load()
+	}
+	if err := stream.Send(&raftdv1.WatchResponse{Revision: after}); err != nil {
+		return err
+	}
+
+	watched := func(key string) bool {
+		if req.Prefix {
+			return strings.HasPrefix(key, req.Key)
+		}
+		return key == req.Key
+	}
+
+	ctx := stream.Context()
+	for {
+		// Take the channel before reading, so that a change applied in
+		// between is not missed.
+		changed := s.fsm.changes.next()
+
+		if after < kv.Compacted() {
+			return compactedError(kv, after)
+		}
+
+		// The store holds every change up to the revision applied before
+		// it is read, so an empty batch lets an idle watch move along with
+		// the log and stay ahead of the compactions.
+		revision := s.fsm.revision.Load()
+		versions := kv.Changes(after, changeBatchSize)
+		if len(versions) > 0 {
+			var changes []*raftdv1.Change
+			for _, v := range versions {
+				if !watched(v.Key) {
+					continue
+				}
+				changes = append(changes, &raftdv1.Change{
+					Revision:  v.Revision,
+					Namespace: namespaceName(req.Namespace),
+					Key:       v.Key,
+					Value:     v.Value,
+					Deleted:   v.Deleted,
+				})
+			}
+			if len(changes) > 0 {
+				if err := stream.Send(&raftdv1.WatchResponse{Changes: changes}); err != nil {
+					return err
+				}
+			}
+			after = versions[len(versions)-1].Revision
+			continue
+		}
+		switch current := s.fsm.namespaces.store(req.Namespace); {
+		case current == nil:
+			return status.Errorf(codes.NotFound, "namespace not found")
+		case current != kv:
+			// The namespace was deleted and created again, or restored
+			// from a snapshot: the watch resumes on the new store.
+			return status.Errorf(codes.Unavailable, "namespace was replaced, watch again after revision %d", after)
+		}
+		after = max(after, revision)
+
+		select {
+		case <-changed:
+		case <-s.shutdown:
+			return status.Errorf(codes.Unavailable, "server is shutting down")
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
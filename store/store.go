@@ -1,26 +1,145 @@
 package store
 
 import (
+	"bytes"
+	"cmp"
 	"errors"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 )
 
+var (
+	// ErrNotInteger is returned by Add when the key holds something other
+	// than a base 10 int64.
+	ErrNotInteger = errors.New("value is not an integer")
+
+	// ErrOutOfRange is returned by Add when the result would overflow or
+	// leave its bounds.
+	ErrOutOfRange = errors.New("value out of range")
+
+	// ErrCompacted is returned by GetAt and RangeAt for revisions before
+	// the compaction point, whose history is gone.
+	ErrCompacted = errors.New("revision is compacted")
+)
+
+type KeyValue struct {
+	Key   string
+	Value []byte
+}
+
+// Version is a value written to a key at a revision, or its deletion.
+type Version struct {
+	Key      string
+	Value    []byte
+	Revision uint64
+	Deleted  bool
+}
+
+// Store holds the current value of every key along with its history. Every
+// write records a version at the revision given by the caller, which must
+// grow from one write to the next. Compact drops the versions superseded
+// before a revision.
 type Store struct {
 	mu sync.Mutex
 	kv map[string][]byte
+	// size is the total length of the keys and values in kv.
+	size int64
+	// history holds the versions of every key in revision order, the last
+	// one being the current value or deletion.
+	history map[string][]Version
+	// historySize is the total length of the keys and values in history.
+	historySize int64
+	compacted   uint64
 }
 
 func New() *Store {
 	return &Store{
-		kv: make(map[string][]byte),
+		kv:      make(map[string][]byte),
+		history: make(map[string][]Version),
 	}
 }
 
-func (s *Store) Set(key string, value []byte) error {
+// Set sets key to value at rev and returns the previous value, if any.
+func (s *Store) Set(key string, value []byte, rev uint64) (prev []byte, ok bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	prev, ok = s.kv[key]
+	s.put(key, prev, ok, value, rev)
+	return prev, ok
+}
+
+// CompareAndSwap sets key to value at rev if it currently holds expected,
+// or if it does not exist when expected is nil. It returns the value held
+// before, if any, and whether the swap happened.
+func (s *Store) CompareAndSwap(key string, expected, value []byte, rev uint64) (prev []byte, ok, swapped bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok = s.kv[key]
+	if expected == nil {
+		if ok {
+			return prev, ok, false
+		}
+	} else if !ok || !bytes.Equal(prev, expected) {
+		return prev, ok, false
+	}
+	s.put(key, prev, ok, value, rev)
+	return prev, ok, true
+}
+
+// Add adds delta at rev to the integer held by key, starting from initial
+// if the key does not exist, and returns the value held before and the
+// result. The result must lie within min and max; otherwise, as on any
+// error, the key is left unchanged.
+func (s *Store) Add(key string, delta, initial, min, max int64, rev uint64) (prev []byte, ok bool, n int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok = s.kv[key]
+	n = initial
+	if ok {
+		n, err = strconv.ParseInt(string(prev), 10, 64)
+		if err != nil {
+			return prev, ok, 0, ErrNotInteger
+		}
+	}
+
+	if delta > 0 && n > math.MaxInt64-delta || delta < 0 && n < math.MinInt64-delta {
+		return prev, ok, 0, ErrOutOfRange
+	}
+	n += delta
+	if n < min || n > max {
+		return prev, ok, 0, ErrOutOfRange
+	}
+
+	s.put(key, prev, ok, []byte(strconv.FormatInt(n, 10)), rev)
+	return prev, ok, n, nil
+}
+
+// put sets key to value at rev, accounting for prev if the key existed. It
+// must be called with mu held.
+func (s *Store) put(key string, prev []byte, ok bool, value []byte, rev uint64) {
+	if ok {
+		s.size -= int64(len(key) + len(prev))
+	}
+	s.size += int64(len(key) + len(value))
 	s.kv[key] = value
-	return nil
+	s.record(Version{Key: key, Value: value, Revision: rev})
+}
+
+// record appends v to the history of its key. It must be called with mu
+// held.
+func (s *Store) record(v Version) {
+	s.history[v.Key] = append(s.history[v.Key], v)
+	s.historySize += v.size()
+}
+
+// size is the length of the key and value of v.
+func (v Version) size() int64 {
+	return int64(len(v.Key) + len(v.Value))
 }
 
 func (s *Store) Get(key string) ([]byte, error) {
@@ -33,11 +152,71 @@ func (s *Store) Get(key string) ([]byte, error) {
 	return value, nil
 }
 
-func (s *Store) Delete(key string) error {
+// GetAt returns the value key had at rev.
+func (s *Store) GetAt(key string, rev uint64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rev < s.compacted {
+		return nil, ErrCompacted
+	}
+	v, ok := versionAt(s.history[key], rev)
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+	return v.Value, nil
+}
+
+// versionAt returns the version current at rev, unless the key did not
+// exist then.
+func versionAt(versions []Version, rev uint64) (Version, bool) {
+	i := sort.Search(len(versions), func(i int) bool {
+		return versions[i].Revision > rev
+	})
+	if i == 0 || versions[i-1].Deleted {
+		return Version{}, false
+	}
+	return versions[i-1], true
+}
+
+// Delete removes key at rev and returns its value, if it existed.
+func (s *Store) Delete(key string, rev uint64) (prev []byte, ok bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	delete(s.kv, key)
-	return nil
+	prev, ok = s.kv[key]
+	if ok {
+		s.size -= int64(len(key) + len(prev))
+		delete(s.kv, key)
+		s.record(Version{Key: key, Revision: rev, Deleted: true})
+	}
+	return prev, ok
+}
+
+// Undo reverts the write to key at rev, if it is the last one, as if it
+// never happened.
+func (s *Store) Undo(key string, rev uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := s.history[key]
+	if len(versions) == 0 || versions[len(versions)-1].Revision != rev {
+		return
+	}
+
+	if value, ok := s.kv[key]; ok {
+		s.size -= int64(len(key) + len(value))
+		delete(s.kv, key)
+	}
+	s.historySize -= versions[len(versions)-1].size()
+	versions = versions[:len(versions)-1]
+	if len(versions) == 0 {
+		delete(s.history, key)
+		return
+	}
+	s.history[key] = versions
+	if last := versions[len(versions)-1]; !last.Deleted {
+		s.kv[key] = last.Value
+		s.size += int64(len(key) + len(last.Value))
+	}
 }
 
 func (s *Store) Keys() []string {
@@ -49,3 +228,173 @@ func (s *Store) Keys() []string {
 	}
 	return keys
 }
+
+// Range returns the pairs whose key starts with prefix in key order. A
+// limit of zero or less returns all of them.
+func (s *Store) Range(prefix string, limit int) []KeyValue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kvs := make([]KeyValue, 0)
+	for key, value := range s.kv {
+		if strings.HasPrefix(key, prefix) {
+			kvs = append(kvs, KeyValue{Key: key, Value: value})
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+	if limit > 0 && len(kvs) > limit {
+		kvs = kvs[:limit]
+	}
+	return kvs
+}
+
+// RangeAt is Range at rev.
+func (s *Store) RangeAt(prefix string, limit int, rev uint64) ([]KeyValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rev < s.compacted {
+		return nil, ErrCompacted
+	}
+	kvs := make([]KeyValue, 0)
+	for key, versions := range s.history {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if v, ok := versionAt(versions, rev); ok {
+			kvs = append(kvs, KeyValue{Key: key, Value: v.Value})
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+	if limit > 0 && len(kvs) > limit {
+		kvs = kvs[:limit]
+	}
+	return kvs, nil
+}
+
+// Compact drops the versions superseded at rev, keeping those needed to
+// read any revision from rev on, and returns how many it dropped.
+// Compacting to a revision at or below an earlier one does nothing.
+func (s *Store) Compact(rev uint64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rev <= s.compacted {
+		return 0
+	}
+	s.compacted = rev
+
+	dropped := 0
+	for key, versions := range s.history {
+		// Keep the version current at rev, unless it is a deletion.
+		i := sort.Search(len(versions), func(i int) bool {
+			return versions[i].Revision > rev
+		}) - 1
+		if i >= 0 && versions[i].Deleted {
+			i++
+		}
+		if i <= 0 {
+			continue
+		}
+
+		dropped += i
+		for _, v := range versions[:i] {
+			s.historySize -= v.size()
+		}
+		if i == len(versions) {
+			delete(s.history, key)
+			continue
+		}
+		s.history[key] = slices.Clone(versions[i:])
+	}
+	return dropped
+}
+
+// Changes returns up to limit versions written after rev, in revision
+// order, or more so as not to split the versions of a revision. It scans
+// the whole history, so callers should ask for batches rather than single
+// versions. Versions dropped by compaction are missing.
+func (s *Store) Changes(rev uint64, limit int) []Version {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var versions []Version
+	for _, kv := range s.history {
+		i := sort.Search(len(kv), func(i int) bool {
+			return kv[i].Revision > rev
+		})
+		versions = append(versions, kv[i:]...)
+	}
+	slices.SortFunc(versions, func(a, b Version) int {
+		return cmp.Compare(a.Revision, b.Revision)
+	})
+	if limit > 0 && len(versions) > limit {
+		for limit < len(versions) && versions[limit].Revision == versions[limit-1].Revision {
+			limit++
+		}
+		versions = versions[:limit]
+	}
+	return versions
+}
+
+// Compacted returns the revision the store was last compacted to.
+func (s *Store) Compacted() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compacted
+}
+
+// History returns every version kept, for snapshots.
+func (s *Store) History() []Version {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var versions []Version
+	for _, kv := range s.history {
+		versions = append(versions, kv...)
+	}
+	return versions
+}
+
+// Restore replaces the contents of the store with the versions returned by
+// History, compacted to compacted.
+func (s *Store) Restore(versions []Version, compacted uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.kv = make(map[string][]byte)
+	s.history = make(map[string][]Version)
+	s.size = 0
+	s.historySize = 0
+	s.compacted = compacted
+
+	versions = slices.Clone(versions)
+	slices.SortStableFunc(versions, func(a, b Version) int {
+		return cmp.Compare(a.Revision, b.Revision)
+	})
+	for _, v := range versions {
+		s.record(v)
+	}
+	for key, kv := range s.history {
+		if last := kv[len(kv)-1]; !last.Deleted {
+			s.kv[key] = last.Value
+			s.size += int64(len(key) + len(last.Value))
+		}
+	}
+}
+
+// Size returns the total length of the keys and values held.
+func (s *Store) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// HistorySize returns the total length of the keys and values of every
+// version kept, the current ones included. Unlike Size, it only shrinks
+// when the store is compacted.
+func (s *Store) HistorySize() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.historySize
+}